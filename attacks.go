@@ -1,42 +1,45 @@
 package gremlin
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
 	uuid "github.com/satori/go.uuid"
 )
 
-// TODO: Implement all attack endpoints
-//
-// - GET /attacks/active
-// - GET /attacks/completed
-// - GET /attacks/{guid}
-// - DELETE /attacks/{guid}
-// - GET /attacks
-// - DELETE /attacks
+// defaultAttackLength is used by Gremlin when an attack omits --length.
+const defaultAttackLength = 60 * time.Second
 
 // CreateAttack will launch a new attack in Gremlin against one of your configured
 // clients. If the request succeeds, you will receive a UUID for the newly created
 // attack.
 func (c *Client) CreateAttack(ac AttackCommand) (*uuid.UUID, error) {
-	rurl := c.resourceURL("attacks/new")
+	return c.CreateAttackContext(context.Background(), ac)
+}
+
+// CreateAttackContext is like CreateAttack but carries a context for
+// cancellation and policy overrides (see WithPolicyOverride). The attack is
+// checked against the client blackout calendar and Policy before it is sent.
+func (c *Client) CreateAttackContext(ctx context.Context, ac AttackCommand) (*uuid.UUID, error) {
+	if err := c.Preflight(ctx, ac); err != nil {
+		return nil, err
+	}
 
 	attackJSON, err := json.Marshal(ac)
 	if err != nil {
 		return nil, fmt.Errorf("Failed to marshal attack JSON: %v", err)
 	}
 
-	req, err := http.NewRequest("POST", rurl.String(), strings.NewReader(string(attackJSON)))
+	req, err := c.newRequest(ctx, "POST", "attacks/new", strings.NewReader(string(attackJSON)))
 	if err != nil {
-		return nil, fmt.Errorf("Failed to create request object: %v", err)
+		return nil, err
 	}
 
-	req.Header.Set("Authorization", c.Token.Header)
-	req.Header.Set("Content-Type", "application/json")
-
 	bs, err := c.dispatchRequest(req, http.StatusCreated)
 	if err != nil {
 		return nil, err
@@ -50,3 +53,94 @@ func (c *Client) CreateAttack(ac AttackCommand) (*uuid.UUID, error) {
 
 	return &guid, nil
 }
+
+// Preflight runs the checks every launch must pass, without launching:
+// blackout windows first, since they need no API calls, then the Policy. It
+// returns the error that would block the attack, such as a *BlackoutError or
+// a *PolicyViolation.
+func (c *Client) Preflight(ctx context.Context, ac AttackCommand) error {
+	if err := c.checkBlackouts(ac.Command.Length()); err != nil {
+		return err
+	}
+	return c.checkPolicy(ctx, ac)
+}
+
+// ListAttacks returns both active and completed attacks.
+func (c *Client) ListAttacks(ctx context.Context) ([]Attack, error) {
+	var attacks []Attack
+	if err := c.getJSON(ctx, "attacks", &attacks); err != nil {
+		return nil, err
+	}
+	return attacks, nil
+}
+
+// ListActiveAttacks returns every attack that has not yet reached a final stage.
+func (c *Client) ListActiveAttacks(ctx context.Context) ([]Attack, error) {
+	var attacks []Attack
+	if err := c.getJSON(ctx, "attacks/active", &attacks); err != nil {
+		return nil, err
+	}
+	return attacks, nil
+}
+
+// Length returns how long the command will run, read from the -l/--length
+// argument in seconds. Commands without a length run for the Gremlin default
+// of 60 seconds.
+func (cmd Command) Length() time.Duration {
+	for i, arg := range cmd.Args {
+		var value string
+		switch {
+		case arg == "-l" || arg == "--length":
+			if i+1 < len(cmd.Args) {
+				value = cmd.Args[i+1]
+			}
+		case strings.HasPrefix(arg, "--length="):
+			value = strings.TrimPrefix(arg, "--length=")
+		default:
+			continue
+		}
+
+		if secs, err := strconv.Atoi(value); err == nil && secs > 0 {
+			return time.Duration(secs) * time.Second
+		}
+	}
+
+	return defaultAttackLength
+}
+
+// ListCompletedAttacks returns recently finished attacks.
+func (c *Client) ListCompletedAttacks(ctx context.Context) ([]Attack, error) {
+	var attacks []Attack
+	if err := c.getJSON(ctx, "attacks/completed", &attacks); err != nil {
+		return nil, err
+	}
+	return attacks, nil
+}
+
+// GetAttack fetches the current state of a single attack.
+func (c *Client) GetAttack(ctx context.Context, guid uuid.UUID) (*Attack, error) {
+	attack := &Attack{}
+	if err := c.getJSON(ctx, "attacks/"+guid.String(), attack); err != nil {
+		return nil, err
+	}
+	return attack, nil
+}
+
+// ListExecutions returns the per-host executions of an attack.
+func (c *Client) ListExecutions(ctx context.Context, guid uuid.UUID) ([]Execution, error) {
+	var executions []Execution
+	if err := c.getJSON(ctx, "executions?taskId="+guid.String(), &executions); err != nil {
+		return nil, err
+	}
+	return executions, nil
+}
+
+// HaltAttack stops a running attack.
+func (c *Client) HaltAttack(ctx context.Context, guid uuid.UUID) error {
+	return c.deleteResource(ctx, "attacks/"+guid.String())
+}
+
+// HaltAllAttacks stops every active attack in the organization.
+func (c *Client) HaltAllAttacks(ctx context.Context) error {
+	return c.deleteResource(ctx, "attacks")
+}
@@ -1,6 +1,7 @@
 package gremlin
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -8,6 +9,7 @@ import (
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 )
 
 // helper methods
@@ -17,7 +19,7 @@ func setup() (mux *http.ServeMux, client *Client, teardown func()) {
 	server := httptest.NewServer(mux)
 
 	client = NewClient("Test Org", "user@domain.com", "secret", WithURL(server.URL))
-	client.Token = &accessToken{Header: "Bearer fake-token"}
+	client.Token = &AccessToken{Header: "Bearer fake-token"}
 
 	return mux, client, server.Close
 }
@@ -126,3 +128,51 @@ func TestCreateAttackWithBadResponse(t *testing.T) {
 		}
 	}
 }
+
+func TestListActiveAttacksSuccess(t *testing.T) {
+	mux, client, teardown := setup()
+	defer teardown()
+
+	someGUID := "123e4567-e89b-12d3-a456-426655440000"
+
+	mux.HandleFunc("/attacks/active", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		testHeader(t, r, "Authorization", "Bearer fake-token")
+
+		fmt.Fprintf(w, `[{"guid":%q,"stage":"Running","command":{"type":"cpu"}}]`, someGUID)
+	})
+
+	attacks, err := client.ListActiveAttacks(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if got, want := len(attacks), 1; got != want {
+		t.Fatalf("Expected %d attack, but got %d", want, got)
+	}
+	if got := attacks[0].Guid.String(); got != someGUID {
+		t.Errorf("Expected guid to be %s, but got %s", someGUID, got)
+	}
+	if got, want := attacks[0].Command.Type, "cpu"; got != want {
+		t.Errorf("Expected command type to be %q, but got %q", want, got)
+	}
+}
+
+func TestCommandLength(t *testing.T) {
+	tests := []struct {
+		args []string
+		want time.Duration
+	}{
+		{nil, 60 * time.Second},
+		{[]string{"-c", "1", "--length", "5"}, 5 * time.Second},
+		{[]string{"-l", "120"}, 2 * time.Minute},
+		{[]string{"--length=30"}, 30 * time.Second},
+		{[]string{"--length"}, 60 * time.Second},
+	}
+
+	for _, tt := range tests {
+		if got := (Command{Type: "cpu", Args: tt.args}).Length(); got != tt.want {
+			t.Errorf("Length() of %v = %s, want %s", tt.args, got, tt.want)
+		}
+	}
+}
@@ -102,7 +102,7 @@ func TestAuthenticationSuccess(t *testing.T) {
 	client := NewClient(orgName, email, password)
 
 	accessTokenBuilt := AccessTokenBuilder.OrganizationName(orgName).Build()
-	mockSucessAuth(defaultURL, []accessToken{accessTokenBuilt})
+	mockSucessAuth(defaultURL, []AccessToken{accessTokenBuilt})
 	defer httpmock.DeactivateAndReset()
 
 	// When
@@ -196,7 +196,7 @@ func TestAuthenticationFailsWhenTokenForOrganizationNotFound(t *testing.T) {
 	client := NewClient(orgName, email, password)
 
 	accessTokenBuilt := AccessTokenBuilder.OrganizationName("Different Org").Build()
-	mockSucessAuth(defaultURL, []accessToken{accessTokenBuilt})
+	mockSucessAuth(defaultURL, []AccessToken{accessTokenBuilt})
 	defer httpmock.DeactivateAndReset()
 
 	// When
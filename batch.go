@@ -0,0 +1,193 @@
+package gremlin
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+// BatchOptions controls BatchCreateAttacks. Zero values use the defaults.
+type BatchOptions struct {
+	// Concurrency is how many attacks are launched at once (default 4).
+	Concurrency int
+
+	// FailFast stops launching once any attack fails to launch. The attacks
+	// that were never started fail with a *BatchAbortedError.
+	FailFast bool
+
+	// HaltOnFailure, together with FailFast, halts the attacks that had
+	// already launched when the batch stopped.
+	HaltOnFailure bool
+
+	// HaltTimeout bounds how long halting takes (default 30s).
+	HaltTimeout time.Duration
+}
+
+func (o BatchOptions) withDefaults() BatchOptions {
+	if o.Concurrency <= 0 {
+		o.Concurrency = 4
+	}
+	if o.HaltTimeout <= 0 {
+		o.HaltTimeout = 30 * time.Second
+	}
+	return o
+}
+
+// BatchResult is the outcome of one attack in a batch: either the GUID of the
+// launched attack or the error that stopped it.
+type BatchResult struct {
+	Guid *uuid.UUID
+	Err  error
+
+	// Halted is set when the attack launched but was halted because another
+	// attack in a fail-fast batch failed. HaltErr is set if that failed.
+	Halted  bool
+	HaltErr error
+}
+
+// BatchAbortedError is the error of an attack that was never launched because
+// the batch stopped first.
+type BatchAbortedError struct {
+	// Index is the position of the attack whose failure stopped the batch,
+	// or -1 if the context ended.
+	Index int
+	Cause error
+}
+
+func (e *BatchAbortedError) Error() string {
+	if e.Index < 0 {
+		return fmt.Sprintf("Attack not launched: %v", e.Cause)
+	}
+	return fmt.Sprintf("Attack not launched because attack %d failed: %v", e.Index, e.Cause)
+}
+
+// BatchError is returned by BatchCreateAttacks when any attack failed to
+// launch. The per-attack errors are in the results.
+type BatchError struct {
+	Failed int
+	Total  int
+
+	// First is the first launch failure in input order.
+	First error
+
+	// Unhalted counts the launched attacks that could not be halted.
+	Unhalted int
+}
+
+func (e *BatchError) Error() string {
+	msg := fmt.Sprintf("Failed to launch %d of %d attacks: %v", e.Failed, e.Total, e.First)
+	if e.Unhalted > 0 {
+		msg += fmt.Sprintf("; %d launched attacks could not be halted", e.Unhalted)
+	}
+	return msg
+}
+
+// BatchCreateAttacks launches many attacks at once using a bounded pool of
+// workers. Every launch passes the same checks as CreateAttackContext and
+// counts against the client rate limit (see WithRateLimit).
+//
+// The results are in the order of commands. By default every attack is
+// attempted; with FailFast the first failure stops further launches and, with
+// HaltOnFailure, halts the attacks that made it. The error is a *BatchError
+// if any attack failed to launch.
+func (c *Client) BatchCreateAttacks(ctx context.Context, commands []AttackCommand, opts BatchOptions) ([]BatchResult, error) {
+	opts = opts.withDefaults()
+	results := make([]BatchResult, len(commands))
+	launched := make([]bool, len(commands))
+
+	var mu sync.Mutex
+	var abort *BatchAbortedError
+	stopped := make(chan struct{})
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < opts.Concurrency && w < len(commands); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				// a job handed out just before the batch stopped is dropped
+				mu.Lock()
+				skip := abort != nil || ctx.Err() != nil
+				launched[i] = !skip
+				mu.Unlock()
+				if skip {
+					continue
+				}
+
+				guid, err := c.CreateAttackContext(ctx, commands[i])
+
+				mu.Lock()
+				results[i] = BatchResult{Guid: guid, Err: err}
+				if err != nil && opts.FailFast && abort == nil {
+					abort = &BatchAbortedError{Index: i, Cause: err}
+					close(stopped)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+dispatch:
+	for i := range commands {
+		select {
+		case jobs <- i:
+		case <-stopped:
+			break dispatch
+		case <-ctx.Done():
+			break dispatch
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	for i := range results {
+		if launched[i] {
+			continue
+		}
+		if abort != nil {
+			results[i].Err = abort
+		} else {
+			results[i].Err = &BatchAbortedError{Index: -1, Cause: ctx.Err()}
+		}
+	}
+
+	unhalted := 0
+	if abort != nil && opts.HaltOnFailure {
+		haltCtx, cancel := context.WithTimeout(context.Background(), opts.HaltTimeout)
+		defer cancel()
+
+		for i := range results {
+			if results[i].Guid == nil {
+				continue
+			}
+			if err := c.HaltAttack(haltCtx, *results[i].Guid); err != nil {
+				results[i].HaltErr = err
+				unhalted++
+			} else {
+				results[i].Halted = true
+			}
+		}
+	}
+
+	batchErr := &BatchError{Total: len(commands), Unhalted: unhalted}
+	for _, res := range results {
+		if res.Err == nil {
+			continue
+		}
+		if batchErr.First == nil {
+			batchErr.First = res.Err
+		}
+		batchErr.Failed++
+	}
+	if abort != nil {
+		batchErr.First = abort.Cause
+	}
+	if batchErr.Failed == 0 {
+		return results, nil
+	}
+	return results, batchErr
+}
@@ -0,0 +1,174 @@
+package gremlin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+// batchServer launches attacks with GUIDs derived from their target host,
+// fails launches against the hosts in fail, and records halts and the
+// highest number of launches in flight at once.
+type batchServer struct {
+	fail map[string]bool
+
+	mu          sync.Mutex
+	inFlight    int
+	maxInFlight int
+	launched    []string
+	halted      []string
+}
+
+func hostGUID(host string) uuid.UUID {
+	return uuid.NewV5(uuid.NamespaceOID, host)
+}
+
+func (s *batchServer) register(mux *http.ServeMux) {
+	mux.HandleFunc("/attacks/new", func(w http.ResponseWriter, r *http.Request) {
+		var ac AttackCommand
+		json.NewDecoder(r.Body).Decode(&ac)
+		host := ac.Target.Exact[0]
+
+		s.mu.Lock()
+		s.inFlight++
+		if s.inFlight > s.maxInFlight {
+			s.maxInFlight = s.inFlight
+		}
+		s.mu.Unlock()
+
+		time.Sleep(5 * time.Millisecond)
+
+		s.mu.Lock()
+		s.inFlight--
+		if !s.fail[host] {
+			s.launched = append(s.launched, host)
+		}
+		s.mu.Unlock()
+
+		if s.fail[host] {
+			http.Error(w, "no such client", http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprint(w, hostGUID(host))
+	})
+	mux.HandleFunc("/attacks/", func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.halted = append(s.halted, strings.TrimPrefix(r.URL.Path, "/attacks/"))
+		s.mu.Unlock()
+	})
+}
+
+func hostAttacks(n int) []AttackCommand {
+	var commands []AttackCommand
+	for i := 0; i < n; i++ {
+		ac := buildAttack()
+		ac.Target.Exact = []string{fmt.Sprintf("host-%d", i)}
+		commands = append(commands, ac)
+	}
+	return commands
+}
+
+func TestBatchCreateAttacksBestEffort(t *testing.T) {
+	mux, client, teardown := setup()
+	defer teardown()
+
+	s := &batchServer{fail: map[string]bool{"host-4": true}}
+	s.register(mux)
+
+	results, err := client.BatchCreateAttacks(context.Background(), hostAttacks(10), BatchOptions{Concurrency: 3})
+
+	be, ok := err.(*BatchError)
+	if !ok || be.Failed != 1 || be.Total != 10 {
+		t.Fatalf("Expected a BatchError with 1 of 10 failed, but got %v", err)
+	}
+	if !strings.Contains(err.Error(), "Failed to launch 1 of 10 attacks: Server failed to process request: status: 404") {
+		t.Errorf("Unexpected error message: %v", err)
+	}
+
+	for i, res := range results {
+		if i == 4 {
+			if res.Err == nil || res.Guid != nil {
+				t.Errorf("Expected attack 4 to fail, but got %+v", res)
+			}
+			continue
+		}
+		if res.Err != nil || res.Guid == nil || *res.Guid != hostGUID(fmt.Sprintf("host-%d", i)) {
+			t.Errorf("Expected attack %d to launch in order, but got %+v", i, res)
+		}
+	}
+
+	if len(s.launched) != 9 {
+		t.Errorf("Expected 9 attacks to launch, but got %v", s.launched)
+	}
+	if s.maxInFlight > 3 {
+		t.Errorf("Expected at most 3 launches at once, but got %d", s.maxInFlight)
+	}
+	if len(s.halted) != 0 {
+		t.Errorf("Expected no halts, but got %v", s.halted)
+	}
+}
+
+func TestBatchCreateAttacksFailFast(t *testing.T) {
+	mux, client, teardown := setup()
+	defer teardown()
+
+	s := &batchServer{fail: map[string]bool{"host-2": true}}
+	s.register(mux)
+
+	opts := BatchOptions{Concurrency: 1, FailFast: true, HaltOnFailure: true}
+	results, err := client.BatchCreateAttacks(context.Background(), hostAttacks(5), opts)
+
+	be, ok := err.(*BatchError)
+	if !ok || be.Failed != 3 || !strings.Contains(be.First.Error(), "status: 404") {
+		t.Fatalf("Expected a BatchError caused by host-2, but got %v", err)
+	}
+
+	for _, i := range []int{0, 1} {
+		if res := results[i]; res.Err != nil || !res.Halted {
+			t.Errorf("Expected attack %d to launch and be halted, but got %+v", i, res)
+		}
+	}
+	for _, i := range []int{3, 4} {
+		abort, ok := results[i].Err.(*BatchAbortedError)
+		if !ok || abort.Index != 2 || results[i].Guid != nil {
+			t.Errorf("Expected attack %d to be aborted by attack 2, but got %+v", i, results[i])
+		}
+	}
+
+	want := []string{hostGUID("host-0").String(), hostGUID("host-1").String()}
+	if !reflect.DeepEqual(s.halted, want) {
+		t.Errorf("Expected halts of %v, but got %v", want, s.halted)
+	}
+	if len(s.launched) != 2 {
+		t.Errorf("Expected no launches after the failure, but got %v", s.launched)
+	}
+}
+
+func TestBatchCreateAttacksCancelled(t *testing.T) {
+	mux, client, teardown := setup()
+	defer teardown()
+
+	(&batchServer{}).register(mux)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	results, err := client.BatchCreateAttacks(ctx, hostAttacks(3), BatchOptions{})
+	if be, ok := err.(*BatchError); !ok || be.Failed != 3 {
+		t.Fatalf("Expected every attack to fail, but got %v", err)
+	}
+	for i, res := range results {
+		if abort, ok := res.Err.(*BatchAbortedError); !ok || abort.Index != -1 || abort.Cause != context.Canceled {
+			t.Errorf("Expected attack %d to be aborted by the context, but got %+v", i, res)
+		}
+	}
+}
@@ -0,0 +1,317 @@
+package gremlin
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Calendar holds the blackout windows during which no attack may run. All
+// wall-clock times are interpreted in Location.
+type Calendar struct {
+	Location *time.Location
+
+	weekly []weeklyWindow
+	ranges []Blackout
+}
+
+// Blackout is a single occurrence of a blackout window.
+type Blackout struct {
+	Name  string
+	Start time.Time
+	End   time.Time
+}
+
+type weeklyWindow struct {
+	name       string
+	days       []time.Weekday
+	start, end time.Duration // offsets from midnight
+}
+
+// BlackoutError is returned when an attack would start during, or run into, a
+// blackout window.
+type BlackoutError struct {
+	Blackout
+}
+
+func (e *BlackoutError) Error() string {
+	return fmt.Sprintf("Attack blocked by blackout window %q (%s to %s)",
+		e.Name, e.Start.Format(time.RFC3339), e.End.Format(time.RFC3339))
+}
+
+// NewCalendar creates an empty calendar in the given timezone, or UTC if loc is nil.
+func NewCalendar(loc *time.Location) *Calendar {
+	if loc == nil {
+		loc = time.UTC
+	}
+	return &Calendar{Location: loc}
+}
+
+// WithBlackouts makes the Client refuse to launch attacks that would overlap
+// any window in the calendar.
+func WithBlackouts(cal *Calendar) ConfigOption {
+	return func(c *Client) error {
+		c.blackouts = cal
+		return nil
+	}
+}
+
+// AddWeekly adds a window that recurs on the given days between two "15:04"
+// wall-clock times. A window whose end is not after its start runs past
+// midnight, so AddWeekly("nights", weekdays, "18:00", "08:00") blocks every
+// weekday evening until the next morning.
+func (cal *Calendar) AddWeekly(name string, days []time.Weekday, start string, end string) error {
+	s, err := parseClock(start)
+	if err != nil {
+		return err
+	}
+	e, err := parseClock(end)
+	if err != nil {
+		return err
+	}
+	if e <= s {
+		e += 24 * time.Hour
+	}
+
+	cal.weekly = append(cal.weekly, weeklyWindow{name: name, days: days, start: s, end: e})
+	return nil
+}
+
+// AddRange adds a one-off window such as a release freeze.
+func (cal *Calendar) AddRange(name string, start time.Time, end time.Time) error {
+	if !end.After(start) {
+		return fmt.Errorf("Blackout %q must end after it starts", name)
+	}
+
+	cal.ranges = append(cal.ranges, Blackout{Name: name, Start: start, End: end})
+	return nil
+}
+
+// Check returns the first blackout overlapping an attack that starts at start
+// and runs for length, or nil if the attack is clear to run.
+func (cal *Calendar) Check(start time.Time, length time.Duration) *Blackout {
+	end := start.Add(length)
+
+	for _, b := range cal.ranges {
+		if b.Start.Before(end) && start.Before(b.End) {
+			b := b
+			return &b
+		}
+	}
+
+	// walk the local days the attack touches, starting the day before in
+	// case a window that began yesterday runs past midnight
+	local := start.In(cal.Location)
+	day := time.Date(local.Year(), local.Month(), local.Day()-1, 0, 0, 0, 0, cal.Location)
+	for !day.After(end) {
+		for _, w := range cal.weekly {
+			if !containsWeekday(w.days, day.Weekday()) {
+				continue
+			}
+			b := Blackout{Name: w.name, Start: addClock(day, w.start), End: addClock(day, w.end)}
+			if b.Start.Before(end) && start.Before(b.End) {
+				return &b
+			}
+		}
+		day = time.Date(day.Year(), day.Month(), day.Day()+1, 0, 0, 0, 0, cal.Location)
+	}
+
+	return nil
+}
+
+// checkBlackouts rejects a launch running for length from now that would
+// overlap a blackout window.
+func (c *Client) checkBlackouts(length time.Duration) error {
+	if c.blackouts == nil {
+		return nil
+	}
+	if b := c.blackouts.Check(time.Now(), length); b != nil {
+		return &BlackoutError{Blackout: *b}
+	}
+	return nil
+}
+
+func parseClock(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("Invalid time of day %q, expected HH:MM", s)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// addClock returns the wall-clock time offset from midnight on day, which
+// stays correct across daylight saving changes.
+func addClock(day time.Time, offset time.Duration) time.Time {
+	return time.Date(day.Year(), day.Month(), day.Day(), 0, int(offset/time.Minute), 0, 0, day.Location())
+}
+
+func containsWeekday(days []time.Weekday, d time.Weekday) bool {
+	for _, day := range days {
+		if day == d {
+			return true
+		}
+	}
+	return false
+}
+
+// LoadICS adds every event in an iCalendar (.ics) file as a blackout range.
+// Times without a timezone are read in the calendar Location. Recurring events
+// are rejected rather than silently ignored; use AddWeekly for those.
+func (cal *Calendar) LoadICS(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("Failed to open calendar: %v", err)
+	}
+	defer f.Close()
+
+	// unfold continuation lines, which begin with a space or tab
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if len(lines) > 0 && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("Failed to read calendar: %v", err)
+	}
+
+	var event map[string]icsProperty
+	var events []Blackout
+	for n, line := range lines {
+		switch {
+		case line == "BEGIN:VEVENT":
+			event = make(map[string]icsProperty)
+		case line == "END:VEVENT":
+			b, err := cal.icsEvent(event)
+			if err != nil {
+				return fmt.Errorf("Invalid event ending on line %d of %s: %v", n+1, path, err)
+			}
+			events = append(events, b)
+			event = nil
+		case event != nil:
+			prop := parseICSProperty(line)
+			event[prop.name] = prop
+		}
+	}
+
+	cal.ranges = append(cal.ranges, events...)
+	return nil
+}
+
+type icsProperty struct {
+	name   string
+	params map[string]string
+	value  string
+}
+
+func parseICSProperty(line string) icsProperty {
+	prop := icsProperty{params: make(map[string]string)}
+
+	sep := strings.Index(line, ":")
+	if sep < 0 {
+		prop.name = line
+		return prop
+	}
+	prop.value = line[sep+1:]
+
+	parts := strings.Split(line[:sep], ";")
+	prop.name = strings.ToUpper(parts[0])
+	for _, p := range parts[1:] {
+		if kv := strings.SplitN(p, "=", 2); len(kv) == 2 {
+			prop.params[strings.ToUpper(kv[0])] = strings.Trim(kv[1], `"`)
+		}
+	}
+	return prop
+}
+
+func (cal *Calendar) icsEvent(event map[string]icsProperty) (Blackout, error) {
+	if _, ok := event["RRULE"]; ok {
+		return Blackout{}, fmt.Errorf("recurring events (RRULE) are not supported")
+	}
+
+	dtstart, ok := event["DTSTART"]
+	if !ok {
+		return Blackout{}, fmt.Errorf("missing DTSTART")
+	}
+	start, allDay, err := cal.icsTime(dtstart)
+	if err != nil {
+		return Blackout{}, err
+	}
+
+	var end time.Time
+	if dtend, ok := event["DTEND"]; ok {
+		if end, _, err = cal.icsTime(dtend); err != nil {
+			return Blackout{}, err
+		}
+	} else if dur, ok := event["DURATION"]; ok {
+		d, err := parseICSDuration(dur.value)
+		if err != nil {
+			return Blackout{}, err
+		}
+		end = start.Add(d)
+	} else if allDay {
+		end = start.AddDate(0, 0, 1)
+	} else {
+		end = start
+	}
+
+	if !end.After(start) {
+		return Blackout{}, fmt.Errorf("event must end after it starts")
+	}
+
+	name := event["SUMMARY"].value
+	if name == "" {
+		name = event["UID"].value
+	}
+	return Blackout{Name: name, Start: start, End: end}, nil
+}
+
+func (cal *Calendar) icsTime(prop icsProperty) (time.Time, bool, error) {
+	loc := cal.Location
+	if tzid, ok := prop.params["TZID"]; ok {
+		l, err := time.LoadLocation(tzid)
+		if err != nil {
+			return time.Time{}, false, fmt.Errorf("unknown TZID %q", tzid)
+		}
+		loc = l
+	}
+
+	switch {
+	case prop.params["VALUE"] == "DATE" || len(prop.value) == 8:
+		t, err := time.ParseInLocation("20060102", prop.value, loc)
+		return t, true, err
+	case strings.HasSuffix(prop.value, "Z"):
+		t, err := time.Parse("20060102T150405Z", prop.value)
+		return t, false, err
+	default:
+		t, err := time.ParseInLocation("20060102T150405", prop.value, loc)
+		return t, false, err
+	}
+}
+
+var icsDurationRE = regexp.MustCompile(`^P(?:(\d+)W)?(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+)S)?)?$`)
+
+func parseICSDuration(s string) (time.Duration, error) {
+	m := icsDurationRE.FindStringSubmatch(s)
+	if m == nil {
+		return 0, fmt.Errorf("invalid DURATION %q", s)
+	}
+
+	units := []time.Duration{7 * 24 * time.Hour, 24 * time.Hour, time.Hour, time.Minute, time.Second}
+	var d time.Duration
+	for i, unit := range units {
+		if m[i+1] != "" {
+			n, _ := strconv.Atoi(m[i+1])
+			d += time.Duration(n) * unit
+		}
+	}
+	return d, nil
+}
@@ -0,0 +1,161 @@
+package gremlin
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func mustLoadLocation(t *testing.T, name string) *time.Location {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Skipf("Timezone %s unavailable: %v", name, err)
+	}
+	return loc
+}
+
+func TestCalendarWeeklyWindows(t *testing.T) {
+	loc := mustLoadLocation(t, "America/New_York")
+	cal := NewCalendar(loc)
+
+	weekdays := []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}
+	if err := cal.AddWeekly("after hours", weekdays, "18:00", "08:00"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := cal.AddWeekly("weekend", []time.Weekday{time.Saturday, time.Sunday}, "00:00", "00:00"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		start  time.Time
+		length time.Duration
+		want   string
+	}{
+		// Wednesday 2018-03-07
+		{time.Date(2018, 3, 7, 10, 0, 0, 0, loc), time.Hour, ""},
+		{time.Date(2018, 3, 7, 17, 30, 0, 0, loc), 10 * time.Minute, ""},
+		{time.Date(2018, 3, 7, 17, 55, 0, 0, loc), 10 * time.Minute, "after hours"},
+		{time.Date(2018, 3, 8, 7, 0, 0, 0, loc), time.Minute, "after hours"},
+		{time.Date(2018, 3, 8, 8, 0, 0, 0, loc), time.Minute, ""},
+		// Saturday, and the Sunday of the DST change
+		{time.Date(2018, 3, 10, 12, 0, 0, 0, loc), time.Minute, "weekend"},
+		{time.Date(2018, 3, 11, 23, 0, 0, 0, loc), time.Minute, "weekend"},
+		// the same Wednesday morning, expressed in UTC
+		{time.Date(2018, 3, 7, 15, 0, 0, 0, time.UTC), time.Hour, ""},
+	}
+
+	for _, tt := range tests {
+		b := cal.Check(tt.start, tt.length)
+		got := ""
+		if b != nil {
+			got = b.Name
+		}
+		if got != tt.want {
+			t.Errorf("Check(%s, %s) = %q, want %q", tt.start, tt.length, got, tt.want)
+		}
+	}
+}
+
+func TestCalendarAddWeeklyWithInvalidTime(t *testing.T) {
+	err := NewCalendar(nil).AddWeekly("bad", []time.Weekday{time.Monday}, "9am", "17:00")
+
+	if err == nil {
+		t.Errorf("Expected invalid time of day to result in error")
+	}
+}
+
+func TestCalendarLoadICS(t *testing.T) {
+	loc := mustLoadLocation(t, "Europe/Berlin")
+	ics := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"BEGIN:VEVENT",
+		"UID:1",
+		"SUMMARY:Release freeze for",
+		"  version 2.0",
+		"DTSTART;VALUE=DATE:20181120",
+		"DTEND;VALUE=DATE:20181127",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"SUMMARY:Peak sales",
+		"DTSTART;TZID=America/New_York:20181123T000000",
+		"DURATION:PT12H",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"SUMMARY:Maintenance",
+		"DTSTART:20181201T100000Z",
+		"DTEND:20181201T110000Z",
+		"END:VEVENT",
+		"END:VCALENDAR",
+	}, "\r\n")
+	path := writeTempFile(t, "freeze.ics", ics)
+	defer os.RemoveAll(filepath.Dir(path))
+
+	cal := NewCalendar(loc)
+	if err := cal.LoadICS(path); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		start time.Time
+		want  string
+	}{
+		{time.Date(2018, 11, 19, 23, 0, 0, 0, loc), ""},
+		{time.Date(2018, 11, 20, 0, 0, 0, 0, loc), "Release freeze for version 2.0"},
+		{time.Date(2018, 11, 28, 0, 0, 0, 0, loc), ""},
+		{time.Date(2018, 12, 1, 10, 30, 0, 0, time.UTC), "Maintenance"},
+	}
+
+	for _, tt := range tests {
+		b := cal.Check(tt.start, time.Minute)
+		got := ""
+		if b != nil {
+			got = b.Name
+		}
+		if got != tt.want {
+			t.Errorf("Check(%s) = %q, want %q", tt.start, got, tt.want)
+		}
+	}
+
+	if got, want := len(cal.ranges), 3; got != want {
+		t.Errorf("Expected %d events to be loaded, but got %d", want, got)
+	}
+}
+
+func TestCalendarLoadICSRejectsRecurringEvents(t *testing.T) {
+	ics := "BEGIN:VEVENT\nSUMMARY:Standup\nDTSTART:20181120T090000\nRRULE:FREQ=DAILY\nEND:VEVENT\n"
+	path := writeTempFile(t, "recurring.ics", ics)
+	defer os.RemoveAll(filepath.Dir(path))
+
+	err := NewCalendar(nil).LoadICS(path)
+
+	if err == nil {
+		t.Errorf("Expected recurring event to result in error")
+	} else {
+		if got, want := err.Error(), "RRULE"; !strings.Contains(got, want) {
+			t.Errorf("Expected error to match %q, but got %q", want, got)
+		}
+	}
+}
+
+func TestCreateAttackDuringBlackout(t *testing.T) {
+	_, client, teardown := setup()
+	defer teardown()
+
+	now := time.Now()
+	cal := NewCalendar(nil)
+	cal.AddRange("Release freeze", now.Add(2*time.Second), now.Add(time.Hour))
+	WithBlackouts(cal)(client)
+
+	// buildAttack runs for 5 seconds, so it would run into the freeze
+	_, err := client.CreateAttack(buildAttack())
+
+	berr, ok := err.(*BlackoutError)
+	if !ok {
+		t.Fatalf("Expected *BlackoutError, but got %v", err)
+	}
+	if got, want := berr.Name, "Release freeze"; got != want {
+		t.Errorf("Expected blackout window %q, but got %q", want, got)
+	}
+}
@@ -1,8 +1,10 @@
 package gremlin
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"net/url"
@@ -20,12 +22,15 @@ var (
 
 // Client manages communication with the Gremlin API
 type Client struct {
-	client   *http.Client
-	Company  string
-	BaseURL  *url.URL
-	Email    string
-	password string
-	Token    *accessToken
+	client    *http.Client
+	Company   string
+	BaseURL   *url.URL
+	Email     string
+	password  string
+	Token     *AccessToken
+	policy    Policy
+	blackouts *Calendar
+	limiter   *rateLimiter
 }
 
 // ConfigOption represents the type interface that can be used to add new
@@ -63,7 +68,7 @@ func NewClient(company string, email string, password string, options ...ConfigO
 		BaseURL:  defaultBaseURL,
 		Email:    email,
 		password: password,
-		Token:    &accessToken{},
+		Token:    &AccessToken{},
 	}
 
 	// apply any functional options
@@ -81,7 +86,7 @@ func NewClient(company string, email string, password string, options ...ConfigO
 //
 // All other API requests require an access token so a token will be required
 // prior to invoking other methods.
-func (c *Client) Authenticate() (*accessToken, error) {
+func (c *Client) Authenticate() (*AccessToken, error) {
 	rurl := c.resourceURL("users/auth")
 
 	// create request body and object
@@ -105,7 +110,7 @@ func (c *Client) Authenticate() (*accessToken, error) {
 	}
 
 	// marshall JSON response into object
-	var tokens []accessToken
+	var tokens []AccessToken
 	if err := json.Unmarshal(bs, &tokens); err != nil {
 		return nil, fmt.Errorf("Failed to marshall response: %s", err.Error())
 	}
@@ -122,15 +127,101 @@ func (c *Client) Authenticate() (*accessToken, error) {
 }
 
 // resourceURL safely joins a string path (e.g. "my/resource") to an existing URL.
+// Anything after a "?" in the path is used as the query string.
 func (c *Client) resourceURL(path string) *url.URL {
 	rel := &url.URL{Path: path}
+	if i := strings.Index(path, "?"); i >= 0 {
+		rel = &url.URL{Path: path[:i], RawQuery: path[i+1:]}
+	}
 	return c.BaseURL.ResolveReference(rel)
 }
 
+// newRequest creates an authorized API request for the given resource path. A
+// JSON content type is set whenever a body is supplied.
+func (c *Client) newRequest(ctx context.Context, method string, path string, body io.Reader) (*http.Request, error) {
+	rurl := c.resourceURL(path)
+
+	req, err := http.NewRequest(method, rurl.String(), body)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create request object: %v", err)
+	}
+
+	req = req.WithContext(ctx)
+	req.Header.Set("Authorization", c.Token.Header)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	return req, nil
+}
+
+// getJSON fetches a resource and unmarshals the JSON response into v.
+func (c *Client) getJSON(ctx context.Context, path string, v interface{}) error {
+	req, err := c.newRequest(ctx, "GET", path, nil)
+	if err != nil {
+		return err
+	}
+
+	bs, err := c.dispatchRequest(req, http.StatusOK)
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(bs, v); err != nil {
+		return fmt.Errorf("Failed to unmarshal response: %v", err)
+	}
+
+	return nil
+}
+
+// sendJSON marshals body, sends it with the given method and unmarshals the
+// JSON response into out, unless out is nil.
+func (c *Client) sendJSON(ctx context.Context, method string, path string, body interface{}, status int, out interface{}) error {
+	bodyJSON, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("Failed to marshal request JSON: %v", err)
+	}
+
+	req, err := c.newRequest(ctx, method, path, strings.NewReader(string(bodyJSON)))
+	if err != nil {
+		return err
+	}
+
+	bs, err := c.dispatchRequest(req, status)
+	if err != nil {
+		return err
+	}
+
+	if out != nil {
+		if err := json.Unmarshal(bs, out); err != nil {
+			return fmt.Errorf("Failed to unmarshal response: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// deleteResource issues a DELETE for the given resource path.
+func (c *Client) deleteResource(ctx context.Context, path string) error {
+	req, err := c.newRequest(ctx, "DELETE", path, nil)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.dispatchRequest(req, http.StatusOK)
+	return err
+}
+
 // dispatchRequest to server and return a byte slice containing the response body.
 // An error will be returned instead if the request fails or if the response
 // status does not match the expected one.
 func (c *Client) dispatchRequest(req *http.Request, status int) ([]byte, error) {
+	if c.limiter != nil {
+		if err := c.limiter.wait(req.Context()); err != nil {
+			return nil, err
+		}
+	}
+
 	resp, err := c.client.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("Request failed: %v", err)
@@ -0,0 +1,21 @@
+package gremlin
+
+import "context"
+
+// ListClients returns every host registered with Gremlin, both active and idle.
+func (c *Client) ListClients(ctx context.Context) ([]Host, error) {
+	var hosts []Host
+	if err := c.getJSON(ctx, "clients", &hosts); err != nil {
+		return nil, err
+	}
+	return hosts, nil
+}
+
+// ListContainers returns the containers reported by all active hosts.
+func (c *Client) ListContainers(ctx context.Context) ([]Container, error) {
+	var containers []Container
+	if err := c.getJSON(ctx, "containers", &containers); err != nil {
+		return nil, err
+	}
+	return containers, nil
+}
@@ -0,0 +1,55 @@
+package gremlin
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestListClientsSuccess(t *testing.T) {
+	mux, client, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/clients", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		testHeader(t, r, "Authorization", "Bearer fake-token")
+
+		fmt.Fprint(w, `[{"identifier":"web-1","state":"ACTIVE","tags":{"zone":"a"}},{"identifier":"web-2","state":"IDLE"}]`)
+	})
+
+	hosts, err := client.ListClients(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if got, want := len(hosts), 2; got != want {
+		t.Fatalf("Expected %d hosts, but got %d", want, got)
+	}
+	if got, want := hosts[0].Tags["zone"], "a"; got != want {
+		t.Errorf("Expected first host zone tag to be %q, but got %q", want, got)
+	}
+	if got, want := hosts[1].State, HostIdle; got != want {
+		t.Errorf("Expected second host state to be %q, but got %q", want, got)
+	}
+}
+
+func TestListContainersWithBadResponse(t *testing.T) {
+	mux, client, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/containers", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "not json")
+	})
+
+	_, err := client.ListContainers(context.Background())
+
+	if err == nil {
+		t.Errorf("Expected invalid response to result in error")
+	} else {
+		if got, want := err.Error(), "Failed to unmarshal response:"; !strings.Contains(got, want) {
+			t.Errorf("Expected error to match %q, but got %q", want, got)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+// Command gremlin-watchdog halts a Gremlin attack when the heartbeat file
+// written by its controller goes stale. Run it as a separate process (or on a
+// separate machine sharing the file) so the attack is halted even if the
+// controller crashes:
+//
+//	gremlin-watchdog -attack 123e4567-... -heartbeat /run/chaos/heartbeat -threshold 30s -length 10m
+//
+// Credentials come from the library credential chain, see gremlin.LoadConfig.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+	gremlin "github.com/sonnysideup/go-gremlin"
+)
+
+func main() {
+	attack := flag.String("attack", "", "GUID of the attack to guard (required)")
+	heartbeat := flag.String("heartbeat", "", "heartbeat file written by the controller (required)")
+	threshold := flag.Duration("threshold", 30*time.Second, "halt when the heartbeat is older than this")
+	length := flag.Duration("length", 0, "stop guarding after this long (default: until killed)")
+	retries := flag.Int("retries", 5, "halt retries before giving up (0 to disable)")
+	flag.Parse()
+
+	guid, err := uuid.FromString(*attack)
+	if err != nil || *heartbeat == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	cfg, err := gremlin.LoadConfig(gremlin.DefaultConfigPath())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	client := cfg.NewClient()
+	if _, err := client.Authenticate(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	opts := gremlin.WatchdogOptions{
+		HeartbeatPath: *heartbeat,
+		Threshold:     *threshold,
+		HaltRetries:   retries,
+		Logger:        log.New(os.Stderr, "gremlin-watchdog: ", log.LstdFlags),
+	}
+	if *length > 0 {
+		opts.Until = time.Now().Add(*length)
+	}
+
+	if err := client.NewWatchdog(guid, opts).Run(context.Background()); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,245 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+	gremlin "github.com/sonnysideup/go-gremlin"
+	"github.com/sonnysideup/go-gremlin/printer"
+)
+
+var attackCommands = map[string]command{
+	"create":   cmdAttackCreate,
+	"list":     cmdAttackList,
+	"get":      cmdAttackGet,
+	"halt":     cmdAttackHalt,
+	"halt-all": cmdAttackHaltAll,
+	"watch":    cmdAttackWatch,
+}
+
+// table returns a writer that aligns tab separated columns on stdout.
+func (a *app) table() *tabwriter.Writer {
+	return tabwriter.NewWriter(a.stdout, 0, 4, 2, ' ', 0)
+}
+
+// parseGUID reads the single attack GUID argument of a command.
+func parseGUID(name string, args []string) (uuid.UUID, error) {
+	if len(args) != 1 {
+		return uuid.UUID{}, usagef("usage: gremlin %s <attack guid>", name)
+	}
+	guid, err := uuid.FromString(args[0])
+	if err != nil {
+		return uuid.UUID{}, usagef("invalid attack guid %q", args[0])
+	}
+	return guid, nil
+}
+
+func cmdAttackCreate(a *app, args []string) error {
+	fs := a.newFlagSet("attack create")
+	target := fs.String("target", "random", "target selector, e.g. 'tags(zone=us-east-1a) random(10%)'")
+	if err := a.parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	if fs.NArg() == 0 {
+		return usagef("usage: gremlin attack create [-target selector] <type> [attack args]")
+	}
+	sel, err := gremlin.ParseSelector(*target)
+	if err != nil {
+		return usagef("%v", err)
+	}
+
+	client, err := a.client()
+	if err != nil {
+		return err
+	}
+
+	ac := sel.AttackCommand(gremlin.Command{Type: fs.Arg(0), Args: fs.Args()[1:]})
+	guid, err := client.CreateAttackContext(context.Background(), ac)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(a.stdout, guid)
+	return nil
+}
+
+func cmdAttackList(a *app, args []string) error {
+	fs := a.newFlagSet("attack list")
+	active := fs.Bool("active", false, "only list active attacks")
+	completed := fs.Bool("completed", false, "only list completed attacks")
+	output := addOutputFlags(fs)
+	if err := a.parseFlags(fs, args); err != nil {
+		return err
+	}
+	p, err := a.printer(output)
+	if err != nil {
+		return err
+	}
+	if *active && *completed {
+		return usagef("-active and -completed are mutually exclusive")
+	}
+
+	client, err := a.client()
+	if err != nil {
+		return err
+	}
+
+	var attacks []gremlin.Attack
+	ctx := context.Background()
+	switch {
+	case *active:
+		attacks, err = client.ListActiveAttacks(ctx)
+	case *completed:
+		attacks, err = client.ListCompletedAttacks(ctx)
+	default:
+		attacks, err = client.ListAttacks(ctx)
+	}
+	if err != nil {
+		return err
+	}
+
+	return p.Print(a.stdout, attacks)
+}
+
+func cmdAttackGet(a *app, args []string) error {
+	fs := a.newFlagSet("attack get")
+	output := addOutputFlags(fs)
+	if err := a.parseFlags(fs, args); err != nil {
+		return err
+	}
+	p, err := a.printer(output)
+	if err != nil {
+		return err
+	}
+	guid, err := parseGUID("attack get", fs.Args())
+	if err != nil {
+		return err
+	}
+
+	client, err := a.client()
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	attack, err := client.GetAttack(ctx, guid)
+	if err != nil {
+		return err
+	}
+	executions, err := client.ListExecutions(ctx, guid)
+	if err != nil {
+		return err
+	}
+
+	return printAttack(a, p, attack, executions)
+}
+
+// attackDetails is an attack with its executions, as printed by attack get
+// and wait in formats other than a table.
+type attackDetails struct {
+	*gremlin.Attack
+	Executions []gremlin.Execution `json:"executions"`
+}
+
+func printAttack(a *app, p *printer.Printer, attack *gremlin.Attack, executions []gremlin.Execution) error {
+	if p.Format() != printer.FormatTable {
+		return p.Print(a.stdout, attackDetails{Attack: attack, Executions: executions})
+	}
+
+	w := a.table()
+	fmt.Fprintf(w, "GUID:\t%s\n", attack.Guid)
+	fmt.Fprintf(w, "Command:\t%s %s\n", attack.Command.Type, strings.Join(attack.Command.Args, " "))
+	fmt.Fprintf(w, "Stage:\t%s\n", attack.Stage)
+	fmt.Fprintf(w, "Started:\t%s\n", printer.FormatTime(attack.StartTime))
+	fmt.Fprintf(w, "Ended:\t%s\n", printer.FormatTime(attack.EndTime))
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	if len(executions) == 0 {
+		return nil
+	}
+
+	fmt.Fprintln(a.stdout)
+	return p.Print(a.stdout, executions)
+}
+
+func cmdAttackHalt(a *app, args []string) error {
+	guid, err := parseGUID("attack halt", args)
+	if err != nil {
+		return err
+	}
+
+	client, err := a.client()
+	if err != nil {
+		return err
+	}
+
+	if err := client.HaltAttack(context.Background(), guid); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(a.stdout, "Halted %s\n", guid)
+	return nil
+}
+
+func cmdAttackHaltAll(a *app, args []string) error {
+	if len(args) != 0 {
+		return usagef("usage: gremlin attack halt-all")
+	}
+
+	client, err := a.client()
+	if err != nil {
+		return err
+	}
+
+	if err := client.HaltAllAttacks(context.Background()); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(a.stdout, "Halted all active attacks")
+	return nil
+}
+
+func cmdWait(a *app, args []string) error {
+	fs := a.newFlagSet("wait")
+	timeout := fs.Duration("timeout", 0, "give up after this long (default: wait forever)")
+	interval := fs.Duration("interval", 2*time.Second, "initial polling interval")
+	output := addOutputFlags(fs)
+	if err := a.parseFlags(fs, args); err != nil {
+		return err
+	}
+	p, err := a.printer(output)
+	if err != nil {
+		return err
+	}
+	guid, err := parseGUID("wait", fs.Args())
+	if err != nil {
+		return err
+	}
+
+	client, err := a.client()
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	if *timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *timeout)
+		defer cancel()
+	}
+
+	attack, executions, err := client.WaitForAttack(ctx, guid, gremlin.WaitOptions{Interval: *interval})
+	if attack != nil && executions != nil {
+		if err := printAttack(a, p, attack, executions); err != nil {
+			return err
+		}
+	}
+	return err
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+func cmdLogin(a *app, args []string) error {
+	fs := a.newFlagSet("login")
+	if err := a.parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	cfg, err := a.config()
+	if err != nil {
+		return err
+	}
+	if cfg.Company == "" || cfg.Email == "" || cfg.Password == "" {
+		return usagef("login needs a company, email and password in %s or the environment", a.configPath)
+	}
+
+	client := cfg.NewClient()
+	token, err := client.Authenticate()
+	if err != nil {
+		return err
+	}
+	if err := client.SaveToken(cfg.TokenPath); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(a.stdout, "Logged in to %s as %s (token expires %s)\n",
+		token.OrganizationName, cfg.Email, token.ExpiresAt.Format(time.RFC3339))
+	return nil
+}
+
+func cmdWhoami(a *app, args []string) error {
+	fs := a.newFlagSet("whoami")
+	if err := a.parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	client, err := a.client()
+	if err != nil {
+		return err
+	}
+
+	w := a.table()
+	fmt.Fprintf(w, "Email:\t%s\n", client.Email)
+	fmt.Fprintf(w, "Company:\t%s\n", client.Token.OrganizationName)
+	fmt.Fprintf(w, "Role:\t%s\n", client.Token.Role)
+	fmt.Fprintf(w, "Token expires:\t%s\n", client.Token.ExpiresAt.Format(time.RFC3339))
+	return w.Flush()
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/sonnysideup/go-gremlin/gameday"
+	"github.com/sonnysideup/go-gremlin/spec"
+)
+
+// cmdCI runs Attack and Scenario specs as experiments, one after the other.
+// Each experiment's probes must pass before its attacks launch and while they
+// run; a probe failing or an attack ending in a failed stage halts the
+// experiment's attacks and fails it. The command fails if any experiment did.
+func cmdCI(a *app, args []string) error {
+	fs := a.newFlagSet("ci")
+	junit := fs.String("junit", "", "write JUnit XML results to this file")
+	interval := fs.Duration("interval", 5*time.Second, "how often to check probes and poll attacks")
+	if err := a.parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return usagef("ci: expected one or more spec files")
+	}
+
+	docs, err := spec.Load(fs.Args()...)
+	if err != nil {
+		return err
+	}
+	var exps []*gameday.Experiment
+	for i := range docs {
+		exp, err := docs[i].Experiment()
+		if err != nil {
+			return usagef("ci: %v", err)
+		}
+		exps = append(exps, exp)
+	}
+
+	client, err := a.client()
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := interruptible()
+	defer stop()
+
+	runner := &gameday.Runner{API: client, Interval: *interval}
+	var results []*gameday.Result
+	failed := 0
+
+	w := a.table()
+	fmt.Fprintln(w, "EXPERIMENT\tSTATUS\tDURATION\tREASON")
+	for _, exp := range exps {
+		if ctx.Err() != nil {
+			break
+		}
+		res, err := runner.Run(ctx, *exp)
+		if err != nil {
+			return err
+		}
+		results = append(results, res)
+		if !res.Passed() {
+			failed++
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", res.Experiment, res.Status, res.Duration().Round(time.Second), res.Reason)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	if *junit != "" {
+		f, err := os.Create(*junit)
+		if err != nil {
+			return fmt.Errorf("Failed to write JUnit results: %v", err)
+		}
+		err = gameday.WriteJUnit(f, results...)
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return fmt.Errorf("Failed to write JUnit results: %v", err)
+		}
+	}
+
+	if skipped := len(exps) - len(results); skipped > 0 {
+		return fmt.Errorf("Interrupted with %d experiments not run", skipped)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d experiments failed", failed, len(exps))
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	gremlin "github.com/sonnysideup/go-gremlin"
+	"github.com/sonnysideup/go-gremlin/gremlintest"
+)
+
+const passingSpec = `apiVersion: gremlin/v1
+kind: Attack
+metadata: {name: db-cpu}
+spec:
+  command: {type: cpu, args: ["-l", "30"]}
+  probes:
+    - tcp: {address: "${DB_ADDR}"}
+`
+
+const failingSpec = `apiVersion: gremlin/v1
+kind: Scenario
+metadata: {name: checkout}
+spec:
+  hypothesis: Checkout stays up
+  probes:
+    - name: checkout
+      http: {url: "${CHECKOUT_URL}"}
+      threshold: 2
+  steps:
+    - command: {type: cpu, args: ["-l", "60"]}
+    - command: {type: latency, args: ["-l", "60"]}
+`
+
+// ciSetup starts a fake API server whose clock moves 5s on every request,
+// writes the spec files and returns a function that runs the CLI against it.
+func ciSetup(t *testing.T) (s *gremlintest.Server, dir string, gremlinCLI func(args ...string) (int, string, string), teardown func()) {
+	s = gremlintest.NewServer()
+	s.AddUser("user@domain.com", "secret", "Test Org")
+	s.Org("Test Org").AddHost(gremlin.Host{Identifier: "db-1", State: gremlin.HostActive})
+	s.AddHook(func(w http.ResponseWriter, r *http.Request) bool {
+		s.Clock.Advance(5 * time.Second)
+		return false
+	})
+
+	dir, err := ioutil.TempDir("", "gremlin-ci")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	configPath := filepath.Join(dir, "config.json")
+	config := fmt.Sprintf(`{"company":"Test Org","email":"user@domain.com","password":"secret","url":%q,"token_path":%q}`,
+		s.URL, filepath.Join(dir, "token.json"))
+	ioutil.WriteFile(configPath, []byte(config), 0600)
+	ioutil.WriteFile(filepath.Join(dir, "pass.yaml"), []byte(passingSpec), 0600)
+	ioutil.WriteFile(filepath.Join(dir, "fail.yaml"), []byte(failingSpec), 0600)
+
+	gremlinCLI = func(args ...string) (int, string, string) {
+		var stdout, stderr bytes.Buffer
+		code := run(append([]string{"-config", configPath}, args...), &stdout, &stderr)
+		return code, stdout.String(), stderr.String()
+	}
+	teardown = func() {
+		s.Close()
+		os.RemoveAll(dir)
+	}
+	return s, dir, gremlinCLI, teardown
+}
+
+func TestCI(t *testing.T) {
+	s, dir, gremlinCLI, teardown := ciSetup(t)
+	defer teardown()
+
+	db, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer db.Close()
+
+	// checkout is healthy for its first three checks
+	var checks int32
+	checkout := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&checks, 1) > 3 {
+			http.Error(w, "down", http.StatusServiceUnavailable)
+		}
+	}))
+	defer checkout.Close()
+
+	os.Setenv("DB_ADDR", db.Addr().String())
+	os.Setenv("CHECKOUT_URL", checkout.URL)
+	defer os.Unsetenv("DB_ADDR")
+	defer os.Unsetenv("CHECKOUT_URL")
+
+	junit := filepath.Join(dir, "results.xml")
+	code, stdout, stderr := gremlinCLI("ci", "-junit", junit, "-interval", "1ms", filepath.Join(dir, "pass.yaml"), filepath.Join(dir, "fail.yaml"))
+	if code != exitAPIError {
+		t.Fatalf("Expected exit status %d, but got %d: %s", exitAPIError, code, stderr)
+	}
+	if !strings.Contains(stderr, "1 of 2 experiments failed") {
+		t.Errorf("Expected the failure count on stderr, but got %q", stderr)
+	}
+	for _, want := range []string{"db-cpu      passed", "checkout    failed", `Probe "checkout" failed: Unexpected status 503 (2 in a row)`} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("Expected %q in the summary, but got:\n%s", want, stdout)
+		}
+	}
+	s.AssertRequestCount(t, "POST", "attacks/new", 2)
+	s.AssertRequestCount(t, "DELETE", "attacks/*", 1)
+
+	data, err := ioutil.ReadFile(junit)
+	if err != nil {
+		t.Fatalf("Expected the JUnit file to be written, but got %v", err)
+	}
+	for _, want := range []string{
+		`<testsuites tests="3" failures="1" skipped="1"`,
+		`<testcase name="db-cpu" classname="db-cpu"`,
+		`<failure message="Probe &#34;checkout&#34; failed: Unexpected status 503 (2 in a row)">`,
+		`<testcase name="step 2 (latency attack)" classname="checkout" time="0.000">`,
+	} {
+		if !bytes.Contains(data, []byte(want)) {
+			t.Errorf("Expected %q in the JUnit results, but got:\n%s", want, data)
+		}
+	}
+}
+
+func TestCIRejectsNonExperiments(t *testing.T) {
+	_, dir, gremlinCLI, teardown := ciSetup(t)
+	defer teardown()
+
+	path := filepath.Join(dir, "template.yaml")
+	ioutil.WriteFile(path, []byte("apiVersion: gremlin/v1\nkind: Template\nmetadata: {name: t}\nspec: {command: {type: cpu}}\n"), 0600)
+
+	code, _, stderr := gremlinCLI("ci", path)
+	if code != exitUsage || !strings.Contains(stderr, "is not an experiment") {
+		t.Errorf("Expected a usage error, but got %d: %s", code, stderr)
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+)
+
+func cmdClientsList(a *app, args []string) error {
+	fs := a.newFlagSet("clients list")
+	output := addOutputFlags(fs)
+	if err := a.parseFlags(fs, args); err != nil {
+		return err
+	}
+	p, err := a.printer(output)
+	if err != nil {
+		return err
+	}
+
+	client, err := a.client()
+	if err != nil {
+		return err
+	}
+
+	hosts, err := client.ListClients(context.Background())
+	if err != nil {
+		return err
+	}
+
+	return p.Print(a.stdout, hosts)
+}
+
+func cmdTemplatesList(a *app, args []string) error {
+	fs := a.newFlagSet("templates list")
+	output := addOutputFlags(fs)
+	if err := a.parseFlags(fs, args); err != nil {
+		return err
+	}
+	p, err := a.printer(output)
+	if err != nil {
+		return err
+	}
+
+	client, err := a.client()
+	if err != nil {
+		return err
+	}
+
+	templates, err := client.ListTemplates(context.Background())
+	if err != nil {
+		return err
+	}
+
+	return p.Print(a.stdout, templates)
+}
@@ -0,0 +1,224 @@
+// Command gremlin is a command-line client for the Gremlin API.
+//
+//	gremlin login
+//	gremlin whoami
+//	gremlin attack create -target 'tags(service=checkout) random(10%)' cpu -c 1 --length 60
+//	gremlin attack list|get|halt|halt-all|watch
+//	gremlin clients list
+//	gremlin templates list -o json
+//	gremlin wait <guid>
+//	gremlin validate|apply <spec file>...
+//	gremlin plan|sync -owner name <spec file>...
+//	gremlin ci [-junit file] [-interval 5s] <spec file>...
+//
+// Commands that print API objects take -o table|json|yaml|template=..., plus
+// -columns, -sort-by and -no-headers for tables.
+//
+// Credentials come from the library credential chain: the config file named
+// by -config (default $GREMLIN_CONFIG or ~/.gremlin/config.json) overridden
+// by GREMLIN_* environment variables. The exit status is 0 on success, 1 when
+// the API call or attack fails, and 2 for usage errors.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+
+	gremlin "github.com/sonnysideup/go-gremlin"
+	"github.com/sonnysideup/go-gremlin/printer"
+)
+
+// Exit statuses
+const (
+	exitOK       = 0
+	exitAPIError = 1
+	exitUsage    = 2
+)
+
+// usageError marks errors caused by bad command-line input.
+type usageError struct {
+	msg string
+}
+
+func (e *usageError) Error() string {
+	return e.msg
+}
+
+func usagef(format string, args ...interface{}) error {
+	return &usageError{msg: fmt.Sprintf(format, args...)}
+}
+
+// app carries the state shared by all commands.
+type app struct {
+	stdout io.Writer
+	stderr io.Writer
+
+	configPath string
+	cfg        *gremlin.Config
+
+	// out is the printer chosen by the command's output flags, if any
+	out *printer.Printer
+}
+
+// command is a (sub)command handler; args excludes the command name.
+type command func(a *app, args []string) error
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdout io.Writer, stderr io.Writer) int {
+	a := &app{stdout: stdout, stderr: stderr}
+
+	fs := flag.NewFlagSet("gremlin", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.StringVar(&a.configPath, "config", gremlin.DefaultConfigPath(), "path to the config file")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "usage: gremlin [-config file] <command> [arguments]")
+		fmt.Fprintln(stderr, "\ncommands:\n  "+strings.Join(commandNames(rootCommands), "\n  "))
+	}
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+
+	err := dispatch(a, rootCommands, fs.Args())
+	switch err.(type) {
+	case nil:
+		return exitOK
+	case *usageError:
+		fmt.Fprintf(stderr, "gremlin: %v\n", err)
+		return exitUsage
+	default:
+		if a.out != nil && a.out.Format() != printer.FormatTable {
+			a.out.PrintError(stderr, err)
+		} else {
+			fmt.Fprintf(stderr, "gremlin: %v\n", err)
+		}
+		return exitAPIError
+	}
+}
+
+var rootCommands map[string]command
+
+func init() {
+	rootCommands = map[string]command{
+		"login":     cmdLogin,
+		"whoami":    cmdWhoami,
+		"attack":    group(attackCommands),
+		"clients":   group(map[string]command{"list": cmdClientsList}),
+		"templates": group(map[string]command{"list": cmdTemplatesList}),
+		"wait":      cmdWait,
+		"validate":  cmdValidate,
+		"apply":     cmdApply,
+		"plan":      cmdPlan,
+		"sync":      cmdSync,
+		"ci":        cmdCI,
+	}
+}
+
+// group returns a command that dispatches to one of the given subcommands.
+func group(commands map[string]command) command {
+	return func(a *app, args []string) error {
+		return dispatch(a, commands, args)
+	}
+}
+
+func dispatch(a *app, commands map[string]command, args []string) error {
+	if len(args) == 0 {
+		return usagef("expected one of: %s", strings.Join(commandNames(commands), ", "))
+	}
+
+	cmd, ok := commands[args[0]]
+	if !ok {
+		return usagef("unknown command %q, expected one of: %s", args[0], strings.Join(commandNames(commands), ", "))
+	}
+	return cmd(a, args[1:])
+}
+
+func commandNames(commands map[string]command) []string {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// newFlagSet creates a flag set whose parse errors are reported as usage errors.
+func (a *app) newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(a.stderr)
+	return fs
+}
+
+func (a *app) parseFlags(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		return usagef("%s: %v", fs.Name(), err)
+	}
+	return nil
+}
+
+// interruptible returns a context that is cancelled on SIGINT or SIGTERM.
+// Call stop once the context is no longer needed.
+func interruptible() (ctx context.Context, stop func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		select {
+		case <-signals:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	return ctx, func() {
+		signal.Stop(signals)
+		cancel()
+	}
+}
+
+func (a *app) config() (*gremlin.Config, error) {
+	if a.cfg == nil {
+		cfg, err := gremlin.LoadConfig(a.configPath)
+		if err != nil {
+			return nil, err
+		}
+		a.cfg = cfg
+	}
+	return a.cfg, nil
+}
+
+// client returns an authenticated client, reusing the token saved by login
+// when it is still valid.
+func (a *app) client() (*gremlin.Client, error) {
+	cfg, err := a.config()
+	if err != nil {
+		return nil, err
+	}
+
+	client := cfg.NewClient()
+	if err := client.LoadToken(cfg.TokenPath); err == nil {
+		return client, nil
+	}
+
+	if cfg.Email == "" || cfg.Password == "" {
+		return nil, fmt.Errorf("not logged in: set %s and %s or add them to %s", gremlin.EnvEmail, gremlin.EnvPassword, a.configPath)
+	}
+	if _, err := client.Authenticate(); err != nil {
+		return nil, err
+	}
+	// the command can go ahead; only the next one has to log in again
+	if err := client.SaveToken(cfg.TokenPath); err != nil {
+		fmt.Fprintf(a.stderr, "gremlin: %v\n", err)
+	}
+
+	return client, nil
+}
@@ -0,0 +1,349 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	gremlin "github.com/sonnysideup/go-gremlin"
+)
+
+const testGUID = "123e4567-e89b-12d3-a456-426655440000"
+
+// setup starts a fake API server, points the credential chain at it and
+// returns a function that runs the CLI against it.
+func setup(t *testing.T) (mux *http.ServeMux, gremlinCLI func(args ...string) (int, string, string), teardown func()) {
+	mux = http.NewServeMux()
+	server := httptest.NewServer(mux)
+
+	mux.HandleFunc("/users/auth", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `[{"org_name":"Test Org","header":"Bearer fake-token","role":"SUPER","expires_at":%q}]`,
+			time.Now().Add(time.Hour).Format(time.RFC3339))
+	})
+
+	dir, err := ioutil.TempDir("", "gremlin-cli")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	configPath := filepath.Join(dir, "config.json")
+	config := fmt.Sprintf(`{"company":"Test Org","email":"user@domain.com","password":"secret","url":%q,"token_path":%q}`,
+		server.URL, filepath.Join(dir, "token.json"))
+	ioutil.WriteFile(configPath, []byte(config), 0600)
+
+	// keep the developer's own environment out of the tests
+	saved := map[string]string{}
+	for _, name := range []string{gremlin.EnvCompany, gremlin.EnvEmail, gremlin.EnvPassword, gremlin.EnvURL, gremlin.EnvToken} {
+		saved[name] = os.Getenv(name)
+		os.Unsetenv(name)
+	}
+
+	gremlinCLI = func(args ...string) (int, string, string) {
+		var stdout, stderr bytes.Buffer
+		code := run(append([]string{"-config", configPath}, args...), &stdout, &stderr)
+		return code, stdout.String(), stderr.String()
+	}
+
+	teardown = func() {
+		server.Close()
+		os.RemoveAll(dir)
+		for name, value := range saved {
+			os.Setenv(name, value)
+		}
+	}
+
+	return mux, gremlinCLI, teardown
+}
+
+func TestLoginAndWhoami(t *testing.T) {
+	_, gremlinCLI, teardown := setup(t)
+	defer teardown()
+
+	if code, _, stderr := gremlinCLI("login"); code != exitOK {
+		t.Fatalf("Expected login to succeed, but got %d: %s", code, stderr)
+	}
+
+	code, stdout, _ := gremlinCLI("whoami")
+	if code != exitOK {
+		t.Fatalf("Expected whoami to succeed, but got %d", code)
+	}
+	if want := "SUPER"; !strings.Contains(stdout, want) {
+		t.Errorf("Expected output to contain %q, but got %q", want, stdout)
+	}
+}
+
+func TestAttackCreate(t *testing.T) {
+	mux, gremlinCLI, teardown := setup(t)
+	defer teardown()
+
+	mux.HandleFunc("/attacks/new", func(w http.ResponseWriter, r *http.Request) {
+		var ac gremlin.AttackCommand
+		json.NewDecoder(r.Body).Decode(&ac)
+
+		if got, want := ac.Command.Type, "cpu"; got != want {
+			t.Errorf("Expected attack type %q, but got %q", want, got)
+		}
+		if got, want := strings.Join(ac.Command.Args, " "), "-c 1 --length 5"; got != want {
+			t.Errorf("Expected attack args %q, but got %q", want, got)
+		}
+		if got, want := ac.Target.Percent, 10; got != want {
+			t.Errorf("Expected target percent %d, but got %d", want, got)
+		}
+
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprint(w, testGUID)
+	})
+
+	code, stdout, stderr := gremlinCLI("attack", "create", "-target", "tags(zone=a) random(10%)", "cpu", "-c", "1", "--length", "5")
+	if code != exitOK {
+		t.Fatalf("Expected exit status %d, but got %d: %s", exitOK, code, stderr)
+	}
+	if got := strings.TrimSpace(stdout); got != testGUID {
+		t.Errorf("Expected guid %s to be printed, but got %q", testGUID, got)
+	}
+}
+
+func TestAttackListAndGet(t *testing.T) {
+	mux, gremlinCLI, teardown := setup(t)
+	defer teardown()
+
+	attackJSON := fmt.Sprintf(`{"guid":%q,"stage":"Running","command":{"type":"latency"}}`, testGUID)
+	mux.HandleFunc("/attacks/active", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "["+attackJSON+"]")
+	})
+	mux.HandleFunc("/attacks/"+testGUID, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, attackJSON)
+	})
+	mux.HandleFunc("/executions", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"client_id":"web-1","stage":"Running"}]`)
+	})
+
+	code, stdout, _ := gremlinCLI("attack", "list", "-active")
+	if code != exitOK || !strings.Contains(stdout, testGUID) || !strings.Contains(stdout, "latency") {
+		t.Errorf("Expected attack list to show the attack, but got %d: %q", code, stdout)
+	}
+
+	code, stdout, _ = gremlinCLI("attack", "get", testGUID)
+	if code != exitOK || !strings.Contains(stdout, "web-1") {
+		t.Errorf("Expected attack get to show executions, but got %d: %q", code, stdout)
+	}
+}
+
+func TestAttackHaltWithAPIError(t *testing.T) {
+	mux, gremlinCLI, teardown := setup(t)
+	defer teardown()
+
+	mux.HandleFunc("/attacks/"+testGUID, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+
+	code, _, stderr := gremlinCLI("attack", "halt", testGUID)
+	if code != exitAPIError {
+		t.Errorf("Expected exit status %d, but got %d", exitAPIError, code)
+	}
+	if want := "status: 503"; !strings.Contains(stderr, want) {
+		t.Errorf("Expected stderr to contain %q, but got %q", want, stderr)
+	}
+}
+
+func TestClientsAndTemplatesList(t *testing.T) {
+	mux, gremlinCLI, teardown := setup(t)
+	defer teardown()
+
+	mux.HandleFunc("/clients", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"identifier":"web-1","state":"ACTIVE","tags":{"zone":"a"}}]`)
+	})
+	mux.HandleFunc("/templates", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"guid":"t-1","name":"cpu spike","command":{"type":"cpu"},"target":{"type":"Random"}}]`)
+	})
+
+	if code, stdout, _ := gremlinCLI("clients", "list"); code != exitOK || !strings.Contains(stdout, "zone=a") {
+		t.Errorf("Expected clients list to show tags, but got %d: %q", code, stdout)
+	}
+	if code, stdout, _ := gremlinCLI("templates", "list"); code != exitOK || !strings.Contains(stdout, "cpu spike") {
+		t.Errorf("Expected templates list to show the template, but got %d: %q", code, stdout)
+	}
+}
+
+func TestWaitForFailedAttack(t *testing.T) {
+	mux, gremlinCLI, teardown := setup(t)
+	defer teardown()
+
+	mux.HandleFunc("/attacks/"+testGUID, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"guid":%q,"stage":"Failed"}`, testGUID)
+	})
+	mux.HandleFunc("/executions", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"client_id":"web-1","stage":"Failed","error":"boom"}]`)
+	})
+
+	code, stdout, stderr := gremlinCLI("wait", "-interval", "1ms", testGUID)
+	if code != exitAPIError {
+		t.Errorf("Expected exit status %d, but got %d", exitAPIError, code)
+	}
+	if !strings.Contains(stdout, "boom") || !strings.Contains(stderr, "web-1: Failed (boom)") {
+		t.Errorf("Expected failure details, but got stdout %q and stderr %q", stdout, stderr)
+	}
+}
+
+func TestUsageErrors(t *testing.T) {
+	_, gremlinCLI, teardown := setup(t)
+	defer teardown()
+
+	tests := [][]string{
+		{},
+		{"bogus"},
+		{"attack"},
+		{"attack", "get"},
+		{"attack", "get", "not-a-guid"},
+		{"attack", "create", "-target", "exact(", "cpu"},
+		{"attack", "list", "-nope"},
+		{"apply"},
+	}
+
+	for _, args := range tests {
+		if code, _, _ := gremlinCLI(args...); code != exitUsage {
+			t.Errorf("gremlin %s: expected exit status %d, but got %d", strings.Join(args, " "), exitUsage, code)
+		}
+	}
+}
+
+func TestValidateAndApply(t *testing.T) {
+	mux, gremlinCLI, teardown := setup(t)
+	defer teardown()
+
+	mux.HandleFunc("/templates", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprint(w, `{"guid":"t-1"}`)
+	})
+
+	dir, _ := ioutil.TempDir("", "gremlin-spec")
+	defer os.RemoveAll(dir)
+	good := filepath.Join(dir, "good.yaml")
+	ioutil.WriteFile(good, []byte("apiVersion: gremlin/v1\nkind: Template\nmetadata: {name: cpu-spike}\nspec: {command: {type: cpu}}\n"), 0600)
+	bad := filepath.Join(dir, "bad.yaml")
+	ioutil.WriteFile(bad, []byte("apiVersion: gremlin/v1\nkind: Template\nmetadata: {name: cpu-spike}\nspec: {command: {type: gpu}}\n"), 0600)
+
+	if code, stdout, _ := gremlinCLI("validate", good); code != exitOK || !strings.Contains(stdout, `Template "cpu-spike" is valid`) {
+		t.Errorf("Expected validate to succeed, but got %d: %q", code, stdout)
+	}
+
+	code, _, stderr := gremlinCLI("validate", good, bad)
+	if code != exitAPIError {
+		t.Errorf("Expected exit status %d, but got %d", exitAPIError, code)
+	}
+	if want := bad + `:4:24: spec.command.type: unknown attack type "gpu"`; !strings.Contains(stderr, want) {
+		t.Errorf("Expected stderr to contain %q, but got %q", want, stderr)
+	}
+
+	if code, stdout, stderr := gremlinCLI("apply", good); code != exitOK || !strings.Contains(stdout, "t-1") {
+		t.Errorf("Expected apply to print the created guid, but got %d: %q %q", code, stdout, stderr)
+	}
+}
+
+func TestPlanAndSync(t *testing.T) {
+	mux, gremlinCLI, teardown := setup(t)
+	defer teardown()
+
+	var created int
+	mux.HandleFunc("/templates", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "POST" {
+			created++
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprint(w, `{"guid":"t-1"}`)
+			return
+		}
+		fmt.Fprint(w, `[]`)
+	})
+	mux.HandleFunc("/schedules/attacks", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[]`)
+	})
+
+	dir, _ := ioutil.TempDir("", "gremlin-spec")
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "templates.yaml")
+	ioutil.WriteFile(file, []byte("apiVersion: gremlin/v1\nkind: Template\nmetadata: {name: cpu-spike}\nspec: {command: {type: cpu}}\n"), 0600)
+
+	if code, _, stderr := gremlinCLI("plan", file); code != exitUsage || !strings.Contains(stderr, "-owner is required") {
+		t.Errorf("Expected plan without -owner to exit %d, but got %d: %q", exitUsage, code, stderr)
+	}
+
+	code, stdout, stderr := gremlinCLI("plan", "-owner", "team-a", file)
+	if code != exitOK || !strings.Contains(stdout, `+ Template "cpu-spike"`) || created != 0 {
+		t.Errorf("Expected plan to list the template without creating it, but got %d: %q %q", code, stdout, stderr)
+	}
+
+	if code, _, stderr := gremlinCLI("sync", "-owner", "team-a", file); code != exitOK || created != 1 {
+		t.Errorf("Expected sync to create the template, but got %d: %q", code, stderr)
+	}
+}
+
+func TestClientCredentials(t *testing.T) {
+	_, gremlinCLI, teardown := setup(t)
+	defer teardown()
+
+	dir, _ := ioutil.TempDir("", "gremlin-cli")
+	defer os.RemoveAll(dir)
+	noCredentials := filepath.Join(dir, "anonymous.json")
+	ioutil.WriteFile(noCredentials, []byte(`{"company":"Test Org","token_path":"`+filepath.Join(dir, "token.json")+`"}`), 0600)
+
+	if code, _, stderr := gremlinCLI("-config", noCredentials, "whoami"); code != exitAPIError || !strings.Contains(stderr, "not logged in") {
+		t.Errorf("Expected a missing login to exit %d, but got %d: %q", exitAPIError, code, stderr)
+	}
+}
+
+func TestOutputFormats(t *testing.T) {
+	mux, gremlinCLI, teardown := setup(t)
+	defer teardown()
+
+	mux.HandleFunc("/clients", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"identifier":"web-2","state":"IDLE"},{"identifier":"web-1","state":"ACTIVE","tags":{"zone":"a"}}]`)
+	})
+	mux.HandleFunc("/attacks/"+testGUID, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"guid":%q,"stage":"Running","command":{"type":"latency"}}`, testGUID)
+	})
+	mux.HandleFunc("/executions", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"client_id":"web-1","stage":"Running"}]`)
+	})
+	mux.HandleFunc("/attacks/active", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+
+	code, stdout, _ := gremlinCLI("clients", "list", "-columns", "identifier", "-sort-by", "identifier", "-no-headers")
+	if want := "web-1\nweb-2\n"; code != exitOK || stdout != want {
+		t.Errorf("Expected %q, but got %d: %q", want, code, stdout)
+	}
+
+	code, stdout, _ = gremlinCLI("clients", "list", "-o", "template={{.Identifier}}={{.State}}")
+	if want := "web-2=IDLE\nweb-1=ACTIVE\n"; code != exitOK || stdout != want {
+		t.Errorf("Expected %q, but got %d: %q", want, code, stdout)
+	}
+
+	code, stdout, _ = gremlinCLI("attack", "get", "-o", "json", testGUID)
+	var details struct {
+		Guid       string
+		Executions []gremlin.Execution
+	}
+	if err := json.Unmarshal([]byte(stdout), &details); code != exitOK || err != nil {
+		t.Fatalf("Expected JSON output, but got %d: %q", code, stdout)
+	}
+	if details.Guid != testGUID || len(details.Executions) != 1 {
+		t.Errorf("Expected the attack and its executions, but got %+v", details)
+	}
+
+	code, _, stderr := gremlinCLI("attack", "list", "-active", "-o", "json")
+	var apiErr struct{ Error string }
+	if err := json.Unmarshal([]byte(stderr), &apiErr); code != exitAPIError || err != nil || !strings.Contains(apiErr.Error, "status: 503") {
+		t.Errorf("Expected a JSON error on stderr, but got %d: %q", code, stderr)
+	}
+
+	if code, _, _ := gremlinCLI("clients", "list", "-o", "xml"); code != exitUsage {
+		t.Errorf("Expected an unknown format to be a usage error, but got %d", code)
+	}
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"flag"
+	"strings"
+
+	"github.com/sonnysideup/go-gremlin/printer"
+)
+
+// outputFlags are the -o, -columns, -sort-by and -no-headers flags shared by
+// the commands that print API objects.
+type outputFlags struct {
+	format    string
+	columns   string
+	sortBy    string
+	noHeaders bool
+}
+
+func addOutputFlags(fs *flag.FlagSet) *outputFlags {
+	o := &outputFlags{}
+	fs.StringVar(&o.format, "o", printer.FormatTable, "output format: table, json, yaml or template='{{.Guid}}'")
+	fs.StringVar(&o.columns, "columns", "", "comma separated table columns to show")
+	fs.StringVar(&o.sortBy, "sort-by", "", "sort lists by this column")
+	fs.BoolVar(&o.noHeaders, "no-headers", false, "omit the table header row")
+	return o
+}
+
+// printer builds the printer for the parsed flags and makes it the one used
+// to report errors from the command.
+func (a *app) printer(o *outputFlags) (*printer.Printer, error) {
+	opts := printer.Options{Format: o.format, SortBy: o.sortBy, NoHeaders: o.noHeaders}
+	if o.columns != "" {
+		opts.Columns = strings.Split(o.columns, ",")
+	}
+
+	p, err := printer.New(opts)
+	if err != nil {
+		return nil, usagef("%v", err)
+	}
+	a.out = p
+	return p, nil
+}
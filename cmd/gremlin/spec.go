@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sonnysideup/go-gremlin/spec"
+)
+
+func cmdValidate(a *app, args []string) error {
+	fs := a.newFlagSet("validate")
+	if err := a.parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return usagef("validate: expected one or more spec files")
+	}
+
+	docs, err := spec.Load(fs.Args()...)
+	if err != nil {
+		return err
+	}
+
+	for _, doc := range docs {
+		fmt.Fprintf(a.stdout, "%s: %s %q is valid\n", doc.Source, doc.Kind, doc.Name)
+	}
+	return nil
+}
+
+func cmdApply(a *app, args []string) error {
+	fs := a.newFlagSet("apply")
+	if err := a.parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return usagef("apply: expected one or more spec files")
+	}
+
+	// validate everything before touching the API
+	docs, err := spec.Load(fs.Args()...)
+	if err != nil {
+		return err
+	}
+
+	client, err := a.client()
+	if err != nil {
+		return err
+	}
+
+	results, applyErr := spec.Apply(context.Background(), client, docs)
+
+	w := a.table()
+	fmt.Fprintln(w, "KIND\tNAME\tGUID")
+	for _, r := range results {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", r.Document.Kind, r.Document.Name, r.GUID)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	return applyErr
+}
+
+// reconcile loads the spec files for plan and sync and computes their plan.
+func (a *app) reconcile(name string, args []string) (*spec.Reconciler, *spec.Plan, error) {
+	fs := a.newFlagSet(name)
+	owner := fs.String("owner", "", "managed-by annotation identifying these spec files (required)")
+	if err := a.parseFlags(fs, args); err != nil {
+		return nil, nil, err
+	}
+	if fs.NArg() == 0 {
+		return nil, nil, usagef("%s: expected one or more spec files", name)
+	}
+	if *owner == "" {
+		return nil, nil, usagef("%s: -owner is required", name)
+	}
+
+	docs, err := spec.Load(fs.Args()...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	client, err := a.client()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	r := &spec.Reconciler{Client: client, Owner: *owner}
+	plan, err := r.Plan(context.Background(), docs)
+	if err != nil {
+		return nil, nil, err
+	}
+	return r, plan, nil
+}
+
+func cmdPlan(a *app, args []string) error {
+	_, plan, err := a.reconcile("plan", args)
+	if err != nil {
+		return err
+	}
+	_, err = plan.WriteTo(a.stdout)
+	return err
+}
+
+func cmdSync(a *app, args []string) error {
+	r, plan, err := a.reconcile("sync", args)
+	if err != nil {
+		return err
+	}
+	if _, err := plan.WriteTo(a.stdout); err != nil {
+		return err
+	}
+	return r.Apply(context.Background(), plan)
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// isTerminal reports whether w is a character device such as a terminal.
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// rawMode switches the terminal on stdin to unbuffered, silent input so
+// single key presses can be read, and returns a function that restores the
+// previous settings. It shells out to stty to avoid a dependency on
+// platform-specific ioctls.
+func rawMode() (restore func(), err error) {
+	saved, err := stty("-g")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := stty("-icanon", "-echo", "min", "1"); err != nil {
+		return nil, err
+	}
+	return func() { stty(strings.TrimSpace(saved)) }, nil
+}
+
+func stty(args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = os.Stdin
+	out, err := cmd.Output()
+	return string(out), err
+}
+
+// Keys reported by readKeys besides single printable characters
+const (
+	keyUp   = "up"
+	keyDown = "down"
+)
+
+// readKeys decodes key presses from r onto keys until r fails or done is
+// closed, then closes keys. Arrow keys arrive as ESC [ A and ESC [ B. A read
+// in progress when done is closed is not interrupted; its keys are dropped.
+func readKeys(r io.Reader, keys chan<- string, done <-chan struct{}) {
+	defer close(keys)
+
+	send := func(key string) bool {
+		select {
+		case keys <- key:
+			return true
+		case <-done:
+			return false
+		}
+	}
+
+	buf := make([]byte, 16)
+	for {
+		n, err := r.Read(buf)
+		if err != nil {
+			return
+		}
+		in := string(buf[:n])
+		for len(in) > 0 {
+			var key string
+			switch {
+			case strings.HasPrefix(in, "\x1b[A"):
+				key, in = keyUp, in[3:]
+			case strings.HasPrefix(in, "\x1b[B"):
+				key, in = keyDown, in[3:]
+			default:
+				key, in = in[:1], in[1:]
+			}
+			if !send(key) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,333 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+	gremlin "github.com/sonnysideup/go-gremlin"
+	"github.com/sonnysideup/go-gremlin/printer"
+)
+
+func cmdAttackWatch(a *app, args []string) error {
+	fs := a.newFlagSet("attack watch")
+	interval := fs.Duration("interval", 2*time.Second, "polling interval")
+	if err := a.parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return usagef("usage: gremlin attack watch [-interval duration]")
+	}
+
+	client, err := a.client()
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := interruptible()
+	defer stop()
+
+	if !isTerminal(a.stdout) || !isTerminal(os.Stdin) {
+		return watchLog(ctx, a, client, *interval)
+	}
+
+	restore, err := rawMode()
+	if err != nil {
+		return watchLog(ctx, a, client, *interval)
+	}
+	defer restore()
+
+	keys, done := make(chan string), make(chan struct{})
+	defer close(done)
+	go readKeys(os.Stdin, keys, done)
+
+	v := &watchView{client: client, now: time.Now}
+	return v.run(ctx, a.stdout, keys, *interval)
+}
+
+// watchLog is the non-interactive fallback: the attacks active now, then one
+// line per event as it happens.
+func watchLog(ctx context.Context, a *app, client *gremlin.Client, interval time.Duration) error {
+	start := time.Now()
+	active, err := client.ListActiveAttacks(ctx)
+	if err != nil {
+		return err
+	}
+	for _, attack := range active {
+		fmt.Fprintf(a.stdout, "%s %s active %s %s\n",
+			start.Format(time.RFC3339), attack.Guid, attack.Command.Type, attack.Stage)
+	}
+
+	w := client.NewWatcher(gremlin.WatcherOptions{
+		Interval:   interval,
+		Since:      start,
+		Executions: true,
+		OnError: func(err error) {
+			fmt.Fprintf(a.stderr, "gremlin: %v\n", err)
+		},
+	})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for e := range w.Events() {
+			fmt.Fprintln(a.stdout, formatEvent(e))
+		}
+	}()
+
+	err = w.Run(ctx)
+	<-done
+	if err != nil && err != ctx.Err() {
+		return err
+	}
+	return nil
+}
+
+func formatEvent(e gremlin.Event) string {
+	prefix := e.Time.Format(time.RFC3339) + " " + e.Attack.Guid.String()
+
+	switch e.Type {
+	case gremlin.EventAttackCreated:
+		return fmt.Sprintf("%s created %s %s", prefix, e.Attack.Command.Type, e.Attack.Stage)
+	case gremlin.EventStageChanged:
+		if e.PreviousStage == "" {
+			return fmt.Sprintf("%s stage %s", prefix, e.Attack.Stage)
+		}
+		return fmt.Sprintf("%s stage %s -> %s", prefix, e.PreviousStage, e.Attack.Stage)
+	case gremlin.EventAttackHalted:
+		return prefix + " halted"
+	case gremlin.EventExecutionStarted:
+		return fmt.Sprintf("%s started on %s", prefix, executionHost(e.Execution))
+	case gremlin.EventExecutionFinished:
+		line := fmt.Sprintf("%s %s on %s", prefix, e.Execution.Stage, executionHost(e.Execution))
+		if e.Execution.Error != "" {
+			line += " (" + e.Execution.Error + ")"
+		}
+		return line
+	default:
+		return fmt.Sprintf("%s %s", prefix, e.Type)
+	}
+}
+
+func executionHost(ex *gremlin.Execution) string {
+	if ex.ContainerID != "" {
+		return ex.HostID + "/" + ex.ContainerID
+	}
+	return ex.HostID
+}
+
+// watchedAttack is an active attack with its executions.
+type watchedAttack struct {
+	gremlin.Attack
+	executions []gremlin.Execution
+}
+
+// watchView is the interactive attack watch screen.
+type watchView struct {
+	client *gremlin.Client
+	now    func() time.Time
+
+	attacks  []watchedAttack
+	updated  time.Time
+	selected int
+	err      error
+
+	// message is a status line, such as the result of a halt
+	message string
+
+	// confirming is the attack waiting for y/n to be halted, if any
+	confirming *uuid.UUID
+}
+
+func (v *watchView) run(ctx context.Context, out io.Writer, keys <-chan string, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	v.refresh(ctx)
+	v.render(out)
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			v.refresh(ctx)
+		case key, ok := <-keys:
+			if !ok || v.handleKey(ctx, key) {
+				return nil
+			}
+		}
+		v.render(out)
+	}
+}
+
+// refresh fetches the active attacks and their executions, keeping the same
+// attack selected if it is still active.
+func (v *watchView) refresh(ctx context.Context) {
+	var selectedGUID string
+	if v.selected < len(v.attacks) {
+		selectedGUID = v.attacks[v.selected].Guid.String()
+	}
+
+	active, err := v.client.ListActiveAttacks(ctx)
+	if err != nil {
+		v.err = err
+		return
+	}
+	sort.Slice(active, func(i, j int) bool {
+		return active[i].CreatedAt.Before(active[j].CreatedAt)
+	})
+
+	attacks := make([]watchedAttack, len(active))
+	for i, attack := range active {
+		executions, err := v.client.ListExecutions(ctx, attack.Guid)
+		if err != nil {
+			v.err = err
+			return
+		}
+		attacks[i] = watchedAttack{Attack: attack, executions: executions}
+	}
+
+	v.attacks, v.updated, v.err = attacks, v.now(), nil
+	v.selected = 0
+	for i, attack := range attacks {
+		if attack.Guid.String() == selectedGUID {
+			v.selected = i
+		}
+	}
+	if v.confirming != nil && !v.listed(*v.confirming) {
+		v.message = fmt.Sprintf("%s is no longer active, not halting it", v.confirming)
+		v.confirming = nil
+	}
+}
+
+func (v *watchView) listed(guid uuid.UUID) bool {
+	for _, attack := range v.attacks {
+		if uuid.Equal(attack.Guid, guid) {
+			return true
+		}
+	}
+	return false
+}
+
+// handleKey acts on a key press and reports whether to quit.
+func (v *watchView) handleKey(ctx context.Context, key string) bool {
+	if v.confirming != nil {
+		guid := *v.confirming
+		v.confirming = nil
+		v.message = ""
+		if key == "y" && v.listed(guid) {
+			if err := v.client.HaltAttack(ctx, guid); err != nil {
+				v.message = fmt.Sprintf("Failed to halt %s: %v", guid, err)
+			} else {
+				v.message = fmt.Sprintf("Halted %s", guid)
+				v.refresh(ctx)
+			}
+		}
+		return false
+	}
+
+	switch key {
+	case "q", "\x03":
+		return true
+	case "j", keyDown:
+		if v.selected < len(v.attacks)-1 {
+			v.selected++
+		}
+	case "k", keyUp:
+		if v.selected > 0 {
+			v.selected--
+		}
+	case "h":
+		if v.selected < len(v.attacks) {
+			guid := v.attacks[v.selected].Guid
+			v.confirming = &guid
+			v.message = fmt.Sprintf("Halt %s? (y/n)", guid)
+		}
+	}
+	return false
+}
+
+func (v *watchView) render(out io.Writer) {
+	var buf bytes.Buffer
+	buf.WriteString("\x1b[H\x1b[2J") // home and clear
+
+	fmt.Fprintf(&buf, "Active attacks at %s    j/k select  h halt  q quit\n\n", v.updated.Format("15:04:05"))
+
+	if len(v.attacks) == 0 {
+		buf.WriteString("No active attacks.\n")
+	} else {
+		w := tabwriter.NewWriter(&buf, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "  GUID\tTYPE\tSTAGE\tELAPSED\tREMAINING\tHOSTS")
+		for i, attack := range v.attacks {
+			marker := " "
+			if i == v.selected {
+				marker = ">"
+			}
+			elapsed, remaining := v.progress(attack.Attack)
+			fmt.Fprintf(w, "%s %s\t%s\t%s\t%s\t%s\t%s\n", marker, attack.Guid, attack.Command.Type,
+				attack.Stage, elapsed, remaining, summarizeExecutions(attack.executions))
+		}
+		w.Flush()
+
+		if selected := v.attacks[v.selected]; len(selected.executions) > 0 {
+			fmt.Fprintf(&buf, "\nExecutions of %s:\n", selected.Guid)
+			p, _ := printer.New(printer.Options{})
+			p.Print(&buf, selected.executions)
+		}
+	}
+
+	if v.err != nil {
+		fmt.Fprintf(&buf, "\nError: %v\n", v.err)
+	}
+	if v.message != "" {
+		fmt.Fprintf(&buf, "\n%s\n", v.message)
+	}
+
+	out.Write(buf.Bytes())
+}
+
+// progress returns how long an attack has run and how long it has left,
+// based on the length in its arguments.
+func (v *watchView) progress(attack gremlin.Attack) (string, string) {
+	start := attack.StartTime
+	if start.IsZero() {
+		return "-", "-"
+	}
+
+	elapsed := v.now().Sub(start)
+	remaining := attack.Command.Length() - elapsed
+	if remaining < 0 {
+		remaining = 0
+	}
+	return elapsed.Round(time.Second).String(), remaining.Round(time.Second).String()
+}
+
+// summarizeExecutions counts executions by stage, e.g. "1 Failed, 2 Running".
+func summarizeExecutions(executions []gremlin.Execution) string {
+	if len(executions) == 0 {
+		return "-"
+	}
+
+	counts := make(map[string]int)
+	for _, ex := range executions {
+		counts[string(ex.Stage)]++
+	}
+	stages := make([]string, 0, len(counts))
+	for stage := range counts {
+		stages = append(stages, stage)
+	}
+	sort.Strings(stages)
+
+	parts := make([]string, len(stages))
+	for i, stage := range stages {
+		parts[i] = fmt.Sprintf("%d %s", counts[stage], stage)
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	gremlin "github.com/sonnysideup/go-gremlin"
+)
+
+var watchStart = time.Date(2018, 3, 1, 12, 0, 0, 0, time.UTC)
+
+// watchServer serves one active attack, started at watchStart, until it is
+// halted.
+func watchServer(t *testing.T) (*gremlin.Client, *bool, func()) {
+	var mu sync.Mutex
+	halted := false
+	started := watchStart.Format(time.RFC3339)
+
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	mux.HandleFunc("/users/auth", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"org_name":"Test Org","header":"Bearer fake-token"}]`)
+	})
+	mux.HandleFunc("/attacks/active", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if halted {
+			fmt.Fprint(w, `[]`)
+			return
+		}
+		fmt.Fprintf(w, `[{"guid":%q,"stage":"Running","command":{"type":"cpu","args":["--length","60"]},"start_time":%q}]`, testGUID, started)
+	})
+	mux.HandleFunc("/attacks/completed", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[]`)
+	})
+	mux.HandleFunc("/attacks/"+testGUID, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		halted = r.Method == "DELETE"
+	})
+	mux.HandleFunc("/executions", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `[{"client_id":"web-1","stage":"Running","start_time":%q},{"client_id":"web-2","stage":"Failed","error":"boom"}]`, started)
+	})
+
+	client := gremlin.NewClient("Test Org", "user@domain.com", "secret", gremlin.WithURL(server.URL))
+	if _, err := client.Authenticate(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	return client, &halted, server.Close
+}
+
+func TestWatchViewHalt(t *testing.T) {
+	client, halted, teardown := watchServer(t)
+	defer teardown()
+
+	var out bytes.Buffer
+	keys := make(chan string)
+	now := func() time.Time { return watchStart.Add(20 * time.Second) }
+	v := &watchView{client: client, now: now}
+	done := make(chan error)
+	go func() { done <- v.run(context.Background(), &out, keys, time.Hour) }()
+
+	keys <- "h"
+	keys <- "y"
+	keys <- "q"
+	if err := <-done; err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if !*halted {
+		t.Errorf("Expected the selected attack to be halted")
+	}
+
+	screens := strings.Split(out.String(), "\x1b[H\x1b[2J")
+	first := screens[1]
+	for _, want := range []string{"> " + testGUID, "Running", "20s", "40s", "1 Failed, 1 Running", "web-2", "boom"} {
+		if !strings.Contains(first, want) {
+			t.Errorf("Expected the first screen to contain %q, but got:\n%s", want, first)
+		}
+	}
+	if want := "Halt " + testGUID + "? (y/n)"; !strings.Contains(screens[2], want) {
+		t.Errorf("Expected a halt confirmation, but got:\n%s", screens[2])
+	}
+	if last := screens[3]; !strings.Contains(last, "Halted "+testGUID) || !strings.Contains(last, "No active attacks") {
+		t.Errorf("Expected the attack to be gone after halting, but got:\n%s", last)
+	}
+}
+
+func TestWatchViewHaltCancelledWhenAttackEnds(t *testing.T) {
+	client, _, teardown := watchServer(t)
+	defer teardown()
+
+	ctx := context.Background()
+	v := &watchView{client: client, now: time.Now}
+	v.refresh(ctx)
+	v.handleKey(ctx, "h")
+
+	// the attack ends before the halt is confirmed
+	client.HaltAttack(ctx, v.attacks[0].Guid)
+	v.refresh(ctx)
+	if v.confirming != nil || !strings.Contains(v.message, testGUID+" is no longer active") {
+		t.Errorf("Expected the confirmation to be cancelled, but got %v: %q", v.confirming, v.message)
+	}
+	if v.handleKey(ctx, "y"); strings.HasPrefix(v.message, "Halted") {
+		t.Errorf("Expected y to halt nothing, but got %q", v.message)
+	}
+}
+
+func TestWatchLog(t *testing.T) {
+	client, _, teardown := watchServer(t)
+	defer teardown()
+
+	var stdout, stderr bytes.Buffer
+	a := &app{stdout: &stdout, stderr: &stderr}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := watchLog(ctx, a, client, 10*time.Millisecond); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if want := testGUID + " active cpu Running"; !strings.Contains(stdout.String(), want) {
+		t.Errorf("Expected output to contain %q, but got %q", want, stdout.String())
+	}
+	if strings.Contains(stdout.String(), "\x1b[") {
+		t.Errorf("Expected no terminal escapes in the line log")
+	}
+}
+
+func TestFormatEvent(t *testing.T) {
+	at := time.Date(2018, 3, 1, 12, 0, 0, 0, time.UTC)
+	attack := gremlin.Attack{Stage: gremlin.StageFailed, Command: gremlin.Command{Type: "cpu"}}
+
+	tests := []struct {
+		event gremlin.Event
+		want  string
+	}{
+		{gremlin.Event{Type: gremlin.EventStageChanged, Time: at, Attack: attack, PreviousStage: gremlin.StageRunning}, "stage Running -> Failed"},
+		{gremlin.Event{Type: gremlin.EventExecutionFinished, Time: at, Attack: attack,
+			Execution: &gremlin.Execution{HostID: "web-1", ContainerID: "c1", Stage: gremlin.StageFailed, Error: "boom"}}, "Failed on web-1/c1 (boom)"},
+		{gremlin.Event{Type: gremlin.EventAttackHalted, Time: at, Attack: attack}, "halted"},
+	}
+
+	for _, tt := range tests {
+		got := formatEvent(tt.event)
+		if !strings.HasPrefix(got, "2018-03-01T12:00:00Z ") || !strings.HasSuffix(got, tt.want) {
+			t.Errorf("Expected event line ending in %q, but got %q", tt.want, got)
+		}
+	}
+}
+
+func TestReadKeys(t *testing.T) {
+	keys := make(chan string, 8)
+	readKeys(strings.NewReader("j\x1b[Ak\x1b[Bq"), keys, nil)
+
+	var got []string
+	for k := range keys {
+		got = append(got, k)
+	}
+	if want := []string{"j", keyUp, "k", keyDown, "q"}; strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("Expected keys %v, but got %v", want, got)
+	}
+}
+
+func TestReadKeysStopsWhenDone(t *testing.T) {
+	keys, done := make(chan string), make(chan struct{})
+	close(done)
+
+	// nobody receives, so readKeys must give up rather than block
+	finished := make(chan bool)
+	go func() {
+		readKeys(strings.NewReader("jjj"), keys, done)
+		close(finished)
+	}()
+	select {
+	case <-finished:
+	case <-time.After(time.Second):
+		t.Fatalf("Expected readKeys to return once done is closed")
+	}
+	if _, ok := <-keys; ok {
+		t.Errorf("Expected keys to be closed")
+	}
+}
@@ -0,0 +1,136 @@
+package gremlin
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Config holds the settings needed to build a Client. It is resolved from a
+// credential chain: a JSON config file first, then GREMLIN_* environment
+// variables, which override anything set in the file.
+type Config struct {
+	Company  string `json:"company"`
+	Email    string `json:"email"`
+	Password string `json:"password"`
+
+	// URL overrides the default API server.
+	URL string `json:"url,omitempty"`
+
+	// TokenPath caches the access token between runs, see SaveToken.
+	TokenPath string `json:"token_path,omitempty"`
+}
+
+// Environment variables read by LoadConfig
+const (
+	EnvConfig   = "GREMLIN_CONFIG"
+	EnvCompany  = "GREMLIN_COMPANY"
+	EnvEmail    = "GREMLIN_EMAIL"
+	EnvPassword = "GREMLIN_PASSWORD"
+	EnvURL      = "GREMLIN_API_URL"
+	EnvToken    = "GREMLIN_TOKEN_PATH"
+)
+
+// DefaultConfigPath returns $GREMLIN_CONFIG, or ~/.gremlin/config.json.
+func DefaultConfigPath() string {
+	if path := os.Getenv(EnvConfig); path != "" {
+		return path
+	}
+	return filepath.Join(gremlinHome(), "config.json")
+}
+
+func gremlinHome() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		home = "."
+	}
+	return filepath.Join(home, ".gremlin")
+}
+
+// LoadConfig resolves the credential chain. A missing config file is not an
+// error, since the environment may provide everything.
+func LoadConfig(path string) (*Config, error) {
+	cfg := &Config{}
+
+	data, err := ioutil.ReadFile(path)
+	if err == nil {
+		if err := json.Unmarshal(data, cfg); err != nil {
+			return nil, fmt.Errorf("Failed to parse config %s: %v", path, err)
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("Failed to read config: %v", err)
+	}
+
+	env := map[string]*string{
+		EnvCompany:  &cfg.Company,
+		EnvEmail:    &cfg.Email,
+		EnvPassword: &cfg.Password,
+		EnvURL:      &cfg.URL,
+		EnvToken:    &cfg.TokenPath,
+	}
+	for name, field := range env {
+		if v := os.Getenv(name); v != "" {
+			*field = v
+		}
+	}
+
+	if cfg.TokenPath == "" {
+		cfg.TokenPath = filepath.Join(gremlinHome(), "token.json")
+	}
+
+	return cfg, nil
+}
+
+// NewClient builds a Client from the config. Any options are applied after
+// the config settings.
+func (cfg *Config) NewClient(options ...ConfigOption) *Client {
+	if cfg.URL != "" {
+		options = append([]ConfigOption{WithURL(cfg.URL)}, options...)
+	}
+	return NewClient(cfg.Company, cfg.Email, cfg.Password, options...)
+}
+
+// SaveToken writes the client access token to path so later runs can skip
+// authentication. The file is only readable by the current user.
+func (c *Client) SaveToken(path string) error {
+	data, err := json.Marshal(c.Token)
+	if err != nil {
+		return fmt.Errorf("Failed to marshal token: %v", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("Failed to save token: %v", err)
+	}
+	if err := ioutil.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("Failed to save token: %v", err)
+	}
+
+	return nil
+}
+
+// LoadToken reads a token written by SaveToken into the client. It fails if
+// the token belongs to a different company or has expired.
+func (c *Client) LoadToken(path string) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("Failed to read token: %v", err)
+	}
+
+	token := &AccessToken{}
+	if err := json.Unmarshal(data, token); err != nil {
+		return fmt.Errorf("Failed to parse token: %v", err)
+	}
+
+	if token.OrganizationName != c.Company {
+		return fmt.Errorf("Saved token is for '%s', not '%s'", token.OrganizationName, c.Company)
+	}
+	if !token.ExpiresAt.IsZero() && time.Now().After(token.ExpiresAt) {
+		return fmt.Errorf("Saved token expired at %s", token.ExpiresAt.Format(time.RFC3339))
+	}
+
+	c.Token = token
+	return nil
+}
@@ -0,0 +1,64 @@
+package gremlin
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadConfigEnvironmentOverridesFile(t *testing.T) {
+	path := writeTempFile(t, "config.json", `{"company":"File Co","email":"file@domain.com","password":"from-file"}`)
+	defer os.RemoveAll(filepath.Dir(path))
+
+	saved := os.Getenv(EnvPassword)
+	os.Setenv(EnvPassword, "from-env")
+	defer os.Setenv(EnvPassword, saved)
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if got, want := cfg.Company, "File Co"; got != want {
+		t.Errorf("Expected company %q, but got %q", want, got)
+	}
+	if got, want := cfg.Password, "from-env"; got != want {
+		t.Errorf("Expected password %q, but got %q", want, got)
+	}
+	if cfg.TokenPath == "" {
+		t.Errorf("Expected a default token path")
+	}
+}
+
+func TestLoadConfigWithMissingFile(t *testing.T) {
+	if _, err := LoadConfig(filepath.Join(os.TempDir(), "does-not-exist.json")); err != nil {
+		t.Errorf("Expected missing config file to be ignored, but got %v", err)
+	}
+}
+
+func TestSaveAndLoadToken(t *testing.T) {
+	dir := filepath.Dir(writeTempFile(t, "unused", ""))
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "nested", "token.json")
+
+	client := NewClient("Test Org", "user@domain.com", "secret")
+	client.Token = &AccessToken{OrganizationName: "Test Org", Header: "Bearer t", ExpiresAt: time.Now().Add(time.Hour)}
+	if err := client.SaveToken(path); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	loaded := NewClient("Test Org", "user@domain.com", "secret")
+	if err := loaded.LoadToken(path); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got, want := loaded.Token.Header, "Bearer t"; got != want {
+		t.Errorf("Expected token header %q, but got %q", want, got)
+	}
+
+	other := NewClient("Other Org", "user@domain.com", "secret")
+	if err := other.LoadToken(path); err == nil || !strings.Contains(err.Error(), "Test Org") {
+		t.Errorf("Expected token for another company to be rejected, but got %v", err)
+	}
+}
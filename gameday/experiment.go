@@ -0,0 +1,107 @@
+// Package gameday runs chaos experiments: it checks that the system is in its
+// steady state, launches attacks in order while probing the system, halts
+// everything the moment a probe fails and rolls back.
+package gameday
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	gremlin "github.com/sonnysideup/go-gremlin"
+)
+
+// Probe checks one aspect of the system under test, returning an error if
+// it is unhealthy.
+type Probe interface {
+	Name() string
+	Check(ctx context.Context) error
+}
+
+type probeFunc struct {
+	name  string
+	check func(ctx context.Context) error
+}
+
+// NewProbe returns a Probe that calls check.
+func NewProbe(name string, check func(ctx context.Context) error) Probe {
+	return probeFunc{name: name, check: check}
+}
+
+func (p probeFunc) Name() string                    { return p.name }
+func (p probeFunc) Check(ctx context.Context) error { return p.check(ctx) }
+
+// Action is a rollback action, run if the experiment fails.
+type Action struct {
+	Name string
+	Run  func(ctx context.Context) error
+}
+
+// Step is one step of an experiment: either an attack or a wait.
+type Step struct {
+	Name string
+
+	// Attack is launched and, unless NoWait is set, waited on until it
+	// finishes.
+	Attack *gremlin.AttackCommand
+	NoWait bool
+
+	// Wait pauses the experiment. Probes keep running if attacks launched
+	// with NoWait are still active.
+	Wait time.Duration
+}
+
+// Experiment is a game day definition.
+type Experiment struct {
+	Name        string
+	Description string
+
+	// SteadyState probes must all pass before the first step runs.
+	SteadyState []Probe
+
+	Steps []Step
+
+	// Probes are checked every Runner.Interval while any attack launched by
+	// the experiment is active.
+	Probes []Probe
+
+	// Rollbacks run in order after a failure, once the attacks are halted.
+	Rollbacks []Action
+}
+
+// Validate reports whether the experiment can be run.
+func (e *Experiment) Validate() error {
+	if e.Name == "" {
+		return fmt.Errorf("Experiment has no name")
+	}
+	if len(e.Steps) == 0 {
+		return fmt.Errorf("Experiment %q has no steps", e.Name)
+	}
+	for i, s := range e.Steps {
+		switch {
+		case s.Attack != nil && s.Wait != 0:
+			return fmt.Errorf("Step %d of %q has both an attack and a wait", i+1, e.Name)
+		case s.Attack == nil && s.Wait <= 0:
+			return fmt.Errorf("Step %d of %q needs an attack or a positive wait", i+1, e.Name)
+		case s.Attack == nil && s.NoWait:
+			return fmt.Errorf("Step %d of %q sets NoWait without an attack", i+1, e.Name)
+		}
+	}
+	for _, a := range e.Rollbacks {
+		if a.Run == nil {
+			return fmt.Errorf("Rollback %q of %q has no Run function", a.Name, e.Name)
+		}
+	}
+	return nil
+}
+
+// stepName returns the step's name, or a description if it has none.
+func (s Step) stepName(i int) string {
+	if s.Name != "" {
+		return s.Name
+	}
+	if s.Attack != nil {
+		return fmt.Sprintf("step %d (%s attack)", i+1, s.Attack.Command.Type)
+	}
+	return fmt.Sprintf("step %d (wait %v)", i+1, s.Wait)
+}
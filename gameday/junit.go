@@ -0,0 +1,149 @@
+package gameday
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+type junitSuites struct {
+	XMLName  xml.Name     `xml:"testsuites"`
+	Tests    int          `xml:"tests,attr"`
+	Failures int          `xml:"failures,attr"`
+	Skipped  int          `xml:"skipped,attr"`
+	Time     string       `xml:"time,attr"`
+	Suites   []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name      string      `xml:"name,attr"`
+	Tests     int         `xml:"tests,attr"`
+	Failures  int         `xml:"failures,attr"`
+	Skipped   int         `xml:"skipped,attr"`
+	Time      string      `xml:"time,attr"`
+	Timestamp string      `xml:"timestamp,attr"`
+	Cases     []junitCase `xml:"testcase"`
+	SystemOut string      `xml:"system-out,omitempty"`
+}
+
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Skipped   *junitSkipped `xml:"skipped,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Body    string `xml:",chardata"`
+}
+
+type junitSkipped struct {
+	Message string `xml:"message,attr"`
+}
+
+func seconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
+
+// WriteJUnit writes results as JUnit XML for CI systems to show in their test
+// UI. Every experiment is a test suite with a test case per step. A failed
+// step's failure lists why it failed, followed by the probe failures seen
+// while it ran and the events of its attack. An experiment whose steady state
+// did not hold gets an extra failed "steady state" test case.
+func WriteJUnit(w io.Writer, results ...*Result) error {
+	var doc junitSuites
+	var total time.Duration
+
+	for _, res := range results {
+		suite := junitSuite{
+			Name:      res.Experiment,
+			Time:      seconds(res.Duration()),
+			Timestamp: res.StartTime.UTC().Format("2006-01-02T15:04:05"),
+			SystemOut: timelineText(res.Timeline, func(e Event) bool { return e.Type != EventProbePassed }),
+		}
+		if res.Status == StatusAborted {
+			suite.Cases = append(suite.Cases, junitCase{
+				Name:      "steady state",
+				Classname: res.Experiment,
+				Time:      "0.000",
+				Failure:   &junitFailure{Message: res.Reason, Body: res.Reason},
+			})
+			suite.Failures++
+		}
+
+		for _, step := range res.Steps {
+			c := junitCase{Name: step.Name, Classname: res.Experiment, Time: seconds(step.EndTime.Sub(step.StartTime))}
+			switch step.Status {
+			case StatusSkipped:
+				c.Time = "0.000"
+				c.Skipped = &junitSkipped{Message: "The experiment ended before this step"}
+				suite.Skipped++
+			case StatusFailed:
+				c.Failure = &junitFailure{Message: step.Failures[0], Body: evidence(res, step)}
+				suite.Failures++
+			}
+			suite.Cases = append(suite.Cases, c)
+		}
+
+		suite.Tests = len(suite.Cases)
+		doc.Tests += suite.Tests
+		doc.Failures += suite.Failures
+		doc.Skipped += suite.Skipped
+		doc.Suites = append(doc.Suites, suite)
+		total += res.Duration()
+	}
+	doc.Time = seconds(total)
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// evidence describes why a step failed: its failures, then the probe
+// failures while it ran and the events of its attack.
+func evidence(res *Result, step StepResult) string {
+	var b strings.Builder
+	for _, f := range step.Failures {
+		b.WriteString(f + "\n")
+	}
+
+	events := timelineText(res.Timeline, func(e Event) bool {
+		if e.Type == EventProbeFailed {
+			return !e.Time.Before(step.StartTime) && !e.Time.After(step.EndTime)
+		}
+		return e.Step == step.Name || (step.Attack != "" && e.Attack == step.Attack)
+	})
+	if events != "" {
+		b.WriteString("\nTimeline:\n" + events)
+	}
+	return b.String()
+}
+
+// timelineText renders the events selected by keep, one per line.
+func timelineText(timeline []Event, keep func(Event) bool) string {
+	var b strings.Builder
+	for _, e := range timeline {
+		if !keep(e) {
+			continue
+		}
+		fmt.Fprintf(&b, "%s %s", e.Time.UTC().Format(time.RFC3339), e.Type)
+		for _, part := range []string{e.Step, e.Probe, e.Attack, e.Message} {
+			if part != "" {
+				b.WriteString(" " + part)
+			}
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
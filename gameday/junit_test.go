@@ -0,0 +1,60 @@
+package gameday
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteJUnit(t *testing.T) {
+	r, _ := newRunner()
+	passed, _ := r.Run(context.Background(), Experiment{Name: "cpu", Steps: []Step{{Name: "burn", Attack: cpu("30")}}})
+	failed, _ := r.Run(context.Background(), Experiment{
+		Name:   "latency",
+		Steps:  []Step{{Name: "burn", Attack: cpu("60")}, {Name: "never", Wait: time.Minute}},
+		Probes: []Probe{&counter{fail: 3}},
+	})
+	aborted, _ := r.Run(context.Background(), Experiment{
+		Name:        "broken",
+		SteadyState: []Probe{NewProbe("healthy", func(context.Context) error { return errors.New("503") })},
+		Steps:       []Step{{Name: "burn", Attack: cpu("30")}},
+	})
+
+	var buf bytes.Buffer
+	if err := WriteJUnit(&buf, passed, failed, aborted); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var doc junitSuites
+	if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("Expected valid XML, but got %v:\n%s", err, buf.String())
+	}
+	if doc.Tests != 5 || doc.Failures != 2 || doc.Skipped != 2 || len(doc.Suites) != 3 {
+		t.Errorf("Expected 5 tests, 2 failures and 2 skipped in 3 suites, but got %+v", doc)
+	}
+
+	if c := doc.Suites[0].Cases[0]; c.Name != "burn" || c.Classname != "cpu" || c.Time != "35.000" || c.Failure != nil {
+		t.Errorf("Expected a passing test case, but got %+v", c)
+	}
+
+	c := doc.Suites[1].Cases[0]
+	if c.Failure == nil || !strings.HasPrefix(c.Failure.Message, `Probe "counter" failed: latency too high`) {
+		t.Fatalf("Expected the probe failure as the message, but got %+v", c)
+	}
+	for _, want := range []string{"probe_failed", "counter latency too high", "attack_launched burn " + failed.Attacks[0], "attack_halted " + failed.Attacks[0]} {
+		if !strings.Contains(c.Failure.Body, want) {
+			t.Errorf("Expected %q in the failure evidence, but got:\n%s", want, c.Failure.Body)
+		}
+	}
+	if c := doc.Suites[1].Cases[1]; c.Skipped == nil {
+		t.Errorf("Expected the step after the failure to be skipped, but got %+v", c)
+	}
+
+	if c := doc.Suites[2].Cases[0]; c.Name != "steady state" || c.Failure == nil || !strings.Contains(c.Failure.Message, "503") {
+		t.Errorf("Expected a failed steady state test case, but got %+v", c)
+	}
+}
@@ -0,0 +1,106 @@
+package gameday
+
+import (
+	"time"
+
+	gremlin "github.com/sonnysideup/go-gremlin"
+)
+
+// Status is the outcome of an experiment.
+type Status string
+
+// Experiment outcomes
+const (
+	// StatusPassed means every step ran and no probe failed.
+	StatusPassed Status = "passed"
+
+	// StatusFailed means a probe or an attack failed during the experiment.
+	StatusFailed Status = "failed"
+
+	// StatusAborted means the steady state did not hold, so nothing ran.
+	StatusAborted Status = "aborted"
+
+	// StatusInterrupted means the context was cancelled mid-experiment.
+	StatusInterrupted Status = "interrupted"
+
+	// StatusSkipped means a step never ran because the experiment ended
+	// before it.
+	StatusSkipped Status = "skipped"
+)
+
+// EventType identifies an entry in the timeline.
+type EventType string
+
+// Timeline event types
+const (
+	EventExperimentStarted  EventType = "experiment_started"
+	EventProbePassed        EventType = "probe_passed"
+	EventProbeFailed        EventType = "probe_failed"
+	EventStepStarted        EventType = "step_started"
+	EventAttackLaunched     EventType = "attack_launched"
+	EventAttackFinished     EventType = "attack_finished"
+	EventAttackHalted       EventType = "attack_halted"
+	EventWaitFinished       EventType = "wait_finished"
+	EventRollbackSucceeded  EventType = "rollback_succeeded"
+	EventRollbackFailed     EventType = "rollback_failed"
+	EventExperimentFinished EventType = "experiment_finished"
+)
+
+// Event is an entry in the timeline of a run.
+type Event struct {
+	Time time.Time `json:"time"`
+	Type EventType `json:"type"`
+
+	// Step, Probe and Attack identify what the event is about, if anything.
+	Step   string `json:"step,omitempty"`
+	Probe  string `json:"probe,omitempty"`
+	Attack string `json:"attack,omitempty"`
+
+	Message string `json:"message,omitempty"`
+}
+
+// Result is the outcome of running an experiment.
+type Result struct {
+	Experiment string    `json:"experiment"`
+	Status     Status    `json:"status"`
+	Reason     string    `json:"reason,omitempty"`
+	StartTime  time.Time `json:"start_time"`
+	EndTime    time.Time `json:"end_time"`
+
+	// Attacks are the GUIDs of the attacks launched, in order.
+	Attacks []string `json:"attacks"`
+
+	// Steps has the outcome of every step, in order.
+	Steps []StepResult `json:"steps"`
+
+	Timeline []Event `json:"timeline"`
+}
+
+// StepResult is the outcome of a single step. A step passes once its attack
+// ends in a successful stage, or its wait ends, without a probe failing in
+// between. A step still running when the experiment fails fails too.
+type StepResult struct {
+	Name   string `json:"name"`
+	Status Status `json:"status"`
+
+	// Attack and Stage are the GUID and final stage of the step's attack.
+	Attack string              `json:"attack,omitempty"`
+	Stage  gremlin.AttackStage `json:"stage,omitempty"`
+
+	StartTime time.Time `json:"start_time,omitempty"`
+	EndTime   time.Time `json:"end_time,omitempty"`
+
+	// Failures explain why the step failed, e.g. the probes that failed
+	// while it ran.
+	Failures []string `json:"failures,omitempty"`
+}
+
+// Passed reports whether the experiment passed.
+func (r *Result) Passed() bool {
+	return r.Status == StatusPassed
+}
+
+// Duration returns how long the run took.
+func (r *Result) Duration() time.Duration {
+	return r.EndTime.Sub(r.StartTime)
+}
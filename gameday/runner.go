@@ -0,0 +1,331 @@
+package gameday
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+	gremlin "github.com/sonnysideup/go-gremlin"
+)
+
+// Runner runs experiments against the Gremlin API.
+type Runner struct {
+	API gremlin.AttackService
+
+	// Interval between probe checks and attack polls (default 5s).
+	Interval time.Duration
+
+	// HaltTimeout bounds how long halting attacks after a failure may take
+	// (default 30s). It applies even when the run's context was cancelled.
+	HaltTimeout time.Duration
+
+	// Now and Sleep default to the real clock. Tests can substitute a fake
+	// one; Sleep must return ctx.Err() if ctx is done.
+	Now   func() time.Time
+	Sleep func(ctx context.Context, d time.Duration) error
+}
+
+func (r Runner) withDefaults() Runner {
+	if r.Interval <= 0 {
+		r.Interval = 5 * time.Second
+	}
+	if r.HaltTimeout <= 0 {
+		r.HaltTimeout = 30 * time.Second
+	}
+	if r.Now == nil {
+		r.Now = time.Now
+	}
+	if r.Sleep == nil {
+		r.Sleep = sleep
+	}
+	return r
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Run runs the experiment and returns its result. The error is only set if
+// the experiment is invalid; failures during the run are reported in the
+// Result.
+func (r *Runner) Run(ctx context.Context, exp Experiment) (*Result, error) {
+	if err := exp.Validate(); err != nil {
+		return nil, err
+	}
+
+	x := &run{r: r.withDefaults(), exp: &exp, res: &Result{Experiment: exp.Name, Attacks: []string{}}, owner: map[uuid.UUID]int{}}
+	x.running = make([]bool, len(exp.Steps))
+	for i, step := range exp.Steps {
+		x.res.Steps = append(x.res.Steps, StepResult{Name: step.stepName(i), Status: StatusSkipped})
+	}
+	x.execute(ctx)
+	return x.res, nil
+}
+
+// run is the state of a single experiment run.
+type run struct {
+	r   Runner
+	exp *Experiment
+	res *Result
+
+	// active are the launched attacks not yet seen in a final stage, and
+	// owner maps each of them to the index of the step that launched it
+	active []uuid.UUID
+	owner  map[uuid.UUID]int
+
+	// running marks the steps that have started but not ended
+	running []bool
+}
+
+func (x *run) event(e Event) {
+	e.Time = x.r.Now()
+	x.res.Timeline = append(x.res.Timeline, e)
+}
+
+func (x *run) execute(ctx context.Context) {
+	x.res.StartTime = x.r.Now()
+	x.event(Event{Type: EventExperimentStarted, Message: x.exp.Description})
+
+	if err := x.check(ctx, x.exp.SteadyState, ""); err != nil {
+		status := StatusAborted
+		if ctx.Err() != nil {
+			status = StatusInterrupted
+		}
+		x.finish(status, "Steady state not met: "+err.Error())
+		return
+	}
+
+	for i, step := range x.exp.Steps {
+		name := step.stepName(i)
+		x.event(Event{Type: EventStepStarted, Step: name})
+
+		x.res.Steps[i].Status, x.res.Steps[i].StartTime = StatusPassed, x.r.Now()
+		x.running[i] = true
+
+		var err error
+		if step.Attack != nil {
+			err = x.attack(ctx, i, *step.Attack, !step.NoWait)
+		} else {
+			err = x.watch(ctx, name, x.r.Now().Add(step.Wait))
+			if err == nil {
+				x.event(Event{Type: EventWaitFinished, Step: name})
+				x.endStep(i, "")
+			}
+		}
+		if err != nil {
+			x.fail(ctx, err)
+			return
+		}
+	}
+
+	// attacks launched with NoWait may still be running
+	if err := x.watch(ctx, "", time.Time{}); err != nil {
+		x.fail(ctx, err)
+		return
+	}
+	x.finish(StatusPassed, "")
+}
+
+// attack launches the attack of step i and, if wait is set, watches it until
+// it ends.
+func (x *run) attack(ctx context.Context, i int, ac gremlin.AttackCommand, wait bool) error {
+	step := x.res.Steps[i].Name
+	guid, err := x.r.API.CreateAttackContext(ctx, ac)
+	if err != nil {
+		err = fmt.Errorf("Failed to launch %s: %v", step, err)
+		x.endStep(i, err.Error())
+		return err
+	}
+
+	x.res.Attacks = append(x.res.Attacks, guid.String())
+	x.res.Steps[i].Attack = guid.String()
+	x.active = append(x.active, *guid)
+	x.owner[*guid] = i
+	x.event(Event{Type: EventAttackLaunched, Step: step, Attack: guid.String(), Message: ac.Command.Type})
+
+	if !wait {
+		return nil
+	}
+	for x.isActive(*guid) {
+		if err := x.tick(ctx, step, x.r.Interval); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// watch ticks until until, or if until is zero, until no attacks are active.
+func (x *run) watch(ctx context.Context, step string, until time.Time) error {
+	for {
+		d := x.r.Interval
+		if until.IsZero() {
+			if len(x.active) == 0 {
+				return nil
+			}
+		} else {
+			remaining := until.Sub(x.r.Now())
+			if remaining <= 0 {
+				return nil
+			}
+			if remaining < d {
+				d = remaining
+			}
+		}
+
+		if err := x.tick(ctx, step, d); err != nil {
+			return err
+		}
+	}
+}
+
+// tick sleeps for d, then checks the probes and polls the active attacks.
+// Probes only run while an attack is active.
+func (x *run) tick(ctx context.Context, step string, d time.Duration) error {
+	if err := x.r.Sleep(ctx, d); err != nil {
+		return err
+	}
+	if len(x.active) == 0 {
+		return nil
+	}
+	if err := x.check(ctx, x.exp.Probes, step); err != nil {
+		// every step in progress saw the probe fail
+		for i := range x.running {
+			if x.running[i] {
+				x.endStep(i, err.Error())
+			}
+		}
+		return err
+	}
+	return x.poll(ctx, step)
+}
+
+// detailer is implemented by probe errors that already name their probe,
+// such as *probe.TripError. Their details are reported instead, so the name
+// is not repeated.
+type detailer interface {
+	Details() string
+}
+
+// check runs probes in order and returns the first failure.
+func (x *run) check(ctx context.Context, probes []Probe, step string) error {
+	for _, p := range probes {
+		if err := p.Check(ctx); err != nil {
+			msg := err.Error()
+			if d, ok := err.(detailer); ok {
+				msg = d.Details()
+			}
+			x.event(Event{Type: EventProbeFailed, Step: step, Probe: p.Name(), Message: msg})
+			return fmt.Errorf("Probe %q failed: %s", p.Name(), msg)
+		}
+		x.event(Event{Type: EventProbePassed, Step: step, Probe: p.Name()})
+	}
+	return nil
+}
+
+// poll refreshes the active attacks, dropping those that have finished. An
+// attack that ends in a failure stage fails the experiment.
+func (x *run) poll(ctx context.Context, step string) error {
+	var still []uuid.UUID
+	defer func() { x.active = still }()
+
+	for i, guid := range x.active {
+		attack, err := x.r.API.GetAttack(ctx, guid)
+		if err != nil {
+			still = append(still, x.active[i:]...)
+			return fmt.Errorf("Failed to poll attack %s: %v", guid, err)
+		}
+
+		if !attack.Stage.IsTerminal() {
+			still = append(still, guid)
+			continue
+		}
+		x.event(Event{Type: EventAttackFinished, Step: step, Attack: guid.String(), Message: string(attack.Stage)})
+		owner := x.owner[guid]
+		x.res.Steps[owner].Stage = attack.Stage
+		if attack.Stage.IsFailure() {
+			still = append(still, x.active[i+1:]...)
+			err := fmt.Errorf("Attack %s ended in stage %s", guid, attack.Stage)
+			x.endStep(owner, err.Error())
+			return err
+		}
+		x.endStep(owner, "")
+	}
+	return nil
+}
+
+// endStep ends step i if it is still running, failing it if failure is set.
+func (x *run) endStep(i int, failure string) {
+	if !x.running[i] {
+		return
+	}
+	x.running[i] = false
+
+	s := &x.res.Steps[i]
+	s.EndTime = x.r.Now()
+	if failure != "" {
+		s.Status = StatusFailed
+		s.Failures = append(s.Failures, failure)
+	}
+}
+
+func (x *run) isActive(guid uuid.UUID) bool {
+	for _, g := range x.active {
+		if g == guid {
+			return true
+		}
+	}
+	return false
+}
+
+// fail halts every active attack, runs the rollbacks and finishes the run.
+// Both use a fresh context so they happen even if ctx was cancelled.
+func (x *run) fail(ctx context.Context, cause error) {
+	status := StatusFailed
+	if ctx.Err() != nil {
+		status = StatusInterrupted
+	}
+	reasons := []string{cause.Error()}
+
+	hctx, cancel := context.WithTimeout(context.Background(), x.r.HaltTimeout)
+	defer cancel()
+	for _, guid := range x.active {
+		if err := x.r.API.HaltAttack(hctx, guid); err != nil {
+			x.event(Event{Type: EventAttackHalted, Attack: guid.String(), Message: "Failed to halt: " + err.Error()})
+			reasons = append(reasons, fmt.Sprintf("failed to halt attack %s: %v", guid, err))
+			continue
+		}
+		x.event(Event{Type: EventAttackHalted, Attack: guid.String()})
+	}
+	x.active = nil
+
+	// steps still in progress were cut short
+	for i := range x.running {
+		x.endStep(i, "Stopped early: "+cause.Error())
+	}
+
+	for _, a := range x.exp.Rollbacks {
+		if err := a.Run(context.Background()); err != nil {
+			x.event(Event{Type: EventRollbackFailed, Message: a.Name + ": " + err.Error()})
+			reasons = append(reasons, fmt.Sprintf("rollback %q failed: %v", a.Name, err))
+			continue
+		}
+		x.event(Event{Type: EventRollbackSucceeded, Message: a.Name})
+	}
+
+	x.finish(status, strings.Join(reasons, "; "))
+}
+
+func (x *run) finish(status Status, reason string) {
+	x.res.Status, x.res.Reason = status, reason
+	x.res.EndTime = x.r.Now()
+	x.event(Event{Type: EventExperimentFinished, Message: string(status)})
+}
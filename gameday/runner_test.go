@@ -0,0 +1,277 @@
+package gameday
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	gremlin "github.com/sonnysideup/go-gremlin"
+	"github.com/sonnysideup/go-gremlin/gremlintest"
+)
+
+func newRunner() (*Runner, *gremlintest.Fake) {
+	f := gremlintest.NewFake()
+	f.AddHost(gremlin.Host{Identifier: "web-1", State: gremlin.HostActive})
+	return &Runner{
+		API:      f,
+		Interval: 5 * time.Second,
+		Now:      f.Clock.Now,
+		Sleep: func(ctx context.Context, d time.Duration) error {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			f.Clock.Advance(d)
+			return nil
+		},
+	}, f
+}
+
+func cpu(seconds string) *gremlin.AttackCommand {
+	return &gremlin.AttackCommand{
+		Command: gremlin.Command{Type: "cpu", Args: []string{"-l", seconds}},
+		Target:  gremlin.Target{Type: gremlin.TargetRandom},
+	}
+}
+
+// counter is a probe that counts its checks and fails from the fail-th on.
+type counter struct {
+	checks, fail int
+}
+
+func (c *counter) Name() string { return "counter" }
+
+func (c *counter) Check(ctx context.Context) error {
+	c.checks++
+	if c.fail > 0 && c.checks >= c.fail {
+		return errors.New("latency too high")
+	}
+	return nil
+}
+
+func eventTypes(res *Result) []string {
+	var types []string
+	for _, e := range res.Timeline {
+		if e.Type != EventProbePassed {
+			types = append(types, string(e.Type))
+		}
+	}
+	return types
+}
+
+func TestRunPasses(t *testing.T) {
+	r, f := newRunner()
+	probe := &counter{}
+	rolledBack := false
+
+	res, err := r.Run(context.Background(), Experiment{
+		Name:        "cpu",
+		SteadyState: []Probe{NewProbe("healthy", func(context.Context) error { return nil })},
+		Steps:       []Step{{Name: "burn", Attack: cpu("30")}, {Wait: 10 * time.Second}},
+		Probes:      []Probe{probe},
+		Rollbacks:   []Action{{Name: "restart", Run: func(context.Context) error { rolledBack = true; return nil }}},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if !res.Passed() || res.Reason != "" {
+		t.Fatalf("Expected the experiment to pass, but got %s: %s", res.Status, res.Reason)
+	}
+	want := "experiment_started step_started attack_launched attack_finished step_started wait_finished experiment_finished"
+	if got := strings.Join(eventTypes(res), " "); got != want {
+		t.Errorf("Expected timeline %q, but got %q", want, got)
+	}
+	// pending for 5s then running for 30s, checked every 5s
+	if probe.checks != 7 {
+		t.Errorf("Expected 7 probe checks during the attack, but got %d", probe.checks)
+	}
+	if res.Duration() != 45*time.Second {
+		t.Errorf("Expected the run to take 45s, but got %v", res.Duration())
+	}
+	if len(res.Attacks) != 1 || rolledBack {
+		t.Errorf("Expected one attack and no rollback, but got %v and %v", res.Attacks, rolledBack)
+	}
+	f.AssertNotCalled(t, "HaltAttack")
+}
+
+func TestProbeFailureHaltsAndRollsBack(t *testing.T) {
+	r, f := newRunner()
+	var rollbacks []string
+
+	res, _ := r.Run(context.Background(), Experiment{
+		Name:   "latency",
+		Steps:  []Step{{Name: "background", Attack: cpu("300"), NoWait: true}, {Name: "burn", Attack: cpu("60")}},
+		Probes: []Probe{&counter{fail: 3}},
+		Rollbacks: []Action{
+			{Name: "scale up", Run: func(context.Context) error { rollbacks = append(rollbacks, "scale up"); return nil }},
+			{Name: "page", Run: func(context.Context) error { return errors.New("pager down") }},
+		},
+	})
+
+	if res.Status != StatusFailed {
+		t.Fatalf("Expected the experiment to fail, but got %s", res.Status)
+	}
+	if !strings.Contains(res.Reason, `Probe "counter" failed: latency too high`) || !strings.Contains(res.Reason, `rollback "page" failed: pager down`) {
+		t.Errorf("Expected the probe and rollback failures in the reason, but got %q", res.Reason)
+	}
+
+	f.AssertCallCount(t, "HaltAttack", 2)
+	attacks, _ := f.ListAttacks(context.Background())
+	for _, a := range attacks {
+		if a.Stage != gremlin.StageUserHalted {
+			t.Errorf("Expected attack %s to be halted, but got %s", a.Guid, a.Stage)
+		}
+	}
+	if len(rollbacks) != 1 {
+		t.Errorf("Expected the first rollback to run, but got %v", rollbacks)
+	}
+
+	want := "experiment_started step_started attack_launched step_started attack_launched probe_failed attack_halted attack_halted rollback_succeeded rollback_failed experiment_finished"
+	if got := strings.Join(eventTypes(res), " "); got != want {
+		t.Errorf("Expected timeline %q, but got %q", want, got)
+	}
+}
+
+func TestSteadyStateAborts(t *testing.T) {
+	r, f := newRunner()
+
+	res, _ := r.Run(context.Background(), Experiment{
+		Name:        "cpu",
+		SteadyState: []Probe{NewProbe("healthy", func(context.Context) error { return errors.New("503") })},
+		Steps:       []Step{{Attack: cpu("30")}},
+		Rollbacks:   []Action{{Name: "never", Run: func(context.Context) error { t.Errorf("Unexpected rollback"); return nil }}},
+	})
+
+	if res.Status != StatusAborted || res.Reason != `Steady state not met: Probe "healthy" failed: 503` {
+		t.Errorf("Expected the experiment to abort, but got %s: %s", res.Status, res.Reason)
+	}
+	f.AssertNotCalled(t, "CreateAttackContext")
+}
+
+func TestAttackFailureFailsExperiment(t *testing.T) {
+	r, f := newRunner()
+	f.FailHost("web-1", "daemon crashed")
+
+	res, _ := r.Run(context.Background(), Experiment{Name: "cpu", Steps: []Step{{Attack: cpu("30")}, {Wait: time.Minute}}})
+
+	if res.Status != StatusFailed || !strings.Contains(res.Reason, "ended in stage Failed") {
+		t.Errorf("Expected the failed attack to fail the experiment, but got %s: %s", res.Status, res.Reason)
+	}
+	f.AssertNotCalled(t, "HaltAttack")
+}
+
+func TestNoWaitAttackIsAwaited(t *testing.T) {
+	r, _ := newRunner()
+	probe := &counter{}
+
+	res, _ := r.Run(context.Background(), Experiment{
+		Name:   "background",
+		Steps:  []Step{{Attack: cpu("60"), NoWait: true}, {Wait: 12 * time.Second}},
+		Probes: []Probe{probe},
+	})
+
+	if !res.Passed() {
+		t.Fatalf("Expected the experiment to pass, but got %s: %s", res.Status, res.Reason)
+	}
+	// the wait ends at 12s, so the attack ending at 65s is seen at 67s
+	if res.Duration() != 67*time.Second {
+		t.Errorf("Expected the run to last until the attack was seen to end at 67s, but got %v", res.Duration())
+	}
+	if probe.checks != 14 {
+		t.Errorf("Expected probes to run during the wait and the rest of the attack, but got %d checks", probe.checks)
+	}
+}
+
+func TestInterruptedRunHalts(t *testing.T) {
+	r, f := newRunner()
+	ctx, cancel := context.WithCancel(context.Background())
+	probe := NewProbe("cancel", func(context.Context) error { cancel(); return nil })
+
+	res, _ := r.Run(ctx, Experiment{Name: "cpu", Steps: []Step{{Attack: cpu("30")}}, Probes: []Probe{probe}})
+
+	if res.Status != StatusInterrupted {
+		t.Errorf("Expected the experiment to be interrupted, but got %s: %s", res.Status, res.Reason)
+	}
+	f.AssertCallCount(t, "HaltAttack", 1)
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		exp  Experiment
+		want string
+	}{
+		{Experiment{}, "has no name"},
+		{Experiment{Name: "x"}, "has no steps"},
+		{Experiment{Name: "x", Steps: []Step{{Attack: cpu("1"), Wait: time.Second}}}, "both an attack and a wait"},
+		{Experiment{Name: "x", Steps: []Step{{}}}, "needs an attack or a positive wait"},
+		{Experiment{Name: "x", Steps: []Step{{Wait: time.Second, NoWait: true}}}, "NoWait without an attack"},
+		{Experiment{Name: "x", Steps: []Step{{Wait: time.Second}}, Rollbacks: []Action{{Name: "r"}}}, "has no Run function"},
+	}
+
+	r, _ := newRunner()
+	for _, tt := range tests {
+		if _, err := r.Run(context.Background(), tt.exp); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Expected an error containing %q, but got %v", tt.want, err)
+		}
+	}
+}
+
+func TestStepResults(t *testing.T) {
+	r, f := newRunner()
+
+	res, _ := r.Run(context.Background(), Experiment{
+		Name: "steps",
+		Steps: []Step{
+			{Name: "burn", Attack: cpu("30")},
+			{Name: "background", Attack: cpu("300"), NoWait: true},
+			{Name: "pause", Wait: time.Minute},
+			{Name: "never", Attack: cpu("30")},
+		},
+		Probes: []Probe{&counter{fail: 10}},
+	})
+
+	want := []Status{StatusPassed, StatusFailed, StatusFailed, StatusSkipped}
+	for i, s := range res.Steps {
+		if s.Status != want[i] {
+			t.Errorf("Expected step %q to be %s, but got %s", s.Name, want[i], s.Status)
+		}
+	}
+
+	burn, background := res.Steps[0], res.Steps[1]
+	if burn.Stage != gremlin.StageSuccessful || burn.Attack != res.Attacks[0] || burn.EndTime.Sub(burn.StartTime) != 35*time.Second {
+		t.Errorf("Expected the first step's attack to succeed after 35s, but got %+v", burn)
+	}
+	if len(background.Failures) != 1 || !strings.Contains(background.Failures[0], `Probe "counter" failed`) {
+		t.Errorf("Expected the probe failure on the running steps, but got %v", background.Failures)
+	}
+	if res.Steps[2].Failures[0] != background.Failures[0] {
+		t.Errorf("Expected the wait step to see the same probe failure, but got %v", res.Steps[2].Failures)
+	}
+	f.AssertCallCount(t, "HaltAttack", 1)
+}
+
+func TestAttackFailureStopsOtherSteps(t *testing.T) {
+	r, f := newRunner()
+	f.AddHost(gremlin.Host{Identifier: "web-2", State: gremlin.HostActive})
+	f.FailHost("web-1", "daemon crashed")
+
+	bad := cpu("30")
+	bad.Target = gremlin.Target{Type: gremlin.TargetExact, Exact: []string{"web-1"}}
+	good := cpu("300")
+	good.Target = gremlin.Target{Type: gremlin.TargetExact, Exact: []string{"web-2"}}
+
+	res, _ := r.Run(context.Background(), Experiment{
+		Name:  "steps",
+		Steps: []Step{{Name: "good", Attack: good, NoWait: true}, {Name: "bad", Attack: bad}},
+	})
+
+	good0, bad0 := res.Steps[0], res.Steps[1]
+	if bad0.Stage != gremlin.StageFailed || !strings.Contains(bad0.Failures[0], "ended in stage Failed") {
+		t.Errorf("Expected the failed attack to fail its step, but got %+v", bad0)
+	}
+	if good0.Status != StatusFailed || !strings.HasPrefix(good0.Failures[0], "Stopped early: Attack") {
+		t.Errorf("Expected the other step to be stopped early, but got %+v", good0)
+	}
+}
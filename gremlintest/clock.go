@@ -0,0 +1,97 @@
+package gremlintest
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock is the time source of a Server. It only moves when told to, so tests
+// can step attacks through their stages without sleeping. It can also stand
+// in for the real clock in code that waits with After, such as the
+// scheduler package.
+type Clock struct {
+	mu      sync.Mutex
+	now     time.Time
+	timers  []clockTimer
+	waiters *sync.Cond
+}
+
+type clockTimer struct {
+	at time.Time
+	ch chan time.Time
+}
+
+// NewClock returns a Clock stopped at now.
+func NewClock(now time.Time) *Clock {
+	return &Clock{now: now}
+}
+
+// Now returns the current fake time.
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Advance moves the clock forward by d.
+func (c *Clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	c.fire()
+}
+
+// Set moves the clock to t, which may be in the past.
+func (c *Clock) Set(t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = t
+	c.fire()
+}
+
+// After returns a channel that receives the fake time once the clock has
+// moved d past the current time.
+func (c *Clock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ch := make(chan time.Time, 1)
+	if d <= 0 {
+		ch <- c.now
+		return ch
+	}
+	c.timers = append(c.timers, clockTimer{at: c.now.Add(d), ch: ch})
+	c.cond().Broadcast()
+	return ch
+}
+
+// BlockUntil waits until n channels returned by After are waiting to fire,
+// so a test knows the code under test is asleep before advancing the clock.
+func (c *Clock) BlockUntil(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for len(c.timers) < n {
+		c.cond().Wait()
+	}
+}
+
+// fire delivers every timer that is due. Callers must hold c.mu.
+func (c *Clock) fire() {
+	pending := c.timers[:0]
+	for _, t := range c.timers {
+		if t.at.After(c.now) {
+			pending = append(pending, t)
+			continue
+		}
+		t.ch <- c.now
+	}
+	c.timers = pending
+}
+
+// cond returns the condition BlockUntil waits on. Callers must hold c.mu.
+func (c *Clock) cond() *sync.Cond {
+	if c.waiters == nil {
+		c.waiters = sync.NewCond(&c.mu)
+	}
+	return c.waiters
+}
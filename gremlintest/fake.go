@@ -0,0 +1,509 @@
+package gremlintest
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+	gremlin "github.com/sonnysideup/go-gremlin"
+)
+
+// Fake is an in-memory gremlin.API for code that accepts the interface
+// rather than a *gremlin.Client. It simulates attacks the same way Server
+// does and records every call, but unlike a Client it checks no Policy or
+// blackout calendar.
+type Fake struct {
+	// Clock drives attack progression, as for Server.
+	Clock *Clock
+
+	// Token is returned by Authenticate.
+	Token gremlin.AccessToken
+
+	mu sync.Mutex
+	store
+	startDelay time.Duration
+	schedules  []gremlin.Schedule
+	scenarios  []gremlin.Scenario
+	calls      []Call
+	errs       []*injectedErr
+}
+
+var _ gremlin.API = (*Fake)(nil)
+
+// Call is a recorded method call on a Fake. Args holds the arguments after
+// the context, if the method takes one.
+type Call struct {
+	Method string
+	Args   []interface{}
+}
+
+type injectedErr struct {
+	method string
+	err    error
+	times  int
+}
+
+// NewFake returns an empty Fake.
+func NewFake() *Fake {
+	clock := NewClock(time.Now().UTC().Truncate(time.Second))
+	return &Fake{
+		Clock:      clock,
+		Token:      gremlin.AccessToken{OrganizationName: "Fake Org", Header: "Bearer fake-token"},
+		store:      newStore(clock),
+		startDelay: DefaultStartDelay,
+	}
+}
+
+// SetStartDelay changes how long new attacks stay Pending.
+func (f *Fake) SetStartDelay(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.startDelay = d
+}
+
+// AddHost registers a host, replacing any host with the same identifier.
+func (f *Fake) AddHost(h gremlin.Host) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.addHost(h)
+}
+
+// AddContainer registers a container on one of the hosts.
+func (f *Fake) AddContainer(c gremlin.Container) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.containers = append(f.containers, c)
+}
+
+// FailHost makes executions on the host fail with message once their attack
+// starts.
+func (f *Fake) FailHost(id, message string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.failedHosts[id] = message
+}
+
+// InjectError makes the next times calls to method return err. A negative
+// times fails every call.
+func (f *Fake) InjectError(method string, err error, times int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.errs = append(f.errs, &injectedErr{method: method, err: err, times: times})
+}
+
+// record logs a call and returns any error injected for it. The caller holds
+// f.mu.
+func (f *Fake) record(method string, args ...interface{}) error {
+	f.calls = append(f.calls, Call{Method: method, Args: args})
+	for _, e := range f.errs {
+		if e.method == method && e.times != 0 {
+			if e.times > 0 {
+				e.times--
+			}
+			return e.err
+		}
+	}
+	return nil
+}
+
+// Calls returns every call made so far, in order.
+func (f *Fake) Calls() []Call {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]Call(nil), f.calls...)
+}
+
+// CallsTo returns the calls made to method.
+func (f *Fake) CallsTo(method string) []Call {
+	var found []Call
+	for _, c := range f.Calls() {
+		if c.Method == method {
+			found = append(found, c)
+		}
+	}
+	return found
+}
+
+// ResetCalls forgets the calls made so far.
+func (f *Fake) ResetCalls() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = nil
+}
+
+// AssertCalled fails t unless method was called with args, compared with
+// reflect.DeepEqual. Without args any call to method matches.
+func (f *Fake) AssertCalled(t testing.TB, method string, args ...interface{}) {
+	t.Helper()
+	calls := f.CallsTo(method)
+	for _, c := range calls {
+		if len(args) == 0 || reflect.DeepEqual(c.Args, args) {
+			return
+		}
+	}
+
+	if len(calls) == 0 {
+		t.Errorf("Expected a call to %s, but it was not called", method)
+		return
+	}
+	got := make([]string, len(calls))
+	for i, c := range calls {
+		got[i] = fmt.Sprintf("%+v", c.Args)
+	}
+	t.Errorf("Expected a call to %s with %+v, but got: %s", method, args, strings.Join(got, ", "))
+}
+
+// AssertNotCalled fails t if method was called.
+func (f *Fake) AssertNotCalled(t testing.TB, method string) {
+	t.Helper()
+	if n := len(f.CallsTo(method)); n > 0 {
+		t.Errorf("Expected no calls to %s, but got %d", method, n)
+	}
+}
+
+// AssertCallCount fails t unless method was called exactly n times.
+func (f *Fake) AssertCallCount(t testing.TB, method string, n int) {
+	t.Helper()
+	if got := len(f.CallsTo(method)); got != n {
+		t.Errorf("Expected %d calls to %s, but got %d", n, method, got)
+	}
+}
+
+// Authenticate returns f.Token.
+func (f *Fake) Authenticate() (*gremlin.AccessToken, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("Authenticate"); err != nil {
+		return nil, err
+	}
+	token := f.Token
+	return &token, nil
+}
+
+// CreateAttack launches a simulated attack.
+func (f *Fake) CreateAttack(ac gremlin.AttackCommand) (*uuid.UUID, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("CreateAttack", ac); err != nil {
+		return nil, err
+	}
+	return f.createAttack(ac)
+}
+
+// CreateAttackContext launches a simulated attack.
+func (f *Fake) CreateAttackContext(ctx context.Context, ac gremlin.AttackCommand) (*uuid.UUID, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("CreateAttackContext", ac); err != nil {
+		return nil, err
+	}
+	return f.createAttack(ac)
+}
+
+func (f *Fake) createAttack(ac gremlin.AttackCommand) (*uuid.UUID, error) {
+	if ac.Command.Type == "" {
+		return nil, fmt.Errorf("Invalid attack: missing command type")
+	}
+	guid := f.launch(ac, f.Token.ID, f.startDelay).Guid
+	return &guid, nil
+}
+
+// ListAttacks returns every attack.
+func (f *Fake) ListAttacks(ctx context.Context) ([]gremlin.Attack, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("ListAttacks"); err != nil {
+		return nil, err
+	}
+	return f.listAttacks(all), nil
+}
+
+// ListActiveAttacks returns the attacks not yet in a final stage.
+func (f *Fake) ListActiveAttacks(ctx context.Context) ([]gremlin.Attack, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("ListActiveAttacks"); err != nil {
+		return nil, err
+	}
+	return f.listAttacks(active), nil
+}
+
+// ListCompletedAttacks returns the attacks in a final stage.
+func (f *Fake) ListCompletedAttacks(ctx context.Context) ([]gremlin.Attack, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("ListCompletedAttacks"); err != nil {
+		return nil, err
+	}
+	return f.listAttacks(completed), nil
+}
+
+// GetAttack returns the current state of an attack.
+func (f *Fake) GetAttack(ctx context.Context, guid uuid.UUID) (*gremlin.Attack, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("GetAttack", guid); err != nil {
+		return nil, err
+	}
+
+	a := f.findAttack(guid.String())
+	if a == nil {
+		return nil, fmt.Errorf("Attack %s not found", guid)
+	}
+	state, _ := a.state(f.clock.Now())
+	return &state, nil
+}
+
+// ListExecutions returns the executions of an attack.
+func (f *Fake) ListExecutions(ctx context.Context, guid uuid.UUID) ([]gremlin.Execution, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("ListExecutions", guid); err != nil {
+		return nil, err
+	}
+
+	a := f.findAttack(guid.String())
+	if a == nil {
+		return nil, fmt.Errorf("Attack %s not found", guid)
+	}
+	_, executions := a.state(f.clock.Now())
+	return executions, nil
+}
+
+// HaltAttack halts an attack that is still in progress.
+func (f *Fake) HaltAttack(ctx context.Context, guid uuid.UUID) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("HaltAttack", guid); err != nil {
+		return err
+	}
+
+	a := f.findAttack(guid.String())
+	if a == nil {
+		return fmt.Errorf("Attack %s not found", guid)
+	}
+	f.halt(a)
+	return nil
+}
+
+// HaltAllAttacks halts every attack in progress.
+func (f *Fake) HaltAllAttacks(ctx context.Context) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("HaltAllAttacks"); err != nil {
+		return err
+	}
+	f.haltAll()
+	return nil
+}
+
+// ListClients returns the hosts added with AddHost.
+func (f *Fake) ListClients(ctx context.Context) ([]gremlin.Host, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("ListClients"); err != nil {
+		return nil, err
+	}
+	return append([]gremlin.Host{}, f.hosts...), nil
+}
+
+// ListContainers returns the containers added with AddContainer.
+func (f *Fake) ListContainers(ctx context.Context) ([]gremlin.Container, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("ListContainers"); err != nil {
+		return nil, err
+	}
+	return append([]gremlin.Container{}, f.containers...), nil
+}
+
+// ListTemplates returns the saved templates.
+func (f *Fake) ListTemplates(ctx context.Context) ([]gremlin.Template, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("ListTemplates"); err != nil {
+		return nil, err
+	}
+	return append([]gremlin.Template{}, f.templates...), nil
+}
+
+// CreateTemplate saves a template, assigning it a GUID.
+func (f *Fake) CreateTemplate(ctx context.Context, t gremlin.Template) (*gremlin.Template, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("CreateTemplate", t); err != nil {
+		return nil, err
+	}
+
+	t.Guid, t.CreatedAt = "", time.Time{}
+	created := f.addTemplate(t)
+	return &created, nil
+}
+
+// UpdateTemplate replaces the template with the same GUID.
+func (f *Fake) UpdateTemplate(ctx context.Context, t gremlin.Template) (*gremlin.Template, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("UpdateTemplate", t); err != nil {
+		return nil, err
+	}
+
+	i := f.findTemplate(t.Guid)
+	if i < 0 {
+		return nil, fmt.Errorf("Template %q not found", t.Guid)
+	}
+	t.CreatedAt = f.templates[i].CreatedAt
+	f.templates[i] = t
+	return &t, nil
+}
+
+// DeleteTemplate removes a template.
+func (f *Fake) DeleteTemplate(ctx context.Context, guid string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("DeleteTemplate", guid); err != nil {
+		return err
+	}
+
+	i := f.findTemplate(guid)
+	if i < 0 {
+		return fmt.Errorf("Template %q not found", guid)
+	}
+	f.templates = append(f.templates[:i], f.templates[i+1:]...)
+	return nil
+}
+
+// ListSchedules returns the saved schedules.
+func (f *Fake) ListSchedules(ctx context.Context) ([]gremlin.Schedule, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("ListSchedules"); err != nil {
+		return nil, err
+	}
+	return append([]gremlin.Schedule{}, f.schedules...), nil
+}
+
+// CreateSchedule saves a schedule, assigning it a GUID. Schedules never
+// launch attacks on their own.
+func (f *Fake) CreateSchedule(ctx context.Context, s gremlin.Schedule) (*gremlin.Schedule, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("CreateSchedule", s); err != nil {
+		return nil, err
+	}
+
+	s.Guid = uuid.NewV4().String()
+	f.schedules = append(f.schedules, s)
+	return &s, nil
+}
+
+// UpdateSchedule replaces the schedule with the same GUID.
+func (f *Fake) UpdateSchedule(ctx context.Context, s gremlin.Schedule) (*gremlin.Schedule, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("UpdateSchedule", s); err != nil {
+		return nil, err
+	}
+
+	i := f.findSchedule(s.Guid)
+	if i < 0 {
+		return nil, fmt.Errorf("Schedule %q not found", s.Guid)
+	}
+	f.schedules[i] = s
+	return &s, nil
+}
+
+// DeleteSchedule removes a schedule.
+func (f *Fake) DeleteSchedule(ctx context.Context, guid string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("DeleteSchedule", guid); err != nil {
+		return err
+	}
+
+	i := f.findSchedule(guid)
+	if i < 0 {
+		return fmt.Errorf("Schedule %q not found", guid)
+	}
+	f.schedules = append(f.schedules[:i], f.schedules[i+1:]...)
+	return nil
+}
+
+func (f *Fake) findSchedule(guid string) int {
+	for i, s := range f.schedules {
+		if s.Guid == guid {
+			return i
+		}
+	}
+	return -1
+}
+
+// ListScenarios returns the saved scenarios.
+func (f *Fake) ListScenarios(ctx context.Context) ([]gremlin.Scenario, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("ListScenarios"); err != nil {
+		return nil, err
+	}
+	return append([]gremlin.Scenario{}, f.scenarios...), nil
+}
+
+// GetScenario returns a saved scenario.
+func (f *Fake) GetScenario(ctx context.Context, guid string) (*gremlin.Scenario, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("GetScenario", guid); err != nil {
+		return nil, err
+	}
+	return f.findScenario(guid)
+}
+
+// CreateScenario saves a scenario, assigning it a GUID.
+func (f *Fake) CreateScenario(ctx context.Context, s gremlin.Scenario) (*gremlin.Scenario, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("CreateScenario", s); err != nil {
+		return nil, err
+	}
+
+	s.Guid = uuid.NewV4().String()
+	f.scenarios = append(f.scenarios, s)
+	return &s, nil
+}
+
+// RunScenario launches one attack per step, each starting after the delay
+// that follows the previous step.
+func (f *Fake) RunScenario(ctx context.Context, guid string) (*gremlin.ScenarioRun, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("RunScenario", guid); err != nil {
+		return nil, err
+	}
+
+	scenario, err := f.findScenario(guid)
+	if err != nil {
+		return nil, err
+	}
+
+	offset := f.startDelay
+	for _, step := range scenario.Steps {
+		offset += time.Duration(step.Delay) * time.Second
+		f.launch(step.AttackCommand(), f.Token.ID, offset)
+		offset += step.Command.Length()
+	}
+	return &gremlin.ScenarioRun{Guid: uuid.NewV4().String(), ScenarioID: guid, Stage: gremlin.StagePending}, nil
+}
+
+func (f *Fake) findScenario(guid string) (*gremlin.Scenario, error) {
+	for _, s := range f.scenarios {
+		if s.Guid == guid {
+			return &s, nil
+		}
+	}
+	return nil, fmt.Errorf("Scenario %q not found", guid)
+}
@@ -0,0 +1,115 @@
+package gremlintest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	gremlin "github.com/sonnysideup/go-gremlin"
+)
+
+// launchOnWeb stands in for consumer code that only needs an AttackService.
+func launchOnWeb(attacks gremlin.AttackService) error {
+	_, err := attacks.CreateAttack(gremlin.AttackCommand{
+		Command: gremlin.Command{Type: "cpu", Args: []string{"-l", "30"}},
+		Target:  gremlin.Target{Type: gremlin.TargetRandom, Tags: map[string]string{"role": "web"}},
+	})
+	return err
+}
+
+func TestFakeRecordsCalls(t *testing.T) {
+	f := NewFake()
+	f.AddHost(gremlin.Host{Identifier: "web-1", State: gremlin.HostActive, Tags: map[string]string{"role": "web"}})
+
+	if err := launchOnWeb(f); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	f.AssertCalled(t, "CreateAttack", gremlin.AttackCommand{
+		Command: gremlin.Command{Type: "cpu", Args: []string{"-l", "30"}},
+		Target:  gremlin.Target{Type: gremlin.TargetRandom, Tags: map[string]string{"role": "web"}},
+	})
+	f.AssertCallCount(t, "CreateAttack", 1)
+	f.AssertNotCalled(t, "HaltAttack")
+
+	fake := &testing.T{}
+	f.AssertCalled(fake, "CreateAttack", gremlin.AttackCommand{Command: gremlin.Command{Type: "memory"}})
+	if !fake.Failed() {
+		t.Errorf("Expected AssertCalled to fail for different arguments")
+	}
+
+	f.ResetCalls()
+	f.AssertCallCount(t, "CreateAttack", 0)
+}
+
+func TestFakeSimulatesAttacks(t *testing.T) {
+	f := NewFake()
+	f.AddHost(gremlin.Host{Identifier: "web-1", State: gremlin.HostActive, Tags: map[string]string{"role": "web"}})
+	ctx := context.Background()
+
+	launchOnWeb(f)
+	active, _ := f.ListActiveAttacks(ctx)
+	if len(active) != 1 || active[0].Stage != gremlin.StagePending {
+		t.Fatalf("Expected one pending attack, but got %+v", active)
+	}
+
+	f.Clock.Advance(DefaultStartDelay)
+	attack, err := f.GetAttack(ctx, active[0].Guid)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if attack.Stage != gremlin.StageRunning {
+		t.Errorf("Expected the attack to be running, but got %s", attack.Stage)
+	}
+
+	f.HaltAttack(ctx, attack.Guid)
+	executions, _ := f.ListExecutions(ctx, attack.Guid)
+	if len(executions) != 1 || executions[0].Stage != gremlin.StageUserHalted {
+		t.Errorf("Expected the execution to be halted, but got %+v", executions)
+	}
+}
+
+func TestFakeInjectError(t *testing.T) {
+	f := NewFake()
+	boom := errors.New("boom")
+	f.InjectError("ListClients", boom, 1)
+
+	if _, err := f.ListClients(context.Background()); err != boom {
+		t.Errorf("Expected the injected error, but got %v", err)
+	}
+	if _, err := f.ListClients(context.Background()); err != nil {
+		t.Errorf("Expected the error to be injected once, but got %v", err)
+	}
+	f.AssertCallCount(t, "ListClients", 2)
+}
+
+func TestFakeScenarioRun(t *testing.T) {
+	f := NewFake()
+	f.AddHost(gremlin.Host{Identifier: "web-1", State: gremlin.HostActive})
+	ctx := context.Background()
+
+	step := gremlin.ScenarioStep{Command: gremlin.Command{Type: "cpu", Args: []string{"-l", "10"}}, Target: gremlin.Target{Type: gremlin.TargetRandom}}
+	second := step
+	second.Delay = 5
+	scenario, _ := f.CreateScenario(ctx, gremlin.Scenario{Name: "two steps", Steps: []gremlin.ScenarioStep{step, second}})
+
+	if _, err := f.RunScenario(ctx, scenario.Guid); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	attacks, _ := f.ListAttacks(ctx)
+	if len(attacks) != 2 {
+		t.Fatalf("Expected an attack per step, but got %d", len(attacks))
+	}
+
+	f.Clock.Advance(DefaultStartDelay + 12*time.Second)
+	attacks, _ = f.ListAttacks(ctx)
+	if attacks[0].Stage != gremlin.StageSuccessful || attacks[1].Stage != gremlin.StagePending {
+		t.Errorf("Expected the first step done and the second waiting, but got %s and %s", attacks[0].Stage, attacks[1].Stage)
+	}
+
+	f.Clock.Advance(5 * time.Second)
+	if attack, _ := f.GetAttack(ctx, attacks[1].Guid); attack.Stage != gremlin.StageRunning {
+		t.Errorf("Expected the second step to be running, but got %s", attack.Stage)
+	}
+}
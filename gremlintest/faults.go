@@ -0,0 +1,273 @@
+package gremlintest
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+type faultKind int
+
+const (
+	faultNone faultKind = iota
+	faultLatency
+	faultReset
+	faultTimeout
+	faultStatus
+	faultTruncate
+	faultBody
+)
+
+// Fault is a failure injected by a FaultTransport.
+type Fault struct {
+	kind    faultKind
+	latency time.Duration
+	status  int
+	body    string
+}
+
+// NoFault lets a request through untouched. It is useful in a Script.
+func NoFault() Fault { return Fault{kind: faultNone} }
+
+// Latency delays the request by d before sending it on.
+func Latency(d time.Duration) Fault { return Fault{kind: faultLatency, latency: d} }
+
+// Reset fails the request with a connection reset error.
+func Reset() Fault { return Fault{kind: faultReset} }
+
+// Timeout hangs the request until its context is done. Requests without a
+// deadline fail at once with a timeout error instead.
+func Timeout() Fault { return Fault{kind: faultTimeout} }
+
+// Status answers the request with status without sending it on.
+func Status(status int) Fault { return Fault{kind: faultStatus, status: status} }
+
+// Truncate sends the request on but cuts the response body in half, failing
+// the read of the rest with io.ErrUnexpectedEOF.
+func Truncate() Fault { return Fault{kind: faultTruncate} }
+
+// Body sends the request on and replaces the response body with body, keeping
+// the status, e.g. Body("not-a-uuid") for attacks/new.
+func Body(body string) Fault { return Fault{kind: faultBody, body: body} }
+
+func (f Fault) String() string {
+	switch f.kind {
+	case faultLatency:
+		return fmt.Sprintf("latency %v", f.latency)
+	case faultReset:
+		return "reset"
+	case faultTimeout:
+		return "timeout"
+	case faultStatus:
+		return fmt.Sprintf("status %d", f.status)
+	case faultTruncate:
+		return "truncate"
+	case faultBody:
+		return fmt.Sprintf("body %q", f.body)
+	default:
+		return "none"
+	}
+}
+
+// FaultRule selects the requests a fault is injected into.
+type FaultRule struct {
+	// Method to match, or empty for any method
+	Method string
+
+	// Path is matched with path.Match against the request path and every
+	// suffix of it that starts after a "/", so "attacks/*" matches
+	// "/v1/attacks/123". Empty matches any path.
+	Path string
+
+	// Fault is injected with the given Probability, from 0 to 1. A zero
+	// Probability means every matching request.
+	Fault       Fault
+	Probability float64
+
+	// Script, if set, is injected into successive matching requests in turn,
+	// after which requests go through untouched. Fault and Probability are
+	// then ignored.
+	Script []Fault
+}
+
+// InjectedFault is a fault a FaultTransport injected.
+type InjectedFault struct {
+	Method string
+	Path   string
+	Fault  Fault
+}
+
+// FaultTransport is an http.RoundTripper that injects faults into requests
+// made by a gremlin.Client, to test how code copes with an unreliable API.
+// Random choices come from a seeded source, so a run can be reproduced:
+//
+//	ft := gremlintest.NewFaultTransport(42, nil)
+//	ft.Add(gremlintest.FaultRule{Path: "attacks/*", Fault: gremlintest.Status(503), Probability: 0.2})
+//	client := gremlin.NewClient(company, email, password, gremlin.WithNetClient(ft.Client()))
+type FaultTransport struct {
+	next http.RoundTripper
+
+	mu       sync.Mutex
+	rand     *rand.Rand
+	rules    []*faultRule
+	injected []InjectedFault
+}
+
+type faultRule struct {
+	FaultRule
+	matched int
+}
+
+// NewFaultTransport returns a FaultTransport that sends requests on to next,
+// or http.DefaultTransport if next is nil.
+func NewFaultTransport(seed int64, next http.RoundTripper) *FaultTransport {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &FaultTransport{next: next, rand: rand.New(rand.NewSource(seed))}
+}
+
+// Add adds a rule. The first rule that injects a fault into a request wins.
+func (t *FaultTransport) Add(rule FaultRule) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.rules = append(t.rules, &faultRule{FaultRule: rule})
+}
+
+// Client returns an HTTP client that uses the FaultTransport.
+func (t *FaultTransport) Client() *http.Client {
+	return &http.Client{Transport: t}
+}
+
+// Injected returns the faults injected so far, in order.
+func (t *FaultTransport) Injected() []InjectedFault {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]InjectedFault(nil), t.injected...)
+}
+
+// choose picks the fault for a request and logs it.
+func (t *FaultTransport) choose(req *http.Request) Fault {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, rule := range t.rules {
+		if !rule.matches(req) {
+			continue
+		}
+
+		var f Fault
+		if len(rule.Script) > 0 {
+			if rule.matched < len(rule.Script) {
+				f = rule.Script[rule.matched]
+			}
+			rule.matched++
+		} else if rule.Probability == 0 || t.rand.Float64() < rule.Probability {
+			f = rule.Fault
+		}
+
+		if f.kind != faultNone {
+			t.injected = append(t.injected, InjectedFault{Method: req.Method, Path: req.URL.Path, Fault: f})
+			return f
+		}
+	}
+	return NoFault()
+}
+
+func (r *faultRule) matches(req *http.Request) bool {
+	if r.Method != "" && r.Method != req.Method {
+		return false
+	}
+	if r.Path == "" {
+		return true
+	}
+
+	p := strings.Trim(req.URL.Path, "/")
+	for {
+		if ok, _ := path.Match(r.Path, p); ok {
+			return true
+		}
+		i := strings.Index(p, "/")
+		if i < 0 {
+			return false
+		}
+		p = p[i+1:]
+	}
+}
+
+// RoundTrip injects the fault chosen for the request, if any.
+func (t *FaultTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	f := t.choose(req)
+	ctx := req.Context()
+
+	switch f.kind {
+	case faultLatency:
+		select {
+		case <-time.After(f.latency):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	case faultReset:
+		return nil, &net.OpError{Op: "read", Net: "tcp", Err: os.NewSyscallError("read", syscall.ECONNRESET)}
+	case faultTimeout:
+		if _, ok := ctx.Deadline(); !ok {
+			return nil, &net.OpError{Op: "read", Net: "tcp", Err: timeoutError{}}
+		}
+		<-ctx.Done()
+		return nil, ctx.Err()
+	case faultStatus:
+		return faultResponse(req, f.status, http.StatusText(f.status)), nil
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	switch f.kind {
+	case faultTruncate:
+		body, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		resp.Body = ioutil.NopCloser(io.MultiReader(strings.NewReader(string(body[:len(body)/2])), errReader{io.ErrUnexpectedEOF}))
+		resp.ContentLength = -1
+	case faultBody:
+		resp.Body.Close()
+		resp.Body = ioutil.NopCloser(strings.NewReader(f.body))
+		resp.ContentLength = int64(len(f.body))
+	}
+	return resp, nil
+}
+
+func faultResponse(req *http.Request, status int, body string) *http.Response {
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": {"text/plain"}},
+		Body:          ioutil.NopCloser(strings.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
+
+type errReader struct{ err error }
+
+func (r errReader) Read([]byte) (int, error) { return 0, r.err }
+
+// timeoutError is a net.Error reporting a timeout.
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
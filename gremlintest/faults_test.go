@@ -0,0 +1,176 @@
+package gremlintest
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	gremlin "github.com/sonnysideup/go-gremlin"
+)
+
+// faultyClient returns a client of s whose requests go through ft. It
+// authenticates before any rules are added.
+func faultyClient(s *Server, ft *FaultTransport) *gremlin.Client {
+	client := gremlin.NewClient("Test Org", "user@domain.com", "secret", gremlin.WithURL(s.URL), gremlin.WithNetClient(ft.Client()))
+	if _, err := client.Authenticate(); err != nil {
+		panic(err)
+	}
+	return client
+}
+
+func TestFaultKinds(t *testing.T) {
+	s, _ := newTestServer()
+	defer s.Close()
+	ctx := context.Background()
+	ac := cpuAttack(gremlin.Target{Type: gremlin.TargetRandom})
+
+	tests := []struct {
+		fault Fault
+		call  func(*gremlin.Client) error
+		want  string
+	}{
+		{Status(http.StatusServiceUnavailable), func(c *gremlin.Client) error { _, err := c.ListClients(ctx); return err }, "status: 503"},
+		{Body("abc-its-easy-as-1-2-3"), func(c *gremlin.Client) error { _, err := c.CreateAttack(ac); return err }, "Invalid UUID from server"},
+		{Truncate(), func(c *gremlin.Client) error { _, err := c.ListClients(ctx); return err }, "Failed to unmarshal response"},
+		{Reset(), func(c *gremlin.Client) error { _, err := c.ListClients(ctx); return err }, "connection reset"},
+		{Timeout(), func(c *gremlin.Client) error { _, err := c.ListClients(ctx); return err }, "i/o timeout"},
+	}
+
+	for _, tt := range tests {
+		ft := NewFaultTransport(1, nil)
+		client := faultyClient(s, ft)
+		ft.Add(FaultRule{Fault: tt.fault})
+
+		if err := tt.call(client); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%v: expected an error containing %q, but got %v", tt.fault, tt.want, err)
+		}
+	}
+}
+
+func TestResetAndTimeoutErrors(t *testing.T) {
+	ft := NewFaultTransport(1, nil)
+	ft.Add(FaultRule{Path: "reset", Fault: Reset()})
+	ft.Add(FaultRule{Path: "timeout", Fault: Timeout()})
+
+	_, err := ft.RoundTrip(httpRequest(context.Background(), "GET", "http://gremlin.invalid/reset"))
+	if !errors.Is(err, syscall.ECONNRESET) {
+		t.Errorf("Expected ECONNRESET, but got %v", err)
+	}
+
+	_, err = ft.RoundTrip(httpRequest(context.Background(), "GET", "http://gremlin.invalid/timeout"))
+	if ne, ok := err.(net.Error); !ok || !ne.Timeout() {
+		t.Errorf("Expected a net timeout error, but got %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = ft.RoundTrip(httpRequest(ctx, "GET", "http://gremlin.invalid/timeout"))
+	if err != context.DeadlineExceeded {
+		t.Errorf("Expected the request to hang until its deadline, but got %v", err)
+	}
+}
+
+func httpRequest(ctx context.Context, method, url string) *http.Request {
+	req, _ := http.NewRequest(method, url, nil)
+	return req.WithContext(ctx)
+}
+
+func TestFaultLatency(t *testing.T) {
+	s, _ := newTestServer()
+	defer s.Close()
+
+	ft := NewFaultTransport(1, nil)
+	client := faultyClient(s, ft)
+	ft.Add(FaultRule{Method: "GET", Path: "clients", Fault: Latency(time.Second)})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := client.ListClients(ctx); err == nil {
+		t.Errorf("Expected the delayed request to time out")
+	}
+	if _, err := client.ListContainers(context.Background()); err != nil {
+		t.Errorf("Expected requests to other paths to be untouched, but got %v", err)
+	}
+}
+
+func TestFaultScript(t *testing.T) {
+	s, _ := newTestServer()
+	defer s.Close()
+	ctx := context.Background()
+
+	ft := NewFaultTransport(1, nil)
+	client := faultyClient(s, ft)
+	ft.Add(FaultRule{Method: "GET", Path: "attacks/*", Script: []Fault{Status(502), NoFault(), Reset()}})
+
+	guid, err := client.CreateAttack(cpuAttack(gremlin.Target{Type: gremlin.TargetRandom}))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var got []bool
+	for i := 0; i < 4; i++ {
+		_, err := client.GetAttack(ctx, *guid)
+		got = append(got, err == nil)
+	}
+	if want := []bool{false, true, false, true}; !equalBools(got, want) {
+		t.Errorf("Expected successes %v, but got %v", want, got)
+	}
+
+	injected := ft.Injected()
+	if len(injected) != 2 || injected[0].Fault.String() != "status 502" || injected[1].Fault.String() != "reset" {
+		t.Errorf("Expected the scripted faults to be logged, but got %+v", injected)
+	}
+	if injected[0].Path != "/attacks/"+guid.String() {
+		t.Errorf("Expected the fault path to be logged, but got %q", injected[0].Path)
+	}
+}
+
+func equalBools(a, b []bool) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestFaultProbabilityIsSeeded(t *testing.T) {
+	s, _ := newTestServer()
+	defer s.Close()
+
+	run := func(seed int64) []bool {
+		ft := NewFaultTransport(seed, nil)
+		client := faultyClient(s, ft)
+		ft.Add(FaultRule{Path: "clients", Fault: Status(500), Probability: 0.3})
+
+		var failed []bool
+		for i := 0; i < 50; i++ {
+			_, err := client.ListClients(context.Background())
+			failed = append(failed, err != nil)
+		}
+		return failed
+	}
+
+	first, second := run(7), run(7)
+	if !equalBools(first, second) {
+		t.Errorf("Expected the same seed to inject the same faults")
+	}
+
+	n := 0
+	for _, f := range first {
+		if f {
+			n++
+		}
+	}
+	if n < 5 || n > 25 {
+		t.Errorf("Expected about 30%% of 50 requests to fail, but %d did", n)
+	}
+}
@@ -0,0 +1,206 @@
+package gremlintest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+	gremlin "github.com/sonnysideup/go-gremlin"
+)
+
+// Org is the state of one organization on a Server. Its methods are safe to
+// call while the server is handling requests.
+type Org struct {
+	s    *Server
+	name string
+	id   string
+	store
+}
+
+func newOrg(s *Server, name, id string) *Org {
+	return &Org{s: s, name: name, id: id, store: newStore(s.Clock)}
+}
+
+// AddHost registers a host, replacing any host with the same identifier.
+func (o *Org) AddHost(h gremlin.Host) {
+	o.s.mu.Lock()
+	defer o.s.mu.Unlock()
+	o.addHost(h)
+}
+
+// AddContainer registers a container on one of the hosts.
+func (o *Org) AddContainer(c gremlin.Container) {
+	o.s.mu.Lock()
+	defer o.s.mu.Unlock()
+	o.containers = append(o.containers, c)
+}
+
+// AddTemplate stores a template as if it had been created through the API
+// and returns it with its GUID set.
+func (o *Org) AddTemplate(t gremlin.Template) gremlin.Template {
+	o.s.mu.Lock()
+	defer o.s.mu.Unlock()
+	return o.addTemplate(t)
+}
+
+// Templates returns the stored templates.
+func (o *Org) Templates() []gremlin.Template {
+	o.s.mu.Lock()
+	defer o.s.mu.Unlock()
+	return append([]gremlin.Template(nil), o.templates...)
+}
+
+// FailHost makes executions on the host fail with message once their attack
+// starts. Attacks with a failed execution end in the Failed stage.
+func (o *Org) FailHost(id, message string) {
+	o.s.mu.Lock()
+	defer o.s.mu.Unlock()
+	o.failedHosts[id] = message
+}
+
+// Attacks returns every attack launched in the organization, as of the
+// server clock, oldest first.
+func (o *Org) Attacks() []gremlin.Attack {
+	o.s.mu.Lock()
+	defer o.s.mu.Unlock()
+	return o.listAttacks(all)
+}
+
+// Executions returns the executions of an attack as of the server clock.
+func (o *Org) Executions(guid uuid.UUID) []gremlin.Execution {
+	o.s.mu.Lock()
+	defer o.s.mu.Unlock()
+
+	a := o.findAttack(guid.String())
+	if a == nil {
+		return nil
+	}
+	_, executions := a.state(o.clock.Now())
+	return executions
+}
+
+// serve handles an authorized API request for the organization.
+func (o *Org) serve(w http.ResponseWriter, r *http.Request, p, email string) {
+	o.s.mu.Lock()
+	defer o.s.mu.Unlock()
+
+	parts := strings.Split(p, "/")
+	switch {
+	case p == "attacks/new" && r.Method == "POST":
+		o.createAttack(w, r, email)
+	case p == "attacks" && r.Method == "GET":
+		writeJSON(w, http.StatusOK, o.listAttacks(all))
+	case p == "attacks/active" && r.Method == "GET":
+		writeJSON(w, http.StatusOK, o.listAttacks(active))
+	case p == "attacks/completed" && r.Method == "GET":
+		writeJSON(w, http.StatusOK, o.listAttacks(completed))
+	case p == "attacks" && r.Method == "DELETE":
+		o.haltAll()
+	case len(parts) == 2 && parts[0] == "attacks":
+		o.serveAttack(w, r, parts[1])
+	case p == "executions" && r.Method == "GET":
+		a := o.findAttack(r.URL.Query().Get("taskId"))
+		if a == nil {
+			http.Error(w, "attack not found", http.StatusNotFound)
+			return
+		}
+		_, executions := a.state(o.clock.Now())
+		writeJSON(w, http.StatusOK, executions)
+	case p == "clients" && r.Method == "GET":
+		writeJSON(w, http.StatusOK, append([]gremlin.Host{}, o.hosts...))
+	case p == "containers" && r.Method == "GET":
+		writeJSON(w, http.StatusOK, append([]gremlin.Container{}, o.containers...))
+	case p == "templates":
+		o.serveTemplates(w, r)
+	case len(parts) == 2 && parts[0] == "templates":
+		o.serveTemplate(w, r, parts[1])
+	default:
+		http.Error(w, "not found", http.StatusNotFound)
+	}
+}
+
+func (o *Org) createAttack(w http.ResponseWriter, r *http.Request, email string) {
+	var ac gremlin.AttackCommand
+	if err := json.NewDecoder(r.Body).Decode(&ac); err != nil {
+		http.Error(w, "invalid attack: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if ac.Command.Type == "" {
+		http.Error(w, "invalid attack: missing command type", http.StatusBadRequest)
+		return
+	}
+
+	a := o.launch(ac, email, o.s.startDelay)
+	w.WriteHeader(http.StatusCreated)
+	fmt.Fprint(w, a.Guid.String())
+}
+
+func (o *Org) serveAttack(w http.ResponseWriter, r *http.Request, guid string) {
+	a := o.findAttack(guid)
+	if a == nil {
+		http.Error(w, "attack not found", http.StatusNotFound)
+		return
+	}
+
+	switch r.Method {
+	case "GET":
+		state, _ := a.state(o.clock.Now())
+		writeJSON(w, http.StatusOK, state)
+	case "DELETE":
+		o.halt(a)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (o *Org) serveTemplates(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "GET":
+		writeJSON(w, http.StatusOK, append([]gremlin.Template{}, o.templates...))
+	case "POST":
+		var t gremlin.Template
+		if err := json.NewDecoder(r.Body).Decode(&t); err != nil {
+			http.Error(w, "invalid template: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		t.Guid, t.CreatedAt = "", time.Time{}
+		writeJSON(w, http.StatusCreated, o.addTemplate(t))
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (o *Org) serveTemplate(w http.ResponseWriter, r *http.Request, guid string) {
+	i := o.findTemplate(guid)
+	if i < 0 {
+		http.Error(w, "template not found", http.StatusNotFound)
+		return
+	}
+
+	switch r.Method {
+	case "GET":
+		writeJSON(w, http.StatusOK, o.templates[i])
+	case "PUT":
+		var t gremlin.Template
+		if err := json.NewDecoder(r.Body).Decode(&t); err != nil {
+			http.Error(w, "invalid template: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		t.Guid, t.CreatedAt = guid, o.templates[i].CreatedAt
+		o.templates[i] = t
+		writeJSON(w, http.StatusOK, t)
+	case "DELETE":
+		o.templates = append(o.templates[:i], o.templates[i+1:]...)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,309 @@
+package gremlintest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Mode selects whether a Recorder talks to the network.
+type Mode int
+
+const (
+	// ModeReplay serves responses from the cassette and fails any request it
+	// has no recording for.
+	ModeReplay Mode = iota
+
+	// ModeRecord sends requests to the real API and saves the interactions
+	// when the Recorder is stopped.
+	ModeRecord
+)
+
+// Redacted replaces credentials and tokens in cassettes.
+const Redacted = "REDACTED"
+
+// DefaultRedactFields are the form and JSON fields redacted from recorded
+// bodies: the login credentials and the access tokens returned by users/auth.
+var DefaultRedactFields = []string{"email", "password", "token", "renew_token", "header"}
+
+// redactHeaders are never written to a cassette.
+var redactHeaders = []string{"Authorization", "Cookie", "Set-Cookie"}
+
+// Cassette is the JSON file a Recorder reads and writes.
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Interaction is one recorded request and its response.
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// RecordedRequest holds the parts of a request that are matched on replay.
+// Query and Body are normalized so that equivalent requests compare equal.
+type RecordedRequest struct {
+	Method string `json:"method"`
+	Path   string `json:"path"`
+	Query  string `json:"query,omitempty"`
+	Body   string `json:"body,omitempty"`
+}
+
+// RecordedResponse is a response replayed verbatim, apart from redactions.
+type RecordedResponse struct {
+	Status int         `json:"status"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body"`
+}
+
+// Recorder is an http.RoundTripper that records interactions with the Gremlin
+// API to a cassette, or replays them without network access. Pass it to the
+// client with gremlin.WithNetClient(r.Client()):
+//
+//	mode := gremlintest.ModeReplay
+//	if os.Getenv("GREMLIN_RECORD") != "" {
+//		mode = gremlintest.ModeRecord
+//	}
+//	r, err := gremlintest.NewRecorder("testdata/attacks.json", mode)
+//	...
+//	defer func() {
+//		if err := r.Stop(); err != nil {
+//			t.Error(err)
+//		}
+//	}()
+type Recorder struct {
+	// Transport sends requests while recording. It defaults to
+	// http.DefaultTransport.
+	Transport http.RoundTripper
+
+	// RedactFields are the form and JSON body fields replaced with Redacted.
+	// It defaults to DefaultRedactFields.
+	RedactFields []string
+
+	path string
+	mode Mode
+
+	mu        sync.Mutex
+	cassette  Cassette
+	used      []bool
+	unmatched []RecordedRequest
+}
+
+// NewRecorder returns a Recorder for the cassette at path. In ModeReplay the
+// cassette must exist.
+func NewRecorder(path string, mode Mode) (*Recorder, error) {
+	r := &Recorder{path: path, mode: mode, RedactFields: DefaultRedactFields}
+	if mode == ModeRecord {
+		return r, nil
+	}
+
+	bs, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read cassette: %v", err)
+	}
+	if err := json.Unmarshal(bs, &r.cassette); err != nil {
+		return nil, fmt.Errorf("Failed to parse cassette %s: %v", path, err)
+	}
+	r.used = make([]bool, len(r.cassette.Interactions))
+	return r, nil
+}
+
+// Client returns an HTTP client that uses the Recorder.
+func (r *Recorder) Client() *http.Client {
+	return &http.Client{Transport: r}
+}
+
+// RoundTrip records or replays a single request.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		if body, err = ioutil.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		req.Body.Close()
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+
+	recorded := RecordedRequest{
+		Method: req.Method,
+		Path:   req.URL.Path,
+		Query:  req.URL.Query().Encode(),
+		Body:   r.redact(req.Header.Get("Content-Type"), body),
+	}
+
+	if r.mode == ModeRecord {
+		return r.record(req, recorded)
+	}
+	return r.replay(req, recorded)
+}
+
+func (r *Recorder) record(req *http.Request, recorded RecordedRequest) (*http.Response, error) {
+	transport := r.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	header := resp.Header.Clone()
+	for _, h := range redactHeaders {
+		header.Del(h)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cassette.Interactions = append(r.cassette.Interactions, Interaction{
+		Request: recorded,
+		Response: RecordedResponse{
+			Status: resp.StatusCode,
+			Header: header,
+			Body:   r.redact(resp.Header.Get("Content-Type"), body),
+		},
+	})
+	return resp, nil
+}
+
+// replay serves the first unused interaction matching the request, so that
+// repeated requests, such as polls of an attack, replay in recorded order.
+func (r *Recorder) replay(req *http.Request, recorded RecordedRequest) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, in := range r.cassette.Interactions {
+		if r.used[i] || in.Request != recorded {
+			continue
+		}
+		r.used[i] = true
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", in.Response.Status, http.StatusText(in.Response.Status)),
+			StatusCode:    in.Response.Status,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        in.Response.Header.Clone(),
+			Body:          ioutil.NopCloser(strings.NewReader(in.Response.Body)),
+			ContentLength: int64(len(in.Response.Body)),
+			Request:       req,
+		}, nil
+	}
+
+	r.unmatched = append(r.unmatched, recorded)
+	return nil, fmt.Errorf("No recorded interaction in %s for %s", r.path, describeRequest(recorded))
+}
+
+func describeRequest(req RecordedRequest) string {
+	s := req.Method + " " + req.Path
+	if req.Query != "" {
+		s += "?" + req.Query
+	}
+	if req.Body != "" {
+		s += " " + req.Body
+	}
+	return s
+}
+
+// Stop finishes the Recorder. In ModeRecord it writes the cassette. In
+// ModeReplay it returns an error if any request went unmatched, so tests fail
+// even when the code under test swallowed the request error.
+func (r *Recorder) Stop() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.mode == ModeReplay {
+		if len(r.unmatched) == 0 {
+			return nil
+		}
+		lines := make([]string, len(r.unmatched))
+		for i, req := range r.unmatched {
+			lines[i] = "  " + describeRequest(req)
+		}
+		return fmt.Errorf("%d requests had no recorded interaction in %s:\n%s", len(r.unmatched), r.path, strings.Join(lines, "\n"))
+	}
+
+	bs, err := json.MarshalIndent(r.cassette, "", "  ")
+	if err != nil {
+		return fmt.Errorf("Failed to marshal cassette: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(r.path), 0755); err != nil {
+		return fmt.Errorf("Failed to write cassette: %v", err)
+	}
+	if err := ioutil.WriteFile(r.path, append(bs, '\n'), 0644); err != nil {
+		return fmt.Errorf("Failed to write cassette: %v", err)
+	}
+	return nil
+}
+
+// redact replaces the RedactFields in a form or JSON body, which also
+// normalizes it: form fields and JSON object keys come out sorted. Other
+// bodies are returned unchanged.
+func (r *Recorder) redact(contentType string, body []byte) string {
+	if len(body) == 0 {
+		return ""
+	}
+
+	if strings.HasPrefix(contentType, "application/x-www-form-urlencoded") {
+		form, err := url.ParseQuery(string(body))
+		if err != nil {
+			return string(body)
+		}
+		for _, f := range r.RedactFields {
+			if _, ok := form[f]; ok {
+				form.Set(f, Redacted)
+			}
+		}
+		return form.Encode()
+	}
+
+	var v interface{}
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil || dec.More() {
+		return string(body)
+	}
+	bs, _ := json.Marshal(r.redactJSON(v))
+	return string(bs)
+}
+
+func (r *Recorder) redactJSON(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, field := range v {
+			if r.redactsField(k) {
+				v[k] = Redacted
+			} else {
+				v[k] = r.redactJSON(field)
+			}
+		}
+	case []interface{}:
+		for i := range v {
+			v[i] = r.redactJSON(v[i])
+		}
+	}
+	return v
+}
+
+func (r *Recorder) redactsField(name string) bool {
+	for _, f := range r.RedactFields {
+		if f == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,134 @@
+package gremlintest
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	gremlin "github.com/sonnysideup/go-gremlin"
+)
+
+// exercise authenticates, launches an attack and polls it twice, so the poll
+// responses differ.
+func exercise(t *testing.T, client *gremlin.Client, clock *Clock) []gremlin.AttackStage {
+	if _, err := client.Authenticate(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	guid, err := client.CreateAttack(cpuAttack(gremlin.Target{Type: gremlin.TargetRandom, Count: 1}))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var stages []gremlin.AttackStage
+	for i := 0; i < 2; i++ {
+		attack, err := client.GetAttack(context.Background(), *guid)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		stages = append(stages, attack.Stage)
+		if clock != nil {
+			clock.Advance(time.Minute)
+		}
+	}
+	return stages
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassettes", "attack.json")
+
+	s, _ := newTestServer()
+	rec, err := NewRecorder(path, ModeRecord)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	client := gremlin.NewClient("Test Org", "user@domain.com", "secret", gremlin.WithURL(s.URL), gremlin.WithNetClient(rec.Client()))
+	recorded := exercise(t, client, s.Clock)
+	s.Close()
+	if err := rec.Stop(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	bs, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, secret := range []string{"secret", "Bearer token-", "Authorization"} {
+		if strings.Contains(string(bs), secret) {
+			t.Errorf("Expected %q to be redacted from the cassette:\n%s", secret, bs)
+		}
+	}
+	if !strings.Contains(string(bs), "email=REDACTED") {
+		t.Errorf("Expected the login email to be redacted from the cassette:\n%s", bs)
+	}
+
+	// The server is closed, so replay must not touch the network.
+	rep, err := NewRecorder(path, ModeReplay)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	client = gremlin.NewClient("Test Org", "someone@else.com", "other", gremlin.WithURL(s.URL), gremlin.WithNetClient(rep.Client()))
+	replayed := exercise(t, client, nil)
+	if err := rep.Stop(); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if recorded[0] != gremlin.StagePending || replayed[0] != recorded[0] || replayed[1] != recorded[1] {
+		t.Errorf("Expected the polls to replay in order as %v, but got %v", recorded, replayed)
+	}
+}
+
+func TestReplayUnmatched(t *testing.T) {
+	path := filepath.Join("testdata", "clients.json")
+	rec, err := NewRecorder(path, ModeReplay)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	client := gremlin.NewClient("Test Org", "user@domain.com", "secret", gremlin.WithURL("http://gremlin.invalid/v1/"), gremlin.WithNetClient(rec.Client()))
+	ctx := context.Background()
+
+	hosts, err := client.ListClients(ctx)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(hosts) != 1 || hosts[0].Identifier != "web-1" {
+		t.Errorf("Expected the recorded host, but got %+v", hosts)
+	}
+
+	// The single recording is used up, and containers were never recorded.
+	for _, call := range []func() error{
+		func() error { _, err := client.ListClients(ctx); return err },
+		func() error { _, err := client.ListContainers(ctx); return err },
+	} {
+		if err := call(); err == nil || !strings.Contains(err.Error(), "No recorded interaction") {
+			t.Errorf("Expected an unmatched request to fail, but got %v", err)
+		}
+	}
+
+	err = rec.Stop()
+	if err == nil || !strings.Contains(err.Error(), "2 requests") || !strings.Contains(err.Error(), "GET /v1/containers") {
+		t.Errorf("Expected Stop to report the unmatched requests, but got %v", err)
+	}
+
+	if _, err := NewRecorder(filepath.Join("testdata", "missing.json"), ModeReplay); err == nil {
+		t.Errorf("Expected replaying a missing cassette to fail")
+	}
+}
+
+func TestRedactNormalizesBodies(t *testing.T) {
+	r := &Recorder{RedactFields: DefaultRedactFields}
+
+	tests := []struct {
+		contentType, body, want string
+	}{
+		{"application/x-www-form-urlencoded", "password=x&email=a%40b&companyName=Acme", "companyName=Acme&email=REDACTED&password=REDACTED"},
+		{"application/json", `{"b":1,"a":[{"token":"t"}],"n":12345678901234567890}`, `{"a":[{"token":"REDACTED"}],"b":1,"n":12345678901234567890}`},
+		{"text/plain", "123e4567-e89b-12d3-a456-426655440000", "123e4567-e89b-12d3-a456-426655440000"},
+	}
+	for _, tt := range tests {
+		if got := r.redact(tt.contentType, []byte(tt.body)); got != tt.want {
+			t.Errorf("Expected %s to normalize to %s, but got %s", tt.body, tt.want, got)
+		}
+	}
+}
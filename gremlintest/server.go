@@ -0,0 +1,371 @@
+// Package gremlintest provides an in-memory fake of the Gremlin API for
+// testing code that uses the gremlin package.
+//
+// A Server keeps per-organization state (hosts, containers, templates and
+// attacks) and moves attacks through their stages according to its Clock:
+//
+//	s := gremlintest.NewServer()
+//	defer s.Close()
+//	s.AddUser("user@example.com", "secret", "Acme")
+//	s.Org("Acme").AddHost(gremlin.Host{Identifier: "web-1", State: gremlin.HostActive})
+//
+//	client := s.Client("Acme", "user@example.com", "secret")
+//	guid, _ := client.CreateAttack(ac)
+//	s.Clock.Advance(time.Minute)
+//
+// Errors and latency can be injected per endpoint, and every request is
+// recorded so tests can assert on what was sent.
+package gremlintest
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	gremlin "github.com/sonnysideup/go-gremlin"
+)
+
+// DefaultStartDelay is how long a new attack stays Pending before it runs.
+const DefaultStartDelay = 5 * time.Second
+
+// Server is a fake Gremlin API listening on a local address.
+type Server struct {
+	// URL of the server, suitable for gremlin.WithURL
+	URL string
+
+	// Clock drives attack progression. It starts at the time the server
+	// was created and never moves on its own.
+	Clock *Clock
+
+	srv *httptest.Server
+
+	mu         sync.Mutex
+	startDelay time.Duration
+	users      map[string]*user
+	orgs       map[string]*Org
+	tokens     map[string]session // by Authorization header
+	hooks      []Hook
+	requests   []Request
+	nextID     int
+}
+
+type user struct {
+	password string
+	orgs     []string
+}
+
+// session is what an issued token grants access to.
+type session struct {
+	org   *Org
+	email string
+}
+
+// Hook is run for every request before it is served. A hook that writes a
+// response must return true, and the request is not served any further.
+type Hook func(w http.ResponseWriter, r *http.Request) bool
+
+// Request is a request received by the Server.
+type Request struct {
+	Method string
+
+	// Path is relative to the API root, e.g. "attacks/new"
+	Path   string
+	Query  url.Values
+	Header http.Header
+	Body   []byte
+
+	// Org is the organization the request was authorized for, if any
+	Org string
+}
+
+// NewServer starts a Server with no users or organizations. Close it when
+// done.
+func NewServer() *Server {
+	s := &Server{
+		Clock:      NewClock(time.Now().UTC().Truncate(time.Second)),
+		startDelay: DefaultStartDelay,
+		users:      make(map[string]*user),
+		orgs:       make(map[string]*Org),
+		tokens:     make(map[string]session),
+	}
+	s.srv = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	s.URL = s.srv.URL
+	return s
+}
+
+// Close shuts the server down.
+func (s *Server) Close() {
+	s.srv.Close()
+}
+
+// Client returns a gremlin.Client for the server, authenticated as email in
+// company. It panics if authentication fails, which means the user was not
+// added with AddUser.
+func (s *Server) Client(company, email, password string) *gremlin.Client {
+	client := gremlin.NewClient(company, email, password, gremlin.WithURL(s.URL))
+	if _, err := client.Authenticate(); err != nil {
+		panic(fmt.Sprintf("gremlintest: %v", err))
+	}
+	return client
+}
+
+// SetStartDelay changes how long new attacks stay Pending.
+func (s *Server) SetStartDelay(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.startDelay = d
+}
+
+// AddUser registers a user who belongs to orgs, creating any organization
+// that does not exist yet. Authenticating returns a token for each of them.
+func (s *Server) AddUser(email, password string, orgs ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, name := range orgs {
+		s.org(name)
+	}
+	s.users[email] = &user{password: password, orgs: orgs}
+}
+
+// Org returns the state of the named organization, creating it if needed.
+func (s *Server) Org(name string) *Org {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.org(name)
+}
+
+func (s *Server) org(name string) *Org {
+	o, ok := s.orgs[name]
+	if !ok {
+		s.nextID++
+		o = newOrg(s, name, fmt.Sprintf("org-%d", s.nextID))
+		s.orgs[name] = o
+	}
+	return o
+}
+
+// AddHook registers a hook run before every request, after earlier hooks.
+func (s *Server) AddHook(h Hook) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hooks = append(s.hooks, h)
+}
+
+// InjectError makes the next times requests matching method and pattern fail
+// with status. An empty method matches any method, and pattern is matched
+// against the request path with path.Match, e.g. "attacks/*". A negative
+// times fails every matching request.
+func (s *Server) InjectError(method, pattern string, status int, times int) {
+	var mu sync.Mutex
+	s.AddHook(func(w http.ResponseWriter, r *http.Request) bool {
+		if !matches(r, method, pattern) {
+			return false
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		if times == 0 {
+			return false
+		}
+		if times > 0 {
+			times--
+		}
+		http.Error(w, fmt.Sprintf("injected %d", status), status)
+		return true
+	})
+}
+
+// InjectLatency delays every request matching method and pattern by d, in
+// real time. See InjectError for the matching rules.
+func (s *Server) InjectLatency(method, pattern string, d time.Duration) {
+	s.AddHook(func(w http.ResponseWriter, r *http.Request) bool {
+		if matches(r, method, pattern) {
+			select {
+			case <-time.After(d):
+			case <-r.Context().Done():
+			}
+		}
+		return false
+	})
+}
+
+// ClearHooks removes every hook, including injected errors and latency.
+func (s *Server) ClearHooks() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hooks = nil
+}
+
+func matches(r *http.Request, method, pattern string) bool {
+	if method != "" && method != r.Method {
+		return false
+	}
+	ok, _ := path.Match(pattern, apiPath(r))
+	return ok
+}
+
+func apiPath(r *http.Request) string {
+	return strings.Trim(r.URL.Path, "/")
+}
+
+// Requests returns every request received so far, in order.
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+// ResetRequests forgets the requests received so far.
+func (s *Server) ResetRequests() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = nil
+}
+
+// Matching returns the received requests matching method and pattern. See
+// InjectError for the matching rules.
+func (s *Server) Matching(method, pattern string) []Request {
+	var found []Request
+	for _, r := range s.Requests() {
+		if method != "" && method != r.Method {
+			continue
+		}
+		if ok, _ := path.Match(pattern, r.Path); ok {
+			found = append(found, r)
+		}
+	}
+	return found
+}
+
+// AssertRequested fails t unless a request matching method and pattern was
+// received.
+func (s *Server) AssertRequested(t testing.TB, method, pattern string) {
+	t.Helper()
+	if len(s.Matching(method, pattern)) == 0 {
+		t.Errorf("Expected a %s %s request, but got: %s", describeMethod(method), pattern, s.summary())
+	}
+}
+
+// AssertNotRequested fails t if a request matching method and pattern was
+// received.
+func (s *Server) AssertNotRequested(t testing.TB, method, pattern string) {
+	t.Helper()
+	if n := len(s.Matching(method, pattern)); n > 0 {
+		t.Errorf("Expected no %s %s requests, but got %d", describeMethod(method), pattern, n)
+	}
+}
+
+// AssertRequestCount fails t unless exactly n requests matching method and
+// pattern were received.
+func (s *Server) AssertRequestCount(t testing.TB, method, pattern string, n int) {
+	t.Helper()
+	if got := len(s.Matching(method, pattern)); got != n {
+		t.Errorf("Expected %d %s %s requests, but got %d", n, describeMethod(method), pattern, got)
+	}
+}
+
+func describeMethod(method string) string {
+	if method == "" {
+		return "*"
+	}
+	return method
+}
+
+func (s *Server) summary() string {
+	requests := s.Requests()
+	if len(requests) == 0 {
+		return "no requests"
+	}
+	lines := make([]string, len(requests))
+	for i, r := range requests {
+		lines[i] = r.Method + " " + r.Path
+	}
+	return strings.Join(lines, ", ")
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := ioutil.ReadAll(r.Body)
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	s.mu.Lock()
+	sess, authorized := s.tokens[r.Header.Get("Authorization")]
+	req := Request{
+		Method: r.Method,
+		Path:   apiPath(r),
+		Query:  r.URL.Query(),
+		Header: r.Header.Clone(),
+		Body:   body,
+	}
+	if authorized {
+		req.Org = sess.org.name
+	}
+	s.requests = append(s.requests, req)
+	hooks := append([]Hook(nil), s.hooks...)
+	s.mu.Unlock()
+
+	for _, h := range hooks {
+		if h(w, r) {
+			return
+		}
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+
+	if req.Path == "users/auth" {
+		s.authenticate(w, r)
+		return
+	}
+	if !authorized {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	sess.org.serve(w, r, req.Path, sess.email)
+}
+
+// authenticate issues a fresh token for each organization of the user.
+func (s *Server) authenticate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, ok := s.users[r.PostForm.Get("email")]
+	if !ok || u.password != r.PostForm.Get("password") {
+		http.Error(w, "invalid credentials", http.StatusUnauthorized)
+		return
+	}
+
+	tokens := make([]gremlin.AccessToken, len(u.orgs))
+	for i, name := range u.orgs {
+		o := s.orgs[name]
+		s.nextID++
+		secret := fmt.Sprintf("token-%d", s.nextID)
+		tokens[i] = gremlin.AccessToken{
+			ID:               r.PostForm.Get("email"),
+			Header:           "Bearer " + secret,
+			OrganizationID:   o.id,
+			OrganizationName: o.name,
+			Token:            secret,
+			RenewToken:       "renew-" + secret,
+			Role:             "USER",
+			ExpiresAt:        s.Clock.Now().Add(24 * time.Hour),
+		}
+		s.tokens[tokens[i].Header] = session{org: o, email: r.PostForm.Get("email")}
+	}
+	writeJSON(w, http.StatusOK, tokens)
+}
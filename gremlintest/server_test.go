@@ -0,0 +1,309 @@
+package gremlintest
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	gremlin "github.com/sonnysideup/go-gremlin"
+)
+
+func newTestServer() (*Server, *gremlin.Client) {
+	s := NewServer()
+	s.AddUser("user@domain.com", "secret", "Other Org", "Test Org")
+	org := s.Org("Test Org")
+	org.AddHost(gremlin.Host{Identifier: "web-2", State: gremlin.HostActive, Tags: map[string]string{"role": "web"}})
+	org.AddHost(gremlin.Host{Identifier: "web-1", State: gremlin.HostActive, Tags: map[string]string{"role": "web"}})
+	org.AddHost(gremlin.Host{Identifier: "db-1", State: gremlin.HostIdle, Tags: map[string]string{"role": "db"}})
+	return s, s.Client("Test Org", "user@domain.com", "secret")
+}
+
+func cpuAttack(target gremlin.Target) gremlin.AttackCommand {
+	return gremlin.AttackCommand{
+		Command: gremlin.Command{Type: "cpu", Args: []string{"-l", "30"}},
+		Target:  target,
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	s, _ := newTestServer()
+	defer s.Close()
+
+	other := s.Client("Other Org", "user@domain.com", "secret")
+	if other.Token.OrganizationName != "Other Org" || !strings.HasPrefix(other.Token.Header, "Bearer ") {
+		t.Errorf("Expected a bearer token for Other Org, but got %+v", other.Token)
+	}
+
+	bad := gremlin.NewClient("Test Org", "user@domain.com", "wrong", gremlin.WithURL(s.URL))
+	if _, err := bad.Authenticate(); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Errorf("Expected a 401 for a wrong password, but got %v", err)
+	}
+
+	anonymous := gremlin.NewClient("Test Org", "user@domain.com", "secret", gremlin.WithURL(s.URL))
+	if _, err := anonymous.ListClients(context.Background()); err == nil {
+		t.Errorf("Expected an unauthenticated request to fail")
+	}
+}
+
+func TestOrganizationsAreIsolated(t *testing.T) {
+	s, client := newTestServer()
+	defer s.Close()
+
+	other := s.Client("Other Org", "user@domain.com", "secret")
+	hosts, err := other.ListClients(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(hosts) != 0 {
+		t.Errorf("Expected no hosts in Other Org, but got %v", hosts)
+	}
+
+	if _, err := client.CreateAttack(cpuAttack(gremlin.Target{Type: gremlin.TargetRandom})); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if n := len(s.Org("Other Org").Attacks()); n != 0 {
+		t.Errorf("Expected no attacks in Other Org, but got %d", n)
+	}
+	if got := s.Matching("POST", "attacks/new")[0].Org; got != "Test Org" {
+		t.Errorf("Expected the attack to be recorded for Test Org, but got %q", got)
+	}
+}
+
+func TestAttackProgression(t *testing.T) {
+	s, client := newTestServer()
+	defer s.Close()
+	ctx := context.Background()
+	created := s.Clock.Now()
+
+	guid, err := client.CreateAttack(cpuAttack(gremlin.Target{Type: gremlin.TargetRandom, Tags: map[string]string{"role": "web"}, Count: 5}))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	steps := []struct {
+		advance time.Duration
+		stage   gremlin.AttackStage
+		active  int
+	}{
+		{0, gremlin.StagePending, 1},
+		{DefaultStartDelay, gremlin.StageRunning, 1},
+		{29 * time.Second, gremlin.StageRunning, 1},
+		{time.Second, gremlin.StageSuccessful, 0},
+	}
+	for _, step := range steps {
+		s.Clock.Advance(step.advance)
+		attack, err := client.GetAttack(ctx, *guid)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if attack.Stage != step.stage {
+			t.Errorf("At %v: expected stage %s, but got %s", s.Clock.Now().Sub(created), step.stage, attack.Stage)
+		}
+		active, _ := client.ListActiveAttacks(ctx)
+		if len(active) != step.active {
+			t.Errorf("At %v: expected %d active attacks, but got %d", s.Clock.Now().Sub(created), step.active, len(active))
+		}
+	}
+
+	attack, _ := client.GetAttack(ctx, *guid)
+	if want := created.Add(DefaultStartDelay + 30*time.Second); !attack.EndTime.Equal(want) {
+		t.Errorf("Expected the attack to end at %v, but got %v", want, attack.EndTime)
+	}
+	if attack.CreateUser != "user@domain.com" {
+		t.Errorf("Expected the attack to be created by user@domain.com, but got %q", attack.CreateUser)
+	}
+
+	executions, err := client.ListExecutions(ctx, *guid)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(executions) != 2 || executions[0].HostID != "web-1" || executions[1].HostID != "web-2" {
+		t.Fatalf("Expected executions on the active web hosts, but got %+v", executions)
+	}
+	for _, ex := range executions {
+		if ex.Stage != gremlin.StageSuccessful {
+			t.Errorf("Expected execution on %s to succeed, but got %s", ex.HostID, ex.Stage)
+		}
+	}
+}
+
+func TestHaltAttack(t *testing.T) {
+	s, client := newTestServer()
+	defer s.Close()
+	ctx := context.Background()
+
+	guid, _ := client.CreateAttack(cpuAttack(gremlin.Target{Type: gremlin.TargetExact, Exact: []string{"web-1"}}))
+	s.Clock.Advance(10 * time.Second)
+	if err := client.HaltAttack(ctx, *guid); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	s.Clock.Advance(time.Minute)
+
+	attack, _ := client.GetAttack(ctx, *guid)
+	if attack.Stage != gremlin.StageUserHalted || attack.EndTime.Sub(attack.StartTime) != 5*time.Second {
+		t.Errorf("Expected the attack to be halted after 5s, but got %s from %v to %v", attack.Stage, attack.StartTime, attack.EndTime)
+	}
+
+	completed, _ := client.ListCompletedAttacks(ctx)
+	if len(completed) != 1 {
+		t.Errorf("Expected 1 completed attack, but got %d", len(completed))
+	}
+
+	second, _ := client.CreateAttack(cpuAttack(gremlin.Target{Type: gremlin.TargetRandom}))
+	if err := client.HaltAllAttacks(ctx); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if attack, _ := client.GetAttack(ctx, *second); attack.Stage != gremlin.StageUserHalted {
+		t.Errorf("Expected a pending attack to be halted, but got %s", attack.Stage)
+	}
+}
+
+func TestFailedTargets(t *testing.T) {
+	s, client := newTestServer()
+	defer s.Close()
+	ctx := context.Background()
+
+	missing, _ := client.CreateAttack(cpuAttack(gremlin.Target{Type: gremlin.TargetExact, Exact: []string{"db-1"}}))
+	s.Org("Test Org").FailHost("web-2", "boom")
+	partial, _ := client.CreateAttack(cpuAttack(gremlin.Target{Type: gremlin.TargetRandom, Percent: 100}))
+	s.Clock.Advance(DefaultStartDelay)
+
+	if attack, _ := client.GetAttack(ctx, *missing); attack.Stage != gremlin.StageTargetNotFound {
+		t.Errorf("Expected an attack on an idle host to find no target, but got %s", attack.Stage)
+	}
+	if attack, _ := client.GetAttack(ctx, *partial); attack.Stage != gremlin.StageRunning {
+		t.Errorf("Expected a partially failed attack to keep running, but got %s", attack.Stage)
+	}
+
+	s.Clock.Advance(time.Minute)
+	if attack, _ := client.GetAttack(ctx, *partial); attack.Stage != gremlin.StageFailed {
+		t.Errorf("Expected a partially failed attack to end Failed, but got %s", attack.Stage)
+	}
+	executions, _ := client.ListExecutions(ctx, *partial)
+	if executions[1].HostID != "web-2" || executions[1].Stage != gremlin.StageFailed || executions[1].Error != "boom" {
+		t.Errorf("Expected the execution on web-2 to fail, but got %+v", executions[1])
+	}
+}
+
+func TestContainerTargets(t *testing.T) {
+	s, client := newTestServer()
+	defer s.Close()
+
+	org := s.Org("Test Org")
+	org.AddContainer(gremlin.Container{Identifier: "c1", HostID: "web-1", Labels: map[string]string{"app": "api"}})
+	org.AddContainer(gremlin.Container{Identifier: "c2", HostID: "web-2", Labels: map[string]string{"app": "worker"}})
+
+	ac := cpuAttack(gremlin.Target{Type: gremlin.TargetRandom, Percent: 100})
+	ac.Labels = map[string]string{"app": "api"}
+	guid, _ := client.CreateAttack(ac)
+
+	executions := org.Executions(*guid)
+	if len(executions) != 1 || executions[0].ContainerID != "c1" {
+		t.Errorf("Expected one execution on c1, but got %+v", executions)
+	}
+
+	containers, _ := client.ListContainers(context.Background())
+	if len(containers) != 2 {
+		t.Errorf("Expected 2 containers, but got %d", len(containers))
+	}
+}
+
+func TestTemplates(t *testing.T) {
+	s, client := newTestServer()
+	defer s.Close()
+	ctx := context.Background()
+
+	created, err := client.CreateTemplate(ctx, gremlin.Template{Name: "cpu", Command: gremlin.Command{Type: "cpu"}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if created.Guid == "" || !created.CreatedAt.Equal(s.Clock.Now()) {
+		t.Errorf("Expected the template to get a GUID and creation time, but got %+v", created)
+	}
+
+	created.Description = "updated"
+	if _, err := client.UpdateTemplate(ctx, *created); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	templates, _ := client.ListTemplates(ctx)
+	if len(templates) != 1 || templates[0].Description != "updated" {
+		t.Errorf("Expected the updated template, but got %+v", templates)
+	}
+
+	if err := client.DeleteTemplate(ctx, created.Guid); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if n := len(s.Org("Test Org").Templates()); n != 0 {
+		t.Errorf("Expected no templates after delete, but got %d", n)
+	}
+	if err := client.DeleteTemplate(ctx, created.Guid); err == nil {
+		t.Errorf("Expected deleting a missing template to fail")
+	}
+}
+
+func TestInjectError(t *testing.T) {
+	s, client := newTestServer()
+	defer s.Close()
+	ctx := context.Background()
+
+	s.InjectError("GET", "clients", http.StatusServiceUnavailable, 1)
+	if _, err := client.ListClients(ctx); err == nil || !strings.Contains(err.Error(), "503") {
+		t.Errorf("Expected an injected 503, but got %v", err)
+	}
+	if _, err := client.ListClients(ctx); err != nil {
+		t.Errorf("Expected the error to be injected once, but got %v", err)
+	}
+
+	s.InjectError("", "attacks/*", http.StatusInternalServerError, -1)
+	for i := 0; i < 2; i++ {
+		if _, err := client.ListActiveAttacks(ctx); err == nil {
+			t.Errorf("Expected every matching request to fail")
+		}
+	}
+	s.ClearHooks()
+	if _, err := client.ListActiveAttacks(ctx); err != nil {
+		t.Errorf("Expected no errors after clearing hooks, but got %v", err)
+	}
+}
+
+func TestInjectLatency(t *testing.T) {
+	s, client := newTestServer()
+	defer s.Close()
+
+	s.InjectLatency("GET", "clients", time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := client.ListClients(ctx); err == nil {
+		t.Errorf("Expected the delayed request to time out")
+	}
+}
+
+func TestAssertions(t *testing.T) {
+	s, client := newTestServer()
+	defer s.Close()
+
+	client.CreateAttack(cpuAttack(gremlin.Target{Type: gremlin.TargetRandom}))
+	client.ListAttacks(context.Background())
+
+	s.AssertRequested(t, "POST", "users/auth")
+	s.AssertRequested(t, "POST", "attacks/new")
+	s.AssertRequestCount(t, "", "attacks/*", 1)
+	s.AssertRequestCount(t, "GET", "attacks", 1)
+	s.AssertNotRequested(t, "DELETE", "attacks/*")
+
+	req := s.Matching("POST", "attacks/new")[0]
+	if !strings.Contains(string(req.Body), `"type":"cpu"`) || req.Header.Get("Content-Type") != "application/json" {
+		t.Errorf("Expected the attack JSON to be recorded, but got %s", req.Body)
+	}
+
+	fake := &testing.T{}
+	s.AssertRequested(fake, "GET", "templates")
+	if !fake.Failed() {
+		t.Errorf("Expected AssertRequested to fail for a request that was not made")
+	}
+
+	s.ResetRequests()
+	s.AssertRequestCount(t, "", "*", 0)
+}
@@ -0,0 +1,255 @@
+package gremlintest
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+	gremlin "github.com/sonnysideup/go-gremlin"
+)
+
+// store is the state of one organization, shared by Server and Fake. Callers
+// hold the lock of whichever owns it.
+type store struct {
+	clock *Clock
+
+	hosts       []gremlin.Host
+	containers  []gremlin.Container
+	templates   []gremlin.Template
+	attacks     []*attack
+	failedHosts map[string]string
+}
+
+func newStore(clock *Clock) store {
+	return store{clock: clock, failedHosts: make(map[string]string)}
+}
+
+func (st *store) addHost(h gremlin.Host) {
+	for i := range st.hosts {
+		if st.hosts[i].Identifier == h.Identifier {
+			st.hosts[i] = h
+			return
+		}
+	}
+	st.hosts = append(st.hosts, h)
+}
+
+func (st *store) addTemplate(t gremlin.Template) gremlin.Template {
+	if t.Guid == "" {
+		t.Guid = uuid.NewV4().String()
+	}
+	if t.CreatedAt.IsZero() {
+		t.CreatedAt = st.clock.Now()
+	}
+	st.templates = append(st.templates, t)
+	return t
+}
+
+func (st *store) findTemplate(guid string) int {
+	for i, t := range st.templates {
+		if t.Guid == guid {
+			return i
+		}
+	}
+	return -1
+}
+
+// launch starts an attack that runs startDelay from now.
+func (st *store) launch(ac gremlin.AttackCommand, user string, startDelay time.Duration) *attack {
+	a := &attack{
+		Attack: gremlin.Attack{
+			Guid:       uuid.NewV4(),
+			Command:    ac.Command,
+			Target:     ac.Target,
+			CreateUser: user,
+			CreatedAt:  st.clock.Now(),
+		},
+		startDelay: startDelay,
+		targets:    st.selectTargets(ac),
+	}
+	st.attacks = append(st.attacks, a)
+	return a
+}
+
+// listAttacks returns the attacks kept by keep as of the clock, oldest first.
+func (st *store) listAttacks(keep func(gremlin.Attack) bool) []gremlin.Attack {
+	now := st.clock.Now()
+	attacks := []gremlin.Attack{}
+	for _, a := range st.attacks {
+		if state, _ := a.state(now); keep(state) {
+			attacks = append(attacks, state)
+		}
+	}
+	return attacks
+}
+
+func all(gremlin.Attack) bool { return true }
+
+func active(a gremlin.Attack) bool { return !a.Stage.IsTerminal() }
+
+func completed(a gremlin.Attack) bool { return a.Stage.IsTerminal() }
+
+func (st *store) findAttack(guid string) *attack {
+	for _, a := range st.attacks {
+		if a.Guid.String() == guid {
+			return a
+		}
+	}
+	return nil
+}
+
+// halt stops an attack that is still in progress.
+func (st *store) halt(a *attack) {
+	now := st.clock.Now()
+	if state, _ := a.state(now); !state.Stage.IsTerminal() {
+		a.haltedAt = now
+	}
+}
+
+func (st *store) haltAll() {
+	for _, a := range st.attacks {
+		st.halt(a)
+	}
+}
+
+// attack is a launched attack. Its stage is derived from the clock whenever
+// it is read.
+type attack struct {
+	gremlin.Attack
+	startDelay time.Duration
+	targets    []execTarget
+	haltedAt   time.Time
+}
+
+type execTarget struct {
+	host, container string
+	failure         string
+}
+
+// state returns the attack and its executions as of now.
+func (a *attack) state(now time.Time) (gremlin.Attack, []gremlin.Execution) {
+	state := a.Attack
+	start := a.CreatedAt.Add(a.startDelay)
+	end := start.Add(a.Command.Length())
+	halted := !a.haltedAt.IsZero() && !a.haltedAt.After(now) && a.haltedAt.Before(end)
+
+	executions := make([]gremlin.Execution, len(a.targets))
+	failed := 0
+	for i, t := range a.targets {
+		ex := gremlin.Execution{
+			Guid:        fmt.Sprintf("%s-%d", a.Guid, i+1),
+			AttackID:    a.Guid,
+			HostID:      t.host,
+			ContainerID: t.container,
+			Stage:       gremlin.StagePending,
+			CreatedAt:   a.CreatedAt,
+		}
+		switch {
+		case halted && a.haltedAt.Before(start):
+			ex.Stage, ex.EndTime = gremlin.StageUserHalted, a.haltedAt
+		case now.Before(start):
+		case t.failure != "":
+			ex.Stage, ex.Error, ex.StartTime, ex.EndTime = gremlin.StageFailed, t.failure, start, start
+			failed++
+		case halted:
+			ex.Stage, ex.StartTime, ex.EndTime = gremlin.StageUserHalted, start, a.haltedAt
+		case now.Before(end):
+			ex.Stage, ex.StartTime = gremlin.StageRunning, start
+		default:
+			ex.Stage, ex.StartTime, ex.EndTime = gremlin.StageSuccessful, start, end
+		}
+		executions[i] = ex
+	}
+
+	switch {
+	case halted:
+		state.Stage, state.EndTime = gremlin.StageUserHalted, a.haltedAt
+		if !a.haltedAt.Before(start) {
+			state.StartTime = start
+		}
+	case now.Before(start):
+		state.Stage = gremlin.StagePending
+	case len(a.targets) == 0:
+		state.Stage, state.EndTime = gremlin.StageTargetNotFound, start
+	case failed == len(a.targets):
+		state.Stage, state.StartTime, state.EndTime = gremlin.StageFailed, start, start
+	case now.Before(end):
+		state.Stage, state.StartTime = gremlin.StageRunning, start
+	case failed > 0:
+		state.Stage, state.StartTime, state.EndTime = gremlin.StageFailed, start, end
+	default:
+		state.Stage, state.StartTime, state.EndTime = gremlin.StageSuccessful, start, end
+	}
+
+	state.UpdatedAt = state.CreatedAt
+	for _, t := range []time.Time{state.StartTime, state.EndTime} {
+		if t.After(state.UpdatedAt) {
+			state.UpdatedAt = t
+		}
+	}
+	return state, executions
+}
+
+// selectTargets picks the hosts, or containers when labels are set, that an
+// attack hits. Random targets take the first matching hosts by identifier so
+// that runs are repeatable.
+func (st *store) selectTargets(ac gremlin.AttackCommand) []execTarget {
+	var hosts []gremlin.Host
+	if ac.Target.Type == gremlin.TargetExact {
+		for _, id := range ac.Target.Exact {
+			for _, h := range st.hosts {
+				if h.Identifier == id && h.State == gremlin.HostActive {
+					hosts = append(hosts, h)
+				}
+			}
+		}
+	} else {
+		excluded := make(map[string]bool)
+		for _, id := range ac.Target.Exclude {
+			excluded[id] = true
+		}
+		for _, h := range st.hosts {
+			if h.State == gremlin.HostActive && !excluded[h.Identifier] && hasAll(h.Tags, ac.Target.Tags) {
+				hosts = append(hosts, h)
+			}
+		}
+		sort.Slice(hosts, func(i, j int) bool { return hosts[i].Identifier < hosts[j].Identifier })
+	}
+
+	var targets []execTarget
+	for _, h := range hosts {
+		if len(ac.Labels) == 0 {
+			targets = append(targets, execTarget{host: h.Identifier, failure: st.failedHosts[h.Identifier]})
+			continue
+		}
+		for _, c := range st.containers {
+			if c.HostID == h.Identifier && hasAll(c.Labels, ac.Labels) {
+				targets = append(targets, execTarget{host: h.Identifier, container: c.Identifier, failure: st.failedHosts[h.Identifier]})
+			}
+		}
+	}
+
+	if ac.Target.Type != gremlin.TargetExact {
+		size := 1
+		switch {
+		case ac.Target.Count > 0:
+			size = ac.Target.Count
+		case ac.Target.Percent > 0:
+			size = (len(targets)*ac.Target.Percent + 99) / 100
+		}
+		if size < len(targets) {
+			targets = targets[:size]
+		}
+	}
+	return targets
+}
+
+func hasAll(have, want map[string]string) bool {
+	for k, v := range want {
+		if got, ok := have[k]; !ok || got != v {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,222 @@
+package gremlin
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+// Heartbeat records that a controller is alive by writing the current time
+// to a file that a Watchdog reads.
+type Heartbeat struct {
+	Path string
+}
+
+// Beat writes the current time to the heartbeat file.
+func (h *Heartbeat) Beat() error {
+	tmp := h.Path + ".tmp"
+	if err := ioutil.WriteFile(tmp, []byte(time.Now().UTC().Format(time.RFC3339Nano)), 0600); err != nil {
+		return fmt.Errorf("Failed to write heartbeat: %v", err)
+	}
+	if err := os.Rename(tmp, h.Path); err != nil {
+		return fmt.Errorf("Failed to write heartbeat: %v", err)
+	}
+	return nil
+}
+
+// Run beats every interval until the context is cancelled.
+func (h *Heartbeat) Run(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := h.Beat(); err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// lastBeat reads the time of the most recent heartbeat.
+func (h *Heartbeat) lastBeat() (time.Time, error) {
+	data, err := ioutil.ReadFile(h.Path)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Parse(time.RFC3339Nano, strings.TrimSpace(string(data)))
+}
+
+// WatchdogOptions configures a Watchdog. Zero values and nil use the defaults.
+type WatchdogOptions struct {
+	// HeartbeatPath is the file the controller beats into.
+	HeartbeatPath string
+
+	// Threshold is how stale the heartbeat may get before the attack is
+	// halted (default 30s).
+	Threshold time.Duration
+
+	// CheckInterval is how often the heartbeat is read (default Threshold/4).
+	CheckInterval time.Duration
+
+	// Until is when the watchdog stops guarding, normally the time the
+	// attack ends by itself. The zero value guards until the context ends.
+	Until time.Time
+
+	// HaltRetries is how many times a failed halt is retried (default 5;
+	// point it at 0 to disable retries), waiting RetryDelay (default 2s) and
+	// doubling it after every attempt.
+	HaltRetries *int
+	RetryDelay  time.Duration
+
+	// Logger receives a line for every trigger and halt attempt.
+	Logger *log.Logger
+}
+
+// Watchdog halts an attack when its controller stops sending heartbeats.
+type Watchdog struct {
+	client  *Client
+	guid    uuid.UUID
+	opts    WatchdogOptions
+	retries int
+	beat    *Heartbeat
+
+	mu        sync.Mutex
+	triggered bool
+}
+
+// NewWatchdog creates a Watchdog for an attack. It can run in the process that
+// launched the attack, or in a separate process for protection against crashes.
+func (c *Client) NewWatchdog(guid uuid.UUID, opts WatchdogOptions) *Watchdog {
+	if opts.Threshold <= 0 {
+		opts.Threshold = 30 * time.Second
+	}
+	if opts.CheckInterval <= 0 {
+		opts.CheckInterval = opts.Threshold / 4
+	}
+	retries := 5
+	if opts.HaltRetries != nil && *opts.HaltRetries >= 0 {
+		retries = *opts.HaltRetries
+	}
+	if opts.RetryDelay <= 0 {
+		opts.RetryDelay = 2 * time.Second
+	}
+	if opts.Logger == nil {
+		opts.Logger = log.New(os.Stderr, "gremlin watchdog: ", log.LstdFlags)
+	}
+
+	return &Watchdog{client: c, guid: guid, opts: opts, retries: retries, beat: &Heartbeat{Path: opts.HeartbeatPath}}
+}
+
+// Triggered reports whether the watchdog has tried to halt the attack.
+func (w *Watchdog) Triggered() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.triggered
+}
+
+// Run checks the heartbeat until it goes stale, the guard period ends or the
+// context is cancelled. When the heartbeat is stale the attack is halted and
+// Run returns the result of the halt.
+func (w *Watchdog) Run(ctx context.Context) error {
+	ticker := time.NewTicker(w.opts.CheckInterval)
+	defer ticker.Stop()
+
+	for {
+		now := time.Now()
+		if !w.opts.Until.IsZero() && now.After(w.opts.Until) {
+			return nil
+		}
+
+		last, err := w.beat.lastBeat()
+		if err != nil {
+			w.opts.Logger.Printf("attack %s: cannot read heartbeat %s: %v", w.guid, w.opts.HeartbeatPath, err)
+		}
+		if err != nil || now.Sub(last) > w.opts.Threshold {
+			w.mu.Lock()
+			w.triggered = true
+			w.mu.Unlock()
+
+			w.opts.Logger.Printf("attack %s: heartbeat stale since %s (threshold %s), halting",
+				w.guid, last.Format(time.RFC3339), w.opts.Threshold)
+			return w.halt()
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// halt tries HaltAttack until it succeeds or the retries are exhausted. It
+// deliberately ignores cancellation of the Run context, since that may be
+// exactly what went wrong with the controller.
+func (w *Watchdog) halt() error {
+	delay := w.opts.RetryDelay
+	var err error
+
+	for attempt := 1; attempt <= w.retries+1; attempt++ {
+		if err = w.client.HaltAttack(context.Background(), w.guid); err == nil {
+			w.opts.Logger.Printf("attack %s: halted on attempt %d", w.guid, attempt)
+			return nil
+		}
+
+		w.opts.Logger.Printf("attack %s: halt attempt %d failed: %v", w.guid, attempt, err)
+		if attempt <= w.retries {
+			time.Sleep(delay)
+			delay *= 2
+		}
+	}
+
+	return fmt.Errorf("Failed to halt attack %s after %d attempts: %v", w.guid, w.retries+1, err)
+}
+
+// CreateGuardedAttack launches an attack and starts a Watchdog for it in a
+// background goroutine. The caller must keep beating opts.HeartbeatPath, e.g.
+// with Heartbeat.Run, for as long as the attack should continue.
+//
+// ctx only bounds the launch: the watchdog keeps guarding after it is
+// cancelled, until opts.Until (default: once the attack length has passed) or
+// until stop is called. Any error from the watchdog is delivered on the
+// returned channel, which is closed when it stops.
+func (c *Client) CreateGuardedAttack(ctx context.Context, ac AttackCommand, opts WatchdogOptions) (guid *uuid.UUID, errs <-chan error, stop func(), err error) {
+	hb := &Heartbeat{Path: opts.HeartbeatPath}
+	if err := hb.Beat(); err != nil {
+		return nil, nil, nil, err
+	}
+
+	guid, err = c.CreateAttackContext(ctx, ac)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	if opts.Until.IsZero() {
+		opts.Until = time.Now().Add(ac.Command.Length())
+	}
+	w := c.NewWatchdog(*guid, opts)
+
+	wctx, cancel := context.WithDeadline(context.Background(), opts.Until)
+	out := make(chan error, 1)
+	go func() {
+		defer close(out)
+		defer cancel()
+		if err := w.Run(wctx); err != nil && err != wctx.Err() {
+			out <- err
+		}
+	}()
+
+	return guid, out, cancel, nil
+}
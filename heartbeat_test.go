@@ -0,0 +1,189 @@
+package gremlin
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+func tempHeartbeat(t *testing.T) (string, func()) {
+	dir, err := ioutil.TempDir("", "gremlin")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	return filepath.Join(dir, "heartbeat"), func() { os.RemoveAll(dir) }
+}
+
+func TestWatchdogHaltsOnStaleHeartbeat(t *testing.T) {
+	mux, client, teardown := setup()
+	defer teardown()
+
+	path, cleanup := tempHeartbeat(t)
+	defer cleanup()
+
+	guid := uuid.Must(uuid.FromString("123e4567-e89b-12d3-a456-426655440000"))
+
+	// fail the first two halts to exercise the retries
+	var mu sync.Mutex
+	halts := 0
+	mux.HandleFunc("/attacks/"+guid.String(), func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "DELETE")
+		mu.Lock()
+		defer mu.Unlock()
+		if halts++; halts <= 2 {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	})
+
+	hb := &Heartbeat{Path: path}
+	if err := hb.Beat(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var logs bytes.Buffer
+	w := client.NewWatchdog(guid, WatchdogOptions{
+		HeartbeatPath: path,
+		Threshold:     20 * time.Millisecond,
+		CheckInterval: time.Millisecond,
+		RetryDelay:    time.Millisecond,
+		Logger:        log.New(&logs, "", 0),
+	})
+
+	if err := w.Run(context.Background()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if !w.Triggered() {
+		t.Errorf("Expected watchdog to be triggered")
+	}
+	if got, want := halts, 3; got != want {
+		t.Errorf("Expected %d halt attempts, but got %d", want, got)
+	}
+	for _, want := range []string{"heartbeat stale", "halt attempt 2 failed", "halted on attempt 3"} {
+		if !strings.Contains(logs.String(), want) {
+			t.Errorf("Expected log to contain %q, but got %q", want, logs.String())
+		}
+	}
+}
+
+func TestWatchdogLeavesAttackWhileHeartbeatIsFresh(t *testing.T) {
+	mux, client, teardown := setup()
+	defer teardown()
+
+	path, cleanup := tempHeartbeat(t)
+	defer cleanup()
+
+	recorder := &haltRecorder{}
+	recorder.register(mux)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go (&Heartbeat{Path: path}).Run(ctx, time.Millisecond)
+
+	guid, errs, stop, err := client.CreateGuardedAttack(ctx, buildAttack(), WatchdogOptions{
+		HeartbeatPath: path,
+		Threshold:     50 * time.Millisecond,
+		CheckInterval: time.Millisecond,
+		Until:         time.Now().Add(100 * time.Millisecond),
+		Logger:        log.New(ioutil.Discard, "", 0),
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer stop()
+
+	if err := <-errs; err != nil {
+		t.Errorf("Unexpected watchdog error: %v", err)
+	}
+	if halted := recorder.Halted(); len(halted) != 0 {
+		t.Errorf("Expected attack %s to not be halted, but got halts %v", guid, halted)
+	}
+}
+
+func TestGuardedAttackOutlivesLaunchContext(t *testing.T) {
+	mux, client, teardown := setup()
+	defer teardown()
+
+	path, cleanup := tempHeartbeat(t)
+	defer cleanup()
+
+	guid := uuid.Must(uuid.FromString("123e4567-e89b-12d3-a456-426655440000"))
+	mux.HandleFunc("/attacks/new", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(guid.String()))
+	})
+	var mu sync.Mutex
+	halts := 0
+	mux.HandleFunc("/attacks/"+guid.String(), func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		halts++
+		mu.Unlock()
+		w.WriteHeader(http.StatusBadGateway)
+	})
+
+	// the controller gives up on the launch context and stops beating
+	ctx, cancel := context.WithCancel(context.Background())
+	none := 0
+	_, errs, stop, err := client.CreateGuardedAttack(ctx, buildAttack(), WatchdogOptions{
+		HeartbeatPath: path,
+		Threshold:     10 * time.Millisecond,
+		CheckInterval: time.Millisecond,
+		Until:         time.Now().Add(time.Minute),
+		HaltRetries:   &none,
+		Logger:        log.New(ioutil.Discard, "", 0),
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer stop()
+	cancel()
+
+	if err := <-errs; err == nil || !strings.Contains(err.Error(), "after 1 attempts") {
+		t.Errorf("Expected the watchdog to halt once without retrying, but got %v", err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if halts != 1 {
+		t.Errorf("Expected 1 halt attempt, but got %d", halts)
+	}
+}
+
+func TestGuardedAttackStop(t *testing.T) {
+	mux, client, teardown := setup()
+	defer teardown()
+
+	path, cleanup := tempHeartbeat(t)
+	defer cleanup()
+
+	recorder := &haltRecorder{}
+	recorder.register(mux)
+
+	_, errs, stop, err := client.CreateGuardedAttack(context.Background(), buildAttack(), WatchdogOptions{
+		HeartbeatPath: path,
+		Threshold:     time.Minute,
+		CheckInterval: time.Millisecond,
+		Until:         time.Now().Add(time.Minute),
+		Logger:        log.New(ioutil.Discard, "", 0),
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	stop()
+	if err := <-errs; err != nil {
+		t.Errorf("Unexpected watchdog error: %v", err)
+	}
+	if halted := recorder.Halted(); len(halted) != 0 {
+		t.Errorf("Expected a stopped watchdog to leave the attack alone, but got halts %v", halted)
+	}
+}
@@ -0,0 +1,227 @@
+// Package journal records what happened during an experiment: the attacks
+// launched and how they ended on each host, what the probes saw and any notes
+// taken along the way. A Report renders the record as JSON, Markdown or HTML.
+package journal
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+	gremlin "github.com/sonnysideup/go-gremlin"
+	"github.com/sonnysideup/go-gremlin/probe"
+)
+
+// AttackRecord is an attack launched while the journal was recording.
+type AttackRecord struct {
+	Guid       string                `json:"guid,omitempty"`
+	Command    gremlin.AttackCommand `json:"command"`
+	LaunchedAt time.Time             `json:"launched_at"`
+
+	// LaunchError is set if the attack could not be launched.
+	LaunchError string `json:"launch_error,omitempty"`
+
+	// HaltedAt is when a halt was requested through the journal.
+	HaltedAt time.Time `json:"halted_at"`
+
+	// Stage, StartTime, EndTime and Executions are filled in by Refresh.
+	Stage      gremlin.AttackStage `json:"stage,omitempty"`
+	StartTime  time.Time           `json:"start_time"`
+	EndTime    time.Time           `json:"end_time"`
+	Executions []gremlin.Execution `json:"executions,omitempty"`
+}
+
+// MarshalJSON leaves out HaltedAt, StartTime and EndTime while they are not
+// set, which omitempty cannot do for a time.Time.
+func (a AttackRecord) MarshalJSON() ([]byte, error) {
+	type record AttackRecord
+	return json.Marshal(struct {
+		record
+		HaltedAt  *time.Time `json:"halted_at,omitempty"`
+		StartTime *time.Time `json:"start_time,omitempty"`
+		EndTime   *time.Time `json:"end_time,omitempty"`
+	}{record(a), setTime(a.HaltedAt), setTime(a.StartTime), setTime(a.EndTime)})
+}
+
+func setTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+// Sample is one run of a probe.
+type Sample struct {
+	Time    time.Time     `json:"time"`
+	Probe   string        `json:"probe"`
+	Passed  bool          `json:"passed"`
+	Latency time.Duration `json:"latency"`
+	Details string        `json:"details,omitempty"`
+}
+
+// Annotation is a note added to the journal.
+type Annotation struct {
+	Time time.Time `json:"time"`
+	Text string    `json:"text"`
+}
+
+// Journal records an experiment. It is safe for concurrent use.
+type Journal struct {
+	Title      string
+	Hypothesis string
+
+	// Now defaults to time.Now.
+	Now func() time.Time
+
+	mu          sync.Mutex
+	started     time.Time
+	attacks     []*AttackRecord
+	samples     []Sample
+	annotations []Annotation
+	held        *bool
+	conclusion  string
+}
+
+// New starts a journal.
+func New(title, hypothesis string) *Journal {
+	j := &Journal{Title: title, Hypothesis: hypothesis, Now: time.Now}
+	j.started = j.Now()
+	return j
+}
+
+func (j *Journal) now() time.Time {
+	if j.Now == nil {
+		return time.Now()
+	}
+	return j.Now()
+}
+
+// Annotate adds a note to the timeline.
+func (j *Journal) Annotate(format string, args ...interface{}) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.annotations = append(j.annotations, Annotation{Time: j.now(), Text: fmt.Sprintf(format, args...)})
+}
+
+// Conclude records whether the hypothesis held, with a summary.
+func (j *Journal) Conclude(held bool, summary string) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.held, j.conclusion = &held, summary
+}
+
+// Record adds a probe sample.
+func (j *Journal) Record(name string, r probe.Result) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.samples = append(j.samples, Sample{Time: j.now(), Probe: name, Passed: r.Passed, Latency: r.Latency, Details: r.Details})
+}
+
+// Probe returns a probe that records every run of p in the journal.
+func (j *Journal) Probe(p probe.Probe) probe.Probe {
+	return recordingProbe{Probe: p, j: j}
+}
+
+type recordingProbe struct {
+	probe.Probe
+	j *Journal
+}
+
+func (p recordingProbe) Run(ctx context.Context) probe.Result {
+	r := p.Probe.Run(ctx)
+	p.j.Record(p.Name(), r)
+	return r
+}
+
+// Wrap returns an AttackService that records every attack launched and
+// every halt requested through it in the journal.
+func (j *Journal) Wrap(api gremlin.AttackService) gremlin.AttackService {
+	return &recordingService{AttackService: api, j: j}
+}
+
+type recordingService struct {
+	gremlin.AttackService
+	j *Journal
+}
+
+func (s *recordingService) CreateAttack(ac gremlin.AttackCommand) (*uuid.UUID, error) {
+	return s.CreateAttackContext(context.Background(), ac)
+}
+
+func (s *recordingService) CreateAttackContext(ctx context.Context, ac gremlin.AttackCommand) (*uuid.UUID, error) {
+	launched := s.j.now()
+	guid, err := s.AttackService.CreateAttackContext(ctx, ac)
+
+	rec := &AttackRecord{Command: ac, LaunchedAt: launched}
+	if err != nil {
+		rec.LaunchError = err.Error()
+	} else {
+		rec.Guid = guid.String()
+	}
+
+	s.j.mu.Lock()
+	s.j.attacks = append(s.j.attacks, rec)
+	s.j.mu.Unlock()
+	return guid, err
+}
+
+func (s *recordingService) HaltAttack(ctx context.Context, guid uuid.UUID) error {
+	if err := s.AttackService.HaltAttack(ctx, guid); err != nil {
+		return err
+	}
+	s.j.halted(guid.String())
+	return nil
+}
+
+func (s *recordingService) HaltAllAttacks(ctx context.Context) error {
+	if err := s.AttackService.HaltAllAttacks(ctx); err != nil {
+		return err
+	}
+	s.j.halted("")
+	return nil
+}
+
+// halted marks an attack, or every attack if guid is empty, as halted now.
+func (j *Journal) halted(guid string) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	now := j.now()
+	for _, a := range j.attacks {
+		if a.Guid != "" && (guid == "" || a.Guid == guid) && a.HaltedAt.IsZero() {
+			a.HaltedAt = now
+		}
+	}
+}
+
+// Refresh fetches the current stage and the per-host executions of every
+// recorded attack. Call it once the attacks have finished.
+func (j *Journal) Refresh(ctx context.Context, api gremlin.AttackService) error {
+	j.mu.Lock()
+	attacks := append([]*AttackRecord(nil), j.attacks...)
+	j.mu.Unlock()
+
+	for _, rec := range attacks {
+		if rec.Guid == "" {
+			continue
+		}
+		guid := uuid.FromStringOrNil(rec.Guid)
+
+		attack, err := api.GetAttack(ctx, guid)
+		if err != nil {
+			return fmt.Errorf("Failed to refresh attack %s: %v", rec.Guid, err)
+		}
+		executions, err := api.ListExecutions(ctx, guid)
+		if err != nil {
+			return fmt.Errorf("Failed to refresh attack %s: %v", rec.Guid, err)
+		}
+
+		j.mu.Lock()
+		rec.Stage, rec.StartTime, rec.EndTime = attack.Stage, attack.StartTime, attack.EndTime
+		rec.Executions = executions
+		j.mu.Unlock()
+	}
+	return nil
+}
@@ -0,0 +1,196 @@
+package journal
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	gremlin "github.com/sonnysideup/go-gremlin"
+	"github.com/sonnysideup/go-gremlin/gremlintest"
+	"github.com/sonnysideup/go-gremlin/probe"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// golden compares got with testdata/name.golden, rewriting it with -update.
+func golden(t *testing.T, name string, got []byte) {
+	path := filepath.Join("testdata", name+".golden")
+	if *update {
+		if err := ioutil.WriteFile(path, got, 0644); err != nil {
+			t.Fatalf("Failed to update %s: %v", path, err)
+		}
+		return
+	}
+
+	want, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read %s: %v", path, err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s: output does not match the golden file\ngot:\n%s\nwant:\n%s", name, got, want)
+	}
+}
+
+func cpuAttack() gremlin.AttackCommand {
+	return gremlin.AttackCommand{
+		Command: gremlin.Command{Type: "cpu", Args: []string{"-l", "30"}},
+		Target:  gremlin.Target{Type: gremlin.TargetRandom, Count: 2},
+	}
+}
+
+func TestWrapRecordsAttacks(t *testing.T) {
+	f := gremlintest.NewFake()
+	f.AddHost(gremlin.Host{Identifier: "web-1", State: gremlin.HostActive})
+	f.AddHost(gremlin.Host{Identifier: "web-2", State: gremlin.HostActive})
+	f.FailHost("web-2", "daemon crashed")
+	f.InjectError("CreateAttackContext", errors.New("quota exceeded"), 1)
+	ctx := context.Background()
+
+	j := New("cpu", "")
+	j.Now = f.Clock.Now
+	api := j.Wrap(f)
+
+	if _, err := api.CreateAttack(cpuAttack()); err == nil {
+		t.Fatalf("Expected the injected error")
+	}
+	guid, err := api.CreateAttack(cpuAttack())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	second, _ := api.CreateAttackContext(ctx, cpuAttack())
+
+	f.Clock.Advance(10 * time.Second)
+	if err := api.HaltAttack(ctx, *second); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	f.Clock.Advance(time.Minute)
+	if err := j.Refresh(ctx, f); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	r := j.Report()
+	if len(r.Attacks) != 3 || r.Attacks[0].LaunchError != "quota exceeded" || r.Attacks[1].Guid != guid.String() {
+		t.Fatalf("Expected a failed launch and two attacks, but got %+v", r.Attacks)
+	}
+	first, halted := r.Attacks[1], r.Attacks[2]
+	if first.Stage != gremlin.StageFailed || len(first.Executions) != 2 || first.Executions[1].Error != "daemon crashed" {
+		t.Errorf("Expected the per-host results of the first attack, but got %s and %+v", first.Stage, first.Executions)
+	}
+	if !first.HaltedAt.IsZero() || halted.HaltedAt.IsZero() || halted.Stage != gremlin.StageUserHalted {
+		t.Errorf("Expected only the second attack to be halted, but got %v and %v (%s)", first.HaltedAt, halted.HaltedAt, halted.Stage)
+	}
+}
+
+func TestProbeRecordsSamples(t *testing.T) {
+	j := New("probes", "")
+	healthy := true
+	p := j.Probe(probe.Func("api", func(context.Context) error {
+		if !healthy {
+			return errors.New("503")
+		}
+		return nil
+	}))
+
+	for _, h := range []bool{true, true, false, false, true} {
+		healthy = h
+		p.Run(context.Background())
+	}
+
+	r := j.Report()
+	if len(r.Samples) != 5 || r.Probes[0].Failures != 2 || r.Probes[0].Samples != 5 {
+		t.Errorf("Expected 5 samples with 2 failures, but got %+v", r.Probes)
+	}
+	var kinds []string
+	for _, e := range r.Timeline {
+		kinds = append(kinds, e.Kind)
+	}
+	if got := strings.Join(kinds, " "); got != "probe_passed probe_failed probe_passed" {
+		t.Errorf("Expected only probe state changes in the timeline, but got %q", got)
+	}
+}
+
+// testJournal returns a journal with fixed contents for the golden tests.
+func testJournal() *Journal {
+	clock := gremlintest.NewClock(time.Date(2018, 3, 1, 12, 0, 0, 0, time.UTC))
+	j := New("Checkout under CPU pressure", "Checkout stays | under 500ms at p99")
+	j.Now = clock.Now
+	j.started = clock.Now()
+	start := clock.Now()
+
+	j.Record("http GET https://shop/healthz", probe.Result{Passed: true, Latency: 80 * time.Millisecond, Details: "OK"})
+	j.attacks = append(j.attacks, &AttackRecord{
+		Guid:       "123e4567-e89b-12d3-a456-426655440000",
+		Command:    cpuAttack(),
+		LaunchedAt: start.Add(time.Second),
+		Stage:      gremlin.StageUserHalted,
+		StartTime:  start.Add(6 * time.Second),
+		EndTime:    start.Add(21 * time.Second),
+		HaltedAt:   start.Add(20 * time.Second),
+		Executions: []gremlin.Execution{
+			{HostID: "web-1", Stage: gremlin.StageUserHalted, StartTime: start.Add(6 * time.Second), EndTime: start.Add(21 * time.Second)},
+			{HostID: "web-2", Stage: gremlin.StageFailed, Error: "daemon crashed", StartTime: start.Add(6 * time.Second), EndTime: start.Add(6 * time.Second)},
+		},
+	}, &AttackRecord{
+		Command:     gremlin.AttackCommand{Command: gremlin.Command{Type: "latency"}, Target: gremlin.Target{Type: gremlin.TargetExact, Exact: []string{"db-1"}}},
+		LaunchedAt:  start.Add(2 * time.Second),
+		LaunchError: "quota exceeded",
+	})
+
+	clock.Advance(15 * time.Second)
+	j.Record("http GET https://shop/healthz", probe.Result{Latency: 900 * time.Millisecond, Details: "Latency 900ms exceeds 500ms"})
+	clock.Advance(5 * time.Second)
+	j.Annotate("Halting: <p99> over budget")
+	clock.Advance(10 * time.Second)
+	j.Record("http GET https://shop/healthz", probe.Result{Passed: true, Latency: 90 * time.Millisecond, Details: "OK"})
+	j.Conclude(false, "p99 rose to 900ms")
+	return j
+}
+
+func TestReportGolden(t *testing.T) {
+	r := testJournal().Report()
+
+	var buf bytes.Buffer
+	if err := r.Markdown(&buf); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	golden(t, "report_markdown", buf.Bytes())
+
+	buf.Reset()
+	if err := r.HTML(&buf); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	golden(t, "report_html", buf.Bytes())
+
+	buf.Reset()
+	if err := r.JSON(&buf); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	golden(t, "report_json", buf.Bytes())
+
+	var decoded Report
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(decoded.Timeline) != len(r.Timeline) || decoded.Held == nil || *decoded.Held {
+		t.Errorf("Expected the JSON report to round trip, but got %+v", decoded)
+	}
+}
+
+func TestEmptyReport(t *testing.T) {
+	var buf bytes.Buffer
+	if err := New("nothing", "").Report().Markdown(&buf); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, want := range []string{"**Outcome:** not concluded", "No attacks were launched.", "No probe samples were recorded."} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("Expected %q in the report, but got:\n%s", want, buf.String())
+		}
+	}
+}
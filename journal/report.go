@@ -0,0 +1,304 @@
+package journal
+
+import (
+	"encoding/json"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	gremlin "github.com/sonnysideup/go-gremlin"
+	"github.com/sonnysideup/go-gremlin/printer"
+)
+
+// Timeline entry kinds
+const (
+	EntryAttackLaunched = "attack_launched"
+	EntryLaunchFailed   = "launch_failed"
+	EntryAttackStarted  = "attack_started"
+	EntryHaltRequested  = "halt_requested"
+	EntryAttackEnded    = "attack_ended"
+	EntryProbeFailed    = "probe_failed"
+	EntryProbePassed    = "probe_passed"
+	EntryNote           = "note"
+)
+
+// Entry is a line of the report's timeline.
+type Entry struct {
+	Time time.Time `json:"time"`
+	Kind string    `json:"kind"`
+	Text string    `json:"text"`
+}
+
+// ProbeSummary aggregates the samples of one probe.
+type ProbeSummary struct {
+	Probe      string        `json:"probe"`
+	Samples    int           `json:"samples"`
+	Failures   int           `json:"failures"`
+	MaxLatency time.Duration `json:"max_latency"`
+}
+
+// Report is a snapshot of a journal, ready to be rendered.
+type Report struct {
+	Title      string    `json:"title"`
+	Hypothesis string    `json:"hypothesis,omitempty"`
+	Held       *bool     `json:"hypothesis_held,omitempty"`
+	Conclusion string    `json:"conclusion,omitempty"`
+	StartTime  time.Time `json:"start_time"`
+	EndTime    time.Time `json:"end_time"`
+
+	Attacks     []AttackRecord `json:"attacks"`
+	Probes      []ProbeSummary `json:"probes"`
+	Samples     []Sample       `json:"samples"`
+	Annotations []Annotation   `json:"annotations"`
+
+	// Timeline merges the attacks, probe state changes and annotations in
+	// time order. Probe samples only appear when a probe's state changes.
+	Timeline []Entry `json:"timeline"`
+}
+
+// Report takes a snapshot of the journal. Its end time is now.
+func (j *Journal) Report() *Report {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	r := &Report{
+		Title:       j.Title,
+		Hypothesis:  j.Hypothesis,
+		Held:        j.held,
+		Conclusion:  j.conclusion,
+		StartTime:   j.started,
+		EndTime:     j.now(),
+		Attacks:     make([]AttackRecord, 0, len(j.attacks)),
+		Samples:     append([]Sample{}, j.samples...),
+		Annotations: append([]Annotation{}, j.annotations...),
+	}
+	for _, a := range j.attacks {
+		r.Attacks = append(r.Attacks, *a)
+	}
+	r.Probes = summarize(r.Samples)
+	r.Timeline = timeline(r)
+	return r
+}
+
+// Duration returns how long the journal recorded for.
+func (r *Report) Duration() time.Duration {
+	return r.EndTime.Sub(r.StartTime)
+}
+
+// Outcome describes whether the hypothesis held.
+func (r *Report) Outcome() string {
+	outcome := "not concluded"
+	if r.Held != nil && *r.Held {
+		outcome = "hypothesis held"
+	} else if r.Held != nil {
+		outcome = "hypothesis did not hold"
+	}
+	if r.Conclusion != "" {
+		outcome += ": " + r.Conclusion
+	}
+	return outcome
+}
+
+func summarize(samples []Sample) []ProbeSummary {
+	var summaries []ProbeSummary
+	index := map[string]int{}
+	for _, s := range samples {
+		i, ok := index[s.Probe]
+		if !ok {
+			i = len(summaries)
+			index[s.Probe] = i
+			summaries = append(summaries, ProbeSummary{Probe: s.Probe})
+		}
+		ps := &summaries[i]
+		ps.Samples++
+		if !s.Passed {
+			ps.Failures++
+		}
+		if s.Latency > ps.MaxLatency {
+			ps.MaxLatency = s.Latency
+		}
+	}
+	if summaries == nil {
+		summaries = []ProbeSummary{}
+	}
+	return summaries
+}
+
+func timeline(r *Report) []Entry {
+	entries := []Entry{}
+	add := func(t time.Time, kind, format string, args ...interface{}) {
+		if !t.IsZero() {
+			entries = append(entries, Entry{Time: t, Kind: kind, Text: fmt.Sprintf(format, args...)})
+		}
+	}
+
+	for _, a := range r.Attacks {
+		if a.LaunchError != "" {
+			add(a.LaunchedAt, EntryLaunchFailed, "Failed to launch %s attack: %s", a.Command.Command.Type, a.LaunchError)
+			continue
+		}
+		add(a.LaunchedAt, EntryAttackLaunched, "Launched %s attack %s on %s", describe(a), a.Guid, formatTarget(a.Command.Target))
+		add(a.StartTime, EntryAttackStarted, "Attack %s started", a.Guid)
+		add(a.HaltedAt, EntryHaltRequested, "Halt requested for attack %s", a.Guid)
+		add(a.EndTime, EntryAttackEnded, "Attack %s ended in stage %s", a.Guid, a.Stage)
+	}
+
+	passing := map[string]bool{}
+	for _, s := range r.Samples {
+		if last, seen := passing[s.Probe]; seen && last == s.Passed {
+			continue
+		}
+		passing[s.Probe] = s.Passed
+		if s.Passed {
+			add(s.Time, EntryProbePassed, "Probe %s passed", s.Probe)
+		} else {
+			add(s.Time, EntryProbeFailed, "Probe %s failed: %s", s.Probe, s.Details)
+		}
+	}
+
+	for _, n := range r.Annotations {
+		add(n.Time, EntryNote, "%s", n.Text)
+	}
+
+	sort.SliceStable(entries, func(i, k int) bool { return entries[i].Time.Before(entries[k].Time) })
+	return entries
+}
+
+// describe returns the attack type and arguments.
+func describe(a AttackRecord) string {
+	return strings.Join(append([]string{a.Command.Command.Type}, a.Command.Command.Args...), " ")
+}
+
+// formatTarget renders a target in the selector syntax used by spec files.
+func formatTarget(t gremlin.Target) string {
+	return (&gremlin.Selector{Target: t}).String()
+}
+
+// JSON writes the report as indented JSON.
+func (r *Report) JSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// Markdown writes the report as Markdown.
+func (r *Report) Markdown(w io.Writer) error {
+	return markdownTemplate.Execute(w, r)
+}
+
+// HTML writes the report as a standalone HTML page.
+func (r *Report) HTML(w io.Writer) error {
+	return htmlTemplate.Execute(w, r)
+}
+
+var funcs = map[string]interface{}{
+	"time":     printer.FormatTime,
+	"target":   formatTarget,
+	"describe": describe,
+	"offset": func(r *Report, t time.Time) string {
+		return "+" + t.Sub(r.StartTime).String()
+	},
+	"ran": func(a AttackRecord) string {
+		if a.StartTime.IsZero() || a.EndTime.IsZero() {
+			return "-"
+		}
+		return a.EndTime.Sub(a.StartTime).String()
+	},
+	"dash": func(s string) string {
+		if s == "" {
+			return "-"
+		}
+		return s
+	},
+	// md escapes text for a Markdown table cell.
+	"md": func(s string) string {
+		return strings.NewReplacer("|", `\|`, "\n", " ", "\r", "").Replace(s)
+	},
+}
+
+var markdownTemplate = template.Must(template.New("markdown").Funcs(funcs).Parse(`# {{md .Title}}
+{{if .Hypothesis}}
+**Hypothesis:** {{md .Hypothesis}}
+{{end}}
+**Outcome:** {{md .Outcome}}
+
+**Started:** {{time .StartTime}} **Duration:** {{.Duration}}
+
+## Attacks
+{{if .Attacks}}
+| GUID | Attack | Target | Launched | Stage | Ran for |
+| --- | --- | --- | --- | --- | --- |
+{{range .Attacks}}| {{dash .Guid}} | {{md (describe .)}} | {{target .Command.Target}} | {{time .LaunchedAt}} | {{if .LaunchError}}launch failed: {{md .LaunchError}}{{else}}{{dash (print .Stage)}}{{end}} | {{ran .}} |
+{{end}}{{range .Attacks}}{{if .Executions}}
+### {{.Guid}}
+
+| Host | Container | Stage | Started | Ended | Error |
+| --- | --- | --- | --- | --- | --- |
+{{range .Executions}}| {{.HostID}} | {{dash .ContainerID}} | {{.Stage}} | {{time .StartTime}} | {{time .EndTime}} | {{md (dash .Error)}} |
+{{end}}{{end}}{{end}}{{else}}
+No attacks were launched.
+{{end}}
+## Probes
+{{if .Probes}}
+| Probe | Samples | Failures | Max latency |
+| --- | --- | --- | --- |
+{{range .Probes}}| {{md .Probe}} | {{.Samples}} | {{.Failures}} | {{.MaxLatency}} |
+{{end}}{{else}}
+No probe samples were recorded.
+{{end}}
+## Timeline
+
+| Time | Offset | Event |
+| --- | --- | --- |
+{{range .Timeline}}| {{time .Time}} | {{offset $ .Time}} | {{md .Text}} |
+{{end}}`))
+
+var htmlTemplate = htmltemplate.Must(htmltemplate.New("html").Funcs(funcs).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; margin-bottom: 1em; }
+th, td { border: 1px solid #ccc; padding: 0.3em 0.6em; text-align: left; }
+.failed { color: #b00; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+{{if .Hypothesis}}<p><strong>Hypothesis:</strong> {{.Hypothesis}}</p>
+{{end}}<p><strong>Outcome:</strong> {{.Outcome}}</p>
+<p><strong>Started:</strong> {{time .StartTime}} <strong>Duration:</strong> {{.Duration}}</p>
+
+<h2>Attacks</h2>
+{{if .Attacks}}<table>
+<tr><th>GUID</th><th>Attack</th><th>Target</th><th>Launched</th><th>Stage</th><th>Ran for</th></tr>
+{{range .Attacks}}<tr><td>{{dash .Guid}}</td><td>{{describe .}}</td><td>{{target .Command.Target}}</td><td>{{time .LaunchedAt}}</td><td>{{if .LaunchError}}<span class="failed">launch failed: {{.LaunchError}}</span>{{else}}{{dash (print .Stage)}}{{end}}</td><td>{{ran .}}</td></tr>
+{{end}}</table>
+{{range .Attacks}}{{if .Executions}}<h3>{{.Guid}}</h3>
+<table>
+<tr><th>Host</th><th>Container</th><th>Stage</th><th>Started</th><th>Ended</th><th>Error</th></tr>
+{{range .Executions}}<tr><td>{{.HostID}}</td><td>{{dash .ContainerID}}</td><td>{{.Stage}}</td><td>{{time .StartTime}}</td><td>{{time .EndTime}}</td><td>{{dash .Error}}</td></tr>
+{{end}}</table>
+{{end}}{{end}}{{else}}<p>No attacks were launched.</p>
+{{end}}
+<h2>Probes</h2>
+{{if .Probes}}<table>
+<tr><th>Probe</th><th>Samples</th><th>Failures</th><th>Max latency</th></tr>
+{{range .Probes}}<tr><td>{{.Probe}}</td><td>{{.Samples}}</td><td{{if .Failures}} class="failed"{{end}}>{{.Failures}}</td><td>{{.MaxLatency}}</td></tr>
+{{end}}</table>
+{{else}}<p>No probe samples were recorded.</p>
+{{end}}
+<h2>Timeline</h2>
+<table>
+<tr><th>Time</th><th>Offset</th><th>Event</th></tr>
+{{range .Timeline}}<tr><td>{{time .Time}}</td><td>{{offset $ .Time}}</td><td{{if eq .Kind "probe_failed" "launch_failed"}} class="failed"{{end}}>{{.Text}}</td></tr>
+{{end}}</table>
+</body>
+</html>
+`))
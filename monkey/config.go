@@ -0,0 +1,167 @@
+package monkey
+
+import (
+	"fmt"
+	"time"
+
+	gremlin "github.com/sonnysideup/go-gremlin"
+)
+
+// Config describes what the monkey may do and how often.
+type Config struct {
+	// Attacks are the permitted attacks, picked in proportion to their
+	// weights.
+	Attacks []Attack
+
+	// Groups are the eligible host groups. Each decision picks one group
+	// with an active host at random, then one of its active hosts.
+	Groups []Group
+
+	// MeanInterval is the mean time between attacks. The times between
+	// attacks are exponentially distributed, so attacks are unpredictable
+	// but average out to this rate.
+	MeanInterval time.Duration
+
+	// Cooldown is how long a group is left alone after it was attacked.
+	Cooldown time.Duration
+
+	// MaxConcurrent limits how many of the monkey's attacks may be active at
+	// once (default 1).
+	MaxConcurrent int
+
+	// Hours are the windows attacks may run in; an attack must start and
+	// end within one. Empty means any time.
+	Hours    []Window
+	Location *time.Location // for Hours (default time.Local)
+
+	// Seed makes the monkey's choices reproducible: the same seed, inventory
+	// and times give the same decisions. Zero picks a seed from the time;
+	// Monkey.Seed reports it.
+	Seed int64
+
+	// DryRun records what the monkey would launch without launching it.
+	DryRun bool
+}
+
+// Attack is a permitted attack. Weight is its relative chance of being
+// picked (default 1).
+type Attack struct {
+	Command gremlin.Command
+	Weight  int
+}
+
+// Group is a set of hosts selected by their tags.
+type Group struct {
+	Name string
+	Tags map[string]string
+}
+
+// Window is a time of day range, such as "09:00" to "17:00", on the given
+// days (every day if empty). A window whose end is not after its start runs
+// past midnight.
+type Window struct {
+	Days       []time.Weekday
+	Start, End string
+}
+
+// Validate reports whether the configuration can be run.
+func (c *Config) Validate() error {
+	if len(c.Attacks) == 0 {
+		return fmt.Errorf("Monkey has no attacks")
+	}
+	for i, a := range c.Attacks {
+		if a.Command.Type == "" {
+			return fmt.Errorf("Attack %d has no command type", i+1)
+		}
+		if a.Weight < 0 {
+			return fmt.Errorf("Attack %d (%s) has a negative weight", i+1, a.Command.Type)
+		}
+	}
+	if len(c.Groups) == 0 {
+		return fmt.Errorf("Monkey has no host groups")
+	}
+	for i, g := range c.Groups {
+		if g.Name == "" {
+			return fmt.Errorf("Group %d has no name", i+1)
+		}
+		if len(g.Tags) == 0 {
+			return fmt.Errorf("Group %q has no tags", g.Name)
+		}
+	}
+	if c.MeanInterval <= 0 {
+		return fmt.Errorf("Monkey needs a positive MeanInterval")
+	}
+	for _, w := range c.Hours {
+		if _, _, err := w.offsets(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (a Attack) weight() int {
+	if a.Weight == 0 {
+		return 1
+	}
+	return a.Weight
+}
+
+// offsets returns the window's start and end as offsets from midnight.
+func (w Window) offsets() (time.Duration, time.Duration, error) {
+	start, err := parseClock(w.Start)
+	if err != nil {
+		return 0, 0, err
+	}
+	end, err := parseClock(w.End)
+	if err != nil {
+		return 0, 0, err
+	}
+	if end <= start {
+		end += 24 * time.Hour
+	}
+	return start, end, nil
+}
+
+// contains reports whether [t, t+length] lies within an occurrence of the
+// window.
+func (w Window) contains(t time.Time, length time.Duration) bool {
+	start, end, err := w.offsets()
+	if err != nil {
+		return false
+	}
+
+	// an occurrence that runs past midnight started the day before
+	for _, back := range []int{0, -1} {
+		day := time.Date(t.Year(), t.Month(), t.Day()+back, 0, 0, 0, 0, t.Location())
+		if len(w.Days) > 0 && !containsWeekday(w.Days, day.Weekday()) {
+			continue
+		}
+		from, to := addClock(day, start), addClock(day, end)
+		if !t.Before(from) && !t.Add(length).After(to) {
+			return true
+		}
+	}
+	return false
+}
+
+func parseClock(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("Invalid time of day %q, expected HH:MM", s)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// addClock returns the wall-clock time offset from midnight on day.
+func addClock(day time.Time, offset time.Duration) time.Time {
+	return time.Date(day.Year(), day.Month(), day.Day(), 0, int(offset/time.Minute), 0, 0, day.Location())
+}
+
+func containsWeekday(days []time.Weekday, d time.Weekday) bool {
+	for _, day := range days {
+		if day == d {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,337 @@
+// Package monkey runs continuous, Chaos Monkey style chaos: at random times
+// during allowed hours it picks a random eligible host group and a random
+// permitted attack, and launches it against one of the group's hosts. Every
+// decision, including the decision not to attack, is recorded.
+package monkey
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+	gremlin "github.com/sonnysideup/go-gremlin"
+	"github.com/sonnysideup/go-gremlin/scheduler"
+)
+
+// API is the part of the Gremlin API the monkey uses. Launches through a
+// *gremlin.Client also pass its blackout calendar and policy.
+type API interface {
+	gremlin.AttackService
+	gremlin.ClientService
+}
+
+// preflighter is implemented by APIs, such as *gremlin.Client, that can
+// check an attack against their blackouts and policy without launching it.
+// Dry runs use it so that they only report attacks that would launch.
+type preflighter interface {
+	Preflight(ctx context.Context, ac gremlin.AttackCommand) error
+}
+
+// Action is what the monkey decided to do.
+type Action string
+
+// Decision actions
+const (
+	// ActionLaunched means an attack was launched.
+	ActionLaunched Action = "launched"
+
+	// ActionDryRun means an attack would have been launched.
+	ActionDryRun Action = "dry_run"
+
+	// ActionSkipped means the monkey chose not to attack, e.g. outside
+	// allowed hours or with every group cooling down.
+	ActionSkipped Action = "skipped"
+
+	// ActionFailed means the launch, or listing the inventory, failed. A
+	// launch refused by a blackout or policy fails too, in a dry run as well.
+	ActionFailed Action = "failed"
+)
+
+// Decision records one decision of the monkey.
+type Decision struct {
+	Time   time.Time `json:"time"`
+	Action Action    `json:"action"`
+
+	Group   string                 `json:"group,omitempty"`
+	Host    string                 `json:"host,omitempty"`
+	Command *gremlin.AttackCommand `json:"command,omitempty"`
+	Attack  string                 `json:"attack,omitempty"` // the launched attack's GUID
+
+	// Reason explains a skip or failure.
+	Reason string `json:"reason,omitempty"`
+}
+
+// Monkey makes attack decisions according to a Config. It is safe for
+// concurrent use.
+type Monkey struct {
+	api    API
+	config Config
+
+	// Clock defaults to the real clock. gremlintest.Clock satisfies it.
+	Clock scheduler.Clock
+
+	// Log, if set, receives every decision as a line of JSON.
+	Log io.Writer
+
+	mu        sync.Mutex
+	seed      int64
+	rng       *rand.Rand
+	decisions []Decision
+	lastHit   map[string]time.Time // group name to time of its last attack
+	launched  map[uuid.UUID]bool
+	simulated []time.Time // end times of dry-run attacks
+}
+
+// New returns a monkey that launches attacks through api.
+func New(api API, config Config) (*Monkey, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	if config.MaxConcurrent <= 0 {
+		config.MaxConcurrent = 1
+	}
+	if config.Location == nil {
+		config.Location = time.Local
+	}
+
+	seed := config.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	return &Monkey{
+		api:      api,
+		config:   config,
+		seed:     seed,
+		rng:      rand.New(rand.NewSource(seed)),
+		lastHit:  map[string]time.Time{},
+		launched: map[uuid.UUID]bool{},
+	}, nil
+}
+
+// Seed returns the seed of the monkey's choices, to reproduce them later.
+func (m *Monkey) Seed() int64 {
+	return m.seed
+}
+
+// Decisions returns every decision made so far, oldest first.
+func (m *Monkey) Decisions() []Decision {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Decision(nil), m.decisions...)
+}
+
+func (m *Monkey) clock() scheduler.Clock {
+	if m.Clock == nil {
+		return scheduler.SystemClock{}
+	}
+	return m.Clock
+}
+
+// Run makes a decision after every random interval until ctx is cancelled,
+// then returns ctx.Err(). Attacks already launched keep running.
+func (m *Monkey) Run(ctx context.Context) error {
+	for {
+		m.mu.Lock()
+		wait := time.Duration(m.rng.ExpFloat64() * float64(m.config.MeanInterval))
+		m.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-m.clock().After(wait):
+		}
+		m.Step(ctx)
+	}
+}
+
+// Step makes one decision now and acts on it.
+func (m *Monkey) Step(ctx context.Context) Decision {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	d := m.decide(ctx)
+	m.decisions = append(m.decisions, d)
+	if m.Log != nil {
+		data, _ := json.Marshal(d)
+		m.Log.Write(append(data, '\n'))
+	}
+	return d
+}
+
+// decide runs the checks in order, so that with the same seed the same
+// random numbers are drawn for the same situation. Callers must hold m.mu.
+func (m *Monkey) decide(ctx context.Context) Decision {
+	now := m.clock().Now().In(m.config.Location)
+	d := Decision{Time: now, Action: ActionSkipped}
+
+	active, err := m.active(ctx, now)
+	if err != nil {
+		d.Action, d.Reason = ActionFailed, fmt.Sprintf("Failed to list active attacks: %v", err)
+		return d
+	}
+	if active >= m.config.MaxConcurrent {
+		d.Reason = fmt.Sprintf("%d attacks are already active, limit is %d", active, m.config.MaxConcurrent)
+		return d
+	}
+
+	// pick the attack first, since its length decides whether it fits in
+	// the allowed hours
+	attack := m.pickAttack()
+	if !m.allowed(now, attack.Length()) {
+		d.Reason = fmt.Sprintf("A %v %s attack would not fit in the allowed hours", attack.Length(), attack.Type)
+		return d
+	}
+
+	hosts, err := m.api.ListClients(ctx)
+	if err != nil {
+		d.Action, d.Reason = ActionFailed, fmt.Sprintf("Failed to list clients: %v", err)
+		return d
+	}
+
+	candidates, cooling := m.eligible(now, hosts)
+	if len(candidates) == 0 {
+		d.Reason = "No eligible group"
+		if len(cooling) > 0 {
+			d.Reason += "; cooling down: " + strings.Join(cooling, ", ")
+		}
+		return d
+	}
+
+	group := m.config.Groups[candidates[m.rng.Intn(len(candidates))]]
+	members := groupHosts(hosts, group)
+	host := members[m.rng.Intn(len(members))]
+
+	ac := gremlin.AttackCommand{
+		Command: attack,
+		Target:  gremlin.Target{Type: gremlin.TargetExact, Exact: []string{host}},
+	}
+	d.Group, d.Host, d.Command = group.Name, host, &ac
+
+	if m.config.DryRun {
+		if p, ok := m.api.(preflighter); ok {
+			if err := p.Preflight(ctx, ac); err != nil {
+				d.Action, d.Reason = ActionFailed, err.Error()
+				return d
+			}
+		}
+		d.Action = ActionDryRun
+		m.lastHit[group.Name] = now
+		m.simulated = append(m.simulated, now.Add(attack.Length()))
+		return d
+	}
+
+	guid, err := m.api.CreateAttackContext(ctx, ac)
+	if err != nil {
+		d.Action, d.Reason = ActionFailed, err.Error()
+		return d
+	}
+	d.Action, d.Attack = ActionLaunched, guid.String()
+	m.lastHit[group.Name] = now
+	m.launched[*guid] = true
+	return d
+}
+
+// active counts the monkey's attacks that are still running, forgetting the
+// ones that ended.
+func (m *Monkey) active(ctx context.Context, now time.Time) (int, error) {
+	if m.config.DryRun {
+		running := m.simulated[:0]
+		for _, end := range m.simulated {
+			if end.After(now) {
+				running = append(running, end)
+			}
+		}
+		m.simulated = running
+		return len(running), nil
+	}
+
+	if len(m.launched) == 0 {
+		return 0, nil
+	}
+	attacks, err := m.api.ListActiveAttacks(ctx)
+	if err != nil {
+		return 0, err
+	}
+	still := map[uuid.UUID]bool{}
+	for _, a := range attacks {
+		if m.launched[a.Guid] {
+			still[a.Guid] = true
+		}
+	}
+	m.launched = still
+	return len(still), nil
+}
+
+func (m *Monkey) pickAttack() gremlin.Command {
+	total := 0
+	for _, a := range m.config.Attacks {
+		total += a.weight()
+	}
+	n := m.rng.Intn(total)
+	for _, a := range m.config.Attacks[:len(m.config.Attacks)-1] {
+		if n < a.weight() {
+			return a.Command
+		}
+		n -= a.weight()
+	}
+	return m.config.Attacks[len(m.config.Attacks)-1].Command
+}
+
+func (m *Monkey) allowed(now time.Time, length time.Duration) bool {
+	if len(m.config.Hours) == 0 {
+		return true
+	}
+	for _, w := range m.config.Hours {
+		if w.contains(now, length) {
+			return true
+		}
+	}
+	return false
+}
+
+// eligible returns the indexes of the groups that have an active host and
+// are not cooling down, and the names of those that are.
+func (m *Monkey) eligible(now time.Time, hosts []gremlin.Host) ([]int, []string) {
+	var candidates []int
+	var cooling []string
+	for i, g := range m.config.Groups {
+		if len(groupHosts(hosts, g)) == 0 {
+			continue
+		}
+		if last, ok := m.lastHit[g.Name]; ok && now.Sub(last) < m.config.Cooldown {
+			cooling = append(cooling, fmt.Sprintf("%s until %s", g.Name, last.Add(m.config.Cooldown).Format("15:04:05")))
+			continue
+		}
+		candidates = append(candidates, i)
+	}
+	return candidates, cooling
+}
+
+// groupHosts returns the identifiers of the group's active hosts, sorted so
+// that the same inventory always gives the same choices.
+func groupHosts(hosts []gremlin.Host, g Group) []string {
+	var ids []string
+	for _, h := range hosts {
+		if h.State == gremlin.HostActive && hasTags(h.Tags, g.Tags) {
+			ids = append(ids, h.Identifier)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func hasTags(have map[string]string, want map[string]string) bool {
+	for k, v := range want {
+		if got, ok := have[k]; !ok || got != v {
+			return false
+		}
+	}
+	return true
+}
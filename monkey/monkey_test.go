@@ -0,0 +1,287 @@
+package monkey
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	gremlin "github.com/sonnysideup/go-gremlin"
+	"github.com/sonnysideup/go-gremlin/gremlintest"
+)
+
+// Monday 5 January 2026, 10:00 UTC
+var monday = time.Date(2026, time.January, 5, 10, 0, 0, 0, time.UTC)
+
+func newFake() *gremlintest.Fake {
+	f := gremlintest.NewFake()
+	f.AddHost(gremlin.Host{Identifier: "web-1", State: gremlin.HostActive, Tags: map[string]string{"service": "web"}})
+	f.AddHost(gremlin.Host{Identifier: "web-2", State: gremlin.HostActive, Tags: map[string]string{"service": "web"}})
+	f.AddHost(gremlin.Host{Identifier: "db-1", State: gremlin.HostActive, Tags: map[string]string{"service": "db"}})
+	f.AddHost(gremlin.Host{Identifier: "db-2", State: gremlin.HostIdle, Tags: map[string]string{"service": "db"}})
+	return f
+}
+
+func config() Config {
+	return Config{
+		Attacks: []Attack{
+			{Command: gremlin.Command{Type: "cpu", Args: []string{"-l", "60"}}, Weight: 3},
+			{Command: gremlin.Command{Type: "latency", Args: []string{"-l", "60", "-m", "100"}}},
+		},
+		Groups: []Group{
+			{Name: "web", Tags: map[string]string{"service": "web"}},
+			{Name: "db", Tags: map[string]string{"service": "db"}},
+		},
+		MeanInterval: time.Hour,
+		Cooldown:     time.Hour,
+		Location:     time.UTC,
+		Seed:         42,
+	}
+}
+
+func newMonkey(t *testing.T, f *gremlintest.Fake, cfg Config) (*Monkey, *gremlintest.Clock) {
+	m, err := New(f, cfg)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	clock := gremlintest.NewClock(monday)
+	m.Clock = clock
+	return m, clock
+}
+
+func TestMonkeyLaunches(t *testing.T) {
+	f := newFake()
+	m, clock := newMonkey(t, f, config())
+
+	first := m.Step(context.Background())
+	if first.Action != ActionLaunched || first.Attack == "" || first.Command == nil {
+		t.Fatalf("Expected an attack to launch, but got %+v", first)
+	}
+	if !strings.HasPrefix(first.Host, first.Group+"-") || first.Host == "db-2" {
+		t.Errorf("Expected an active host of group %s, but got %s", first.Group, first.Host)
+	}
+	if want := []string{first.Host}; !reflect.DeepEqual(first.Command.Target.Exact, want) {
+		t.Errorf("Expected the attack to target %v, but got %+v", want, first.Command.Target)
+	}
+
+	d := m.Step(context.Background())
+	if d.Action != ActionSkipped || d.Reason != "1 attacks are already active, limit is 1" {
+		t.Errorf("Expected the concurrency limit to skip, but got %+v", d)
+	}
+
+	// once the first attack is over only the other group is eligible
+	f.Clock.Advance(2 * time.Minute)
+	clock.Advance(2 * time.Minute)
+	second := m.Step(context.Background())
+	if second.Action != ActionLaunched || second.Group == first.Group {
+		t.Errorf("Expected the other group to be attacked, but got %+v", second)
+	}
+
+	f.Clock.Advance(2 * time.Minute)
+	clock.Advance(2 * time.Minute)
+	d = m.Step(context.Background())
+	if d.Action != ActionSkipped || !strings.HasPrefix(d.Reason, "No eligible group; cooling down: ") {
+		t.Errorf("Expected every group to be cooling down, but got %+v", d)
+	}
+
+	f.AssertCallCount(t, "CreateAttackContext", 2)
+	if n := len(m.Decisions()); n != 4 {
+		t.Errorf("Expected 4 decisions, but got %d", n)
+	}
+}
+
+func TestMonkeyReproducible(t *testing.T) {
+	cfg := config()
+	cfg.DryRun = true
+	cfg.Cooldown = 0
+	cfg.MaxConcurrent = 100
+
+	run := func() []Decision {
+		m, clock := newMonkey(t, newFake(), cfg)
+		for i := 0; i < 20; i++ {
+			m.Step(context.Background())
+			clock.Advance(time.Minute)
+		}
+		return m.Decisions()
+	}
+
+	first, second := run(), run()
+	if !reflect.DeepEqual(first, second) {
+		t.Errorf("Expected the same seed to give the same decisions, but got:\n%+v\n%+v", first, second)
+	}
+
+	hosts, types := map[string]bool{}, map[string]bool{}
+	for _, d := range first {
+		if d.Action != ActionDryRun {
+			t.Fatalf("Expected a dry run, but got %+v", d)
+		}
+		hosts[d.Host], types[d.Command.Command.Type] = true, true
+	}
+	if len(hosts) != 3 || len(types) != 2 {
+		t.Errorf("Expected every active host and attack to be picked, but got %v and %v", hosts, types)
+	}
+}
+
+func TestMonkeyDryRun(t *testing.T) {
+	f := newFake()
+	cfg := config()
+	cfg.DryRun = true
+	m, clock := newMonkey(t, f, cfg)
+
+	if d := m.Step(context.Background()); d.Action != ActionDryRun || d.Attack != "" {
+		t.Errorf("Expected a dry run decision, but got %+v", d)
+	}
+	if d := m.Step(context.Background()); d.Action != ActionSkipped {
+		t.Errorf("Expected the simulated attack to count as active, but got %+v", d)
+	}
+	clock.Advance(time.Minute)
+	if d := m.Step(context.Background()); d.Action != ActionDryRun {
+		t.Errorf("Expected the simulated attack to have ended, but got %+v", d)
+	}
+	f.AssertNotCalled(t, "CreateAttackContext")
+}
+
+func TestMonkeyAllowedHours(t *testing.T) {
+	cfg := config()
+	cfg.Hours = []Window{{Days: []time.Weekday{time.Monday, time.Tuesday}, Start: "09:00", End: "17:00"}}
+
+	tests := []struct {
+		at     time.Time
+		action Action
+	}{
+		{monday, ActionLaunched},
+		{monday.Add(6*time.Hour + 59*time.Minute + 30*time.Second), ActionSkipped}, // 16:59:30
+		{monday.Add(-time.Hour - time.Second), ActionSkipped},                      // 08:59:59
+		{monday.AddDate(0, 0, 5), ActionSkipped},                                   // Saturday
+	}
+	for _, tt := range tests {
+		m, clock := newMonkey(t, newFake(), cfg)
+		clock.Set(tt.at)
+		d := m.Step(context.Background())
+		if d.Action != tt.action {
+			t.Errorf("%v: expected %s, but got %+v", tt.at, tt.action, d)
+		}
+		if d.Action == ActionSkipped && !strings.Contains(d.Reason, "would not fit in the allowed hours") {
+			t.Errorf("%v: unexpected reason %q", tt.at, d.Reason)
+		}
+	}
+}
+
+func TestWindowContains(t *testing.T) {
+	nights := Window{Days: []time.Weekday{time.Friday}, Start: "22:00", End: "02:00"}
+
+	tests := []struct {
+		at   time.Time
+		want bool
+	}{
+		{time.Date(2026, time.January, 9, 23, 0, 0, 0, time.UTC), true},    // Friday night
+		{time.Date(2026, time.January, 10, 1, 0, 0, 0, time.UTC), true},    // into Saturday
+		{time.Date(2026, time.January, 10, 1, 59, 30, 0, time.UTC), false}, // would overrun
+		{time.Date(2026, time.January, 10, 23, 0, 0, 0, time.UTC), false},  // Saturday night
+	}
+	for _, tt := range tests {
+		if got := nights.contains(tt.at, time.Minute); got != tt.want {
+			t.Errorf("%v: expected %v, but got %v", tt.at, tt.want, got)
+		}
+	}
+}
+
+func TestMonkeyLaunchRefused(t *testing.T) {
+	f := newFake()
+	f.InjectError("CreateAttackContext", &gremlin.BlackoutError{Blackout: gremlin.Blackout{Name: "freeze", Start: monday, End: monday.Add(time.Hour)}}, 1)
+	m, _ := newMonkey(t, f, config())
+
+	d := m.Step(context.Background())
+	if d.Action != ActionFailed || !strings.Contains(d.Reason, `blackout window "freeze"`) || d.Host == "" {
+		t.Errorf("Expected the refused launch to be recorded, but got %+v", d)
+	}
+
+	// a refused launch does not start the group's cooldown
+	if d := m.Step(context.Background()); d.Action != ActionLaunched {
+		t.Errorf("Expected the next decision to launch, but got %+v", d)
+	}
+}
+
+// preflightFake refuses every attack in its preflight checks.
+type preflightFake struct {
+	*gremlintest.Fake
+	err error
+}
+
+func (f *preflightFake) Preflight(ctx context.Context, ac gremlin.AttackCommand) error {
+	return f.err
+}
+
+func TestMonkeyDryRunPreflight(t *testing.T) {
+	f := newFake()
+	cfg := config()
+	cfg.DryRun = true
+	api := &preflightFake{Fake: f, err: &gremlin.BlackoutError{Blackout: gremlin.Blackout{Name: "freeze", Start: monday, End: monday.Add(time.Hour)}}}
+	m, err := New(api, cfg)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	m.Clock = gremlintest.NewClock(monday)
+
+	d := m.Step(context.Background())
+	if d.Action != ActionFailed || !strings.Contains(d.Reason, `blackout window "freeze"`) || d.Host == "" {
+		t.Errorf("Expected the dry run to be refused, but got %+v", d)
+	}
+
+	// a refused dry run is not simulated as active
+	api.err = nil
+	if d := m.Step(context.Background()); d.Action != ActionDryRun {
+		t.Errorf("Expected the next decision to be a dry run, but got %+v", d)
+	}
+	f.AssertNotCalled(t, "CreateAttackContext")
+}
+
+func TestMonkeyRun(t *testing.T) {
+	f := newFake()
+	m, clock := newMonkey(t, f, config())
+	var log bytes.Buffer
+	m.Log = &log
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- m.Run(ctx) }()
+
+	clock.BlockUntil(1)
+	clock.Advance(24 * time.Hour)
+	clock.BlockUntil(1)
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Errorf("Expected context.Canceled, but got %v", err)
+	}
+
+	var d Decision
+	if err := json.Unmarshal(log.Bytes(), &d); err != nil || d.Action != ActionLaunched {
+		t.Errorf("Expected the decision to be logged as JSON, but got %q", log.String())
+	}
+	if n := len(m.Decisions()); n != 1 {
+		t.Errorf("Expected one decision, but got %d", n)
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		change func(c *Config)
+		want   string
+	}{
+		{func(c *Config) { c.Attacks = nil }, "Monkey has no attacks"},
+		{func(c *Config) { c.Attacks[1].Weight = -1 }, "Attack 2 (latency) has a negative weight"},
+		{func(c *Config) { c.Groups[0].Tags = nil }, `Group "web" has no tags`},
+		{func(c *Config) { c.MeanInterval = 0 }, "Monkey needs a positive MeanInterval"},
+		{func(c *Config) { c.Hours = []Window{{Start: "9am", End: "17:00"}} }, `Invalid time of day "9am"`},
+	}
+	for _, tt := range tests {
+		cfg := config()
+		tt.change(&cfg)
+		if _, err := New(newFake(), cfg); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Expected an error containing %q, but got %v", tt.want, err)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package gremlin
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Policy decides whether an attack may be launched. It is consulted by the
+// Client before every attack launch.
+type Policy interface {
+	// Check returns nil if the attack is allowed, or a *PolicyViolation
+	// describing every rule that failed. Any other error aborts the launch.
+	Check(ctx context.Context, c *Client, ac AttackCommand) error
+}
+
+// PolicyFunc adapts an ordinary function to the Policy interface.
+type PolicyFunc func(ctx context.Context, c *Client, ac AttackCommand) error
+
+// Check calls f(ctx, c, ac).
+func (f PolicyFunc) Check(ctx context.Context, c *Client, ac AttackCommand) error {
+	return f(ctx, c, ac)
+}
+
+// WithPolicy installs a Policy that every attack launched by the Client must
+// satisfy.
+func WithPolicy(p Policy) ConfigOption {
+	return func(c *Client) error {
+		c.policy = p
+		return nil
+	}
+}
+
+// RuleFailure is a single policy rule that an attack did not satisfy.
+type RuleFailure struct {
+	Rule    string
+	Message string
+
+	// Overridable failures can be waived with WithPolicyOverride.
+	Overridable bool
+}
+
+// PolicyViolation is returned when an attack is rejected by the Client Policy.
+type PolicyViolation struct {
+	Failures []RuleFailure
+}
+
+func (v *PolicyViolation) Error() string {
+	msgs := make([]string, len(v.Failures))
+	for i, f := range v.Failures {
+		msgs[i] = fmt.Sprintf("%s: %s", f.Rule, f.Message)
+		if f.Overridable {
+			msgs[i] += " (overridable)"
+		}
+	}
+	return fmt.Sprintf("Attack rejected by policy: %s", strings.Join(msgs, "; "))
+}
+
+// Overridable reports whether every failure may be waived with an override.
+func (v *PolicyViolation) Overridable() bool {
+	for _, f := range v.Failures {
+		if !f.Overridable {
+			return false
+		}
+	}
+	return true
+}
+
+type policyOverrideKey struct{}
+
+// WithPolicyOverride returns a context that waives overridable policy failures
+// for launches made with it. The reason should explain why the override is
+// needed; it is required so overrides are never granted by accident.
+func WithPolicyOverride(ctx context.Context, reason string) context.Context {
+	return context.WithValue(ctx, policyOverrideKey{}, reason)
+}
+
+// PolicyOverrideReason returns the reason given to WithPolicyOverride, if any.
+func PolicyOverrideReason(ctx context.Context) (string, bool) {
+	reason, ok := ctx.Value(policyOverrideKey{}).(string)
+	return reason, ok && reason != ""
+}
+
+// checkPolicy applies the Client Policy to an attack, honouring overrides.
+func (c *Client) checkPolicy(ctx context.Context, ac AttackCommand) error {
+	if c.policy == nil {
+		return nil
+	}
+
+	err := c.policy.Check(ctx, c, ac)
+	if v, ok := err.(*PolicyViolation); ok && v.Overridable() {
+		if _, overridden := PolicyOverrideReason(ctx); overridden {
+			return nil
+		}
+	}
+
+	return err
+}
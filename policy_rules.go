@@ -0,0 +1,277 @@
+package gremlin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+// Names of the built-in PolicyRules, as reported in RuleFailure.Rule and
+// listed in PolicyRules.Overridable.
+const (
+	RuleMaxHosts         = "max_hosts"
+	RuleMaxPercent       = "max_percent"
+	RuleAllowedTypes     = "allowed_types"
+	RuleForbiddenTypes   = "forbidden_types"
+	RuleForbiddenTags    = "forbidden_tags"
+	RuleMaxLength        = "max_length"
+	RuleMaxActiveAttacks = "max_active_attacks"
+	RuleRequiredLabels   = "required_labels"
+)
+
+// PolicyRules is the built-in blast-radius Policy. Zero values disable a rule.
+// Rules are usually loaded from a YAML or JSON file with LoadPolicyRules:
+//
+//	max_hosts: 10
+//	max_percent: 25
+//	forbidden_types: [shutdown]
+//	forbidden_tags:
+//	  env: [prod]
+//	max_length: 15m
+//	overridable: [max_hosts, max_length]
+type PolicyRules struct {
+	// MaxHosts is the largest number of hosts (or containers) an attack may hit.
+	MaxHosts int `json:"max_hosts,omitempty" yaml:"max_hosts,omitempty"`
+
+	// MaxPercent is the largest share of the active fleet an attack may hit.
+	MaxPercent int `json:"max_percent,omitempty" yaml:"max_percent,omitempty"`
+
+	// AllowedTypes, when set, lists the only attack types that may run.
+	AllowedTypes []string `json:"allowed_types,omitempty" yaml:"allowed_types,omitempty"`
+
+	// ForbiddenTypes lists attack types that may never run.
+	ForbiddenTypes []string `json:"forbidden_types,omitempty" yaml:"forbidden_types,omitempty"`
+
+	// ForbiddenTags maps a tag key to values that no targeted host may carry.
+	// The value "*" forbids every value of the key.
+	ForbiddenTags map[string][]string `json:"forbidden_tags,omitempty" yaml:"forbidden_tags,omitempty"`
+
+	// MaxLength is the longest an attack may run.
+	MaxLength Duration `json:"max_length,omitempty" yaml:"max_length,omitempty"`
+
+	// MaxActiveAttacks limits how many attacks may be active at once,
+	// including the one being launched.
+	MaxActiveAttacks int `json:"max_active_attacks,omitempty" yaml:"max_active_attacks,omitempty"`
+
+	// RequiredLabels lists container label keys every attack must set.
+	RequiredLabels []string `json:"required_labels,omitempty" yaml:"required_labels,omitempty"`
+
+	// Overridable lists the rule names that WithPolicyOverride may waive.
+	Overridable []string `json:"overridable,omitempty" yaml:"overridable,omitempty"`
+}
+
+// LoadPolicyRules reads PolicyRules from a file. Files ending in ".json" are
+// decoded as JSON, anything else as YAML. Unknown keys are rejected so typos
+// never silently disable a rule.
+func LoadPolicyRules(path string) (*PolicyRules, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read policy file: %v", err)
+	}
+
+	rules := &PolicyRules{}
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(rules)
+	} else {
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		err = dec.Decode(rules)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse policy file %s: %v", path, err)
+	}
+
+	return rules, nil
+}
+
+// Check evaluates every rule against the attack and returns a *PolicyViolation
+// listing all failures. The client inventory is only fetched when a rule needs
+// to know which hosts the attack would hit.
+func (r *PolicyRules) Check(ctx context.Context, c *Client, ac AttackCommand) error {
+	var failures []RuleFailure
+	fail := func(rule string, format string, args ...interface{}) {
+		failures = append(failures, RuleFailure{
+			Rule:        rule,
+			Message:     fmt.Sprintf(format, args...),
+			Overridable: containsString(r.Overridable, rule),
+		})
+	}
+
+	if len(r.AllowedTypes) > 0 && !containsString(r.AllowedTypes, ac.Command.Type) {
+		fail(RuleAllowedTypes, "attack type %q is not one of %s", ac.Command.Type, strings.Join(r.AllowedTypes, ", "))
+	}
+	if containsString(r.ForbiddenTypes, ac.Command.Type) {
+		fail(RuleForbiddenTypes, "attack type %q is forbidden", ac.Command.Type)
+	}
+
+	if max := time.Duration(r.MaxLength); max > 0 && ac.Command.Length() > max {
+		fail(RuleMaxLength, "attack length %s exceeds %s", ac.Command.Length(), max)
+	}
+
+	for _, key := range r.RequiredLabels {
+		if _, ok := ac.Labels[key]; !ok {
+			fail(RuleRequiredLabels, "label %q is required", key)
+		}
+	}
+
+	if r.MaxHosts > 0 || r.MaxPercent > 0 || len(r.ForbiddenTags) > 0 {
+		hosts, err := c.ListClients(ctx)
+		if err != nil {
+			return err
+		}
+		var containers []Container
+		if len(ac.Labels) > 0 {
+			if containers, err = c.ListContainers(ctx); err != nil {
+				return err
+			}
+		}
+		res := resolveTarget(ac, hosts, containers)
+
+		if r.MaxHosts > 0 && res.SelectionSize > r.MaxHosts {
+			fail(RuleMaxHosts, "attack would hit %d targets, limit is %d", res.SelectionSize, r.MaxHosts)
+		}
+
+		if r.MaxPercent > 0 {
+			fleet := 0
+			for _, h := range hosts {
+				if h.State == HostActive {
+					fleet++
+				}
+			}
+			if len(ac.Labels) > 0 {
+				fleet = len(containers)
+			}
+			if fleet > 0 && res.SelectionSize*100 > r.MaxPercent*fleet {
+				fail(RuleMaxPercent, "attack would hit %d of %d targets, limit is %d%%", res.SelectionSize, fleet, r.MaxPercent)
+			}
+		}
+
+		for _, msg := range r.forbiddenTagMatches(ac.Target.Tags, res.Hosts) {
+			fail(RuleForbiddenTags, "%s", msg)
+		}
+	}
+
+	if r.MaxActiveAttacks > 0 {
+		active, err := c.ListActiveAttacks(ctx)
+		if err != nil {
+			return err
+		}
+		if len(active) >= r.MaxActiveAttacks {
+			fail(RuleMaxActiveAttacks, "%d attacks are already active, limit is %d", len(active), r.MaxActiveAttacks)
+		}
+	}
+
+	if len(failures) > 0 {
+		return &PolicyViolation{Failures: failures}
+	}
+	return nil
+}
+
+// forbiddenTagMatches checks the target tags and the tags of every candidate
+// host against ForbiddenTags.
+func (r *PolicyRules) forbiddenTagMatches(targetTags map[string]string, hosts []Host) []string {
+	var msgs []string
+
+	keys := make([]string, 0, len(r.ForbiddenTags))
+	for k := range r.ForbiddenTags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		forbidden := func(tags map[string]string) bool {
+			v, ok := tags[key]
+			return ok && (containsString(r.ForbiddenTags[key], "*") || containsString(r.ForbiddenTags[key], v))
+		}
+
+		if forbidden(targetTags) {
+			msgs = append(msgs, fmt.Sprintf("target tag %s=%s is forbidden", key, targetTags[key]))
+			continue
+		}
+
+		var matched []string
+		for _, h := range hosts {
+			if forbidden(h.Tags) {
+				matched = append(matched, h.Identifier)
+			}
+		}
+		if len(matched) > 0 {
+			msgs = append(msgs, fmt.Sprintf("hosts with forbidden tag %s would be targeted: %s", key, strings.Join(matched, ", ")))
+		}
+	}
+
+	return msgs
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// Duration is a time.Duration that decodes from strings such as "15m" or from
+// a number of seconds in JSON and YAML files.
+type Duration time.Duration
+
+func (d Duration) String() string {
+	return time.Duration(d).String()
+}
+
+// MarshalJSON encodes the duration in its string form.
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+// UnmarshalJSON decodes either a duration string or a number of seconds.
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var v interface{}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	return d.set(v)
+}
+
+// MarshalYAML encodes the duration in its string form.
+func (d Duration) MarshalYAML() (interface{}, error) {
+	return d.String(), nil
+}
+
+// UnmarshalYAML decodes either a duration string or a number of seconds.
+func (d *Duration) UnmarshalYAML(node *yaml.Node) error {
+	var v interface{}
+	if err := node.Decode(&v); err != nil {
+		return err
+	}
+	return d.set(v)
+}
+
+func (d *Duration) set(v interface{}) error {
+	switch value := v.(type) {
+	case string:
+		parsed, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q", value)
+		}
+		*d = Duration(parsed)
+	case int:
+		*d = Duration(time.Duration(value) * time.Second)
+	case float64:
+		*d = Duration(time.Duration(value * float64(time.Second)))
+	default:
+		return fmt.Errorf("invalid duration %v", v)
+	}
+	return nil
+}
@@ -0,0 +1,198 @@
+package gremlin
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeTempFile(t *testing.T, name string, content string) string {
+	dir, err := ioutil.TempDir("", "gremlin")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	path := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("Failed to write %s: %v", path, err)
+	}
+	return path
+}
+
+func handleInventory(mux *http.ServeMux, active int) {
+	mux.HandleFunc("/clients", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[
+			{"identifier":"web-1","state":"ACTIVE","tags":{"env":"staging"}},
+			{"identifier":"web-2","state":"ACTIVE","tags":{"env":"staging"}},
+			{"identifier":"web-3","state":"ACTIVE","tags":{"env":"prod"}},
+			{"identifier":"web-4","state":"ACTIVE","tags":{"env":"prod"}}
+		]`)
+	})
+	mux.HandleFunc("/attacks/active", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "["+strings.TrimSuffix(strings.Repeat(`{"guid":"123e4567-e89b-12d3-a456-426655440000"},`, active), ",")+"]")
+	})
+}
+
+func TestPolicyRulesCheckListsEveryFailure(t *testing.T) {
+	mux, client, teardown := setup()
+	defer teardown()
+	handleInventory(mux, 2)
+
+	rules := &PolicyRules{
+		MaxHosts:         1,
+		MaxPercent:       25,
+		ForbiddenTypes:   []string{"shutdown"},
+		ForbiddenTags:    map[string][]string{"env": {"prod"}},
+		MaxLength:        Duration(time.Minute),
+		MaxActiveAttacks: 2,
+		RequiredLabels:   []string{"owner"},
+		Overridable:      []string{RuleMaxHosts},
+	}
+	ac := AttackCommand{
+		Command: Command{Type: "shutdown", Args: []string{"--length", "300"}},
+		Target:  Target{Type: TargetRandom, Percent: 50},
+	}
+
+	err := rules.Check(context.Background(), client, ac)
+	v, ok := err.(*PolicyViolation)
+	if !ok {
+		t.Fatalf("Expected *PolicyViolation, but got %v", err)
+	}
+
+	var got []string
+	for _, f := range v.Failures {
+		got = append(got, f.Rule)
+	}
+	want := []string{
+		RuleForbiddenTypes, RuleMaxLength, RuleRequiredLabels, RuleMaxHosts,
+		RuleMaxPercent, RuleForbiddenTags, RuleMaxActiveAttacks,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected failed rules %v, but got %v", want, got)
+	}
+
+	if v.Overridable() {
+		t.Errorf("Expected violation with non-overridable failures to not be overridable")
+	}
+	if !strings.Contains(v.Error(), "max_hosts: attack would hit 2 targets, limit is 1 (overridable)") {
+		t.Errorf("Expected error to describe max_hosts failure, but got %q", v.Error())
+	}
+}
+
+func TestPolicyRulesCheckPasses(t *testing.T) {
+	mux, client, teardown := setup()
+	defer teardown()
+	handleInventory(mux, 0)
+
+	rules := &PolicyRules{
+		MaxHosts:      2,
+		AllowedTypes:  []string{"cpu"},
+		ForbiddenTags: map[string][]string{"env": {"prod"}},
+	}
+	ac := MustParseSelector("random(2) tags(env=staging)").AttackCommand(Command{Type: "cpu"})
+
+	if err := rules.Check(context.Background(), client, ac); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestCreateAttackRejectedByPolicy(t *testing.T) {
+	mux, client, teardown := setup()
+	defer teardown()
+
+	launched := false
+	mux.HandleFunc("/attacks/new", func(w http.ResponseWriter, r *http.Request) {
+		launched = true
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	WithPolicy(&PolicyRules{ForbiddenTypes: []string{"cpu"}})(client)
+
+	_, err := client.CreateAttack(buildAttack())
+
+	if _, ok := err.(*PolicyViolation); !ok {
+		t.Errorf("Expected *PolicyViolation, but got %v", err)
+	}
+	if launched {
+		t.Errorf("Expected rejected attack to not be sent to the API")
+	}
+}
+
+func TestCreateAttackWithPolicyOverride(t *testing.T) {
+	mux, client, teardown := setup()
+	defer teardown()
+
+	someGUID := "123e4567-e89b-12d3-a456-426655440000"
+	mux.HandleFunc("/attacks/new", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprint(w, someGUID)
+	})
+
+	WithPolicy(&PolicyRules{
+		MaxLength:   Duration(time.Second),
+		Overridable: []string{RuleMaxLength},
+	})(client)
+
+	if _, err := client.CreateAttack(buildAttack()); err == nil {
+		t.Fatalf("Expected attack without override to be rejected")
+	}
+
+	ctx := WithPolicyOverride(context.Background(), "approved in change 42")
+	guid, err := client.CreateAttackContext(ctx, buildAttack())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if guid.String() != someGUID {
+		t.Errorf("Expected guid to be %s, but got %s", someGUID, guid)
+	}
+}
+
+func TestLoadPolicyRules(t *testing.T) {
+	want := &PolicyRules{
+		MaxHosts:       10,
+		ForbiddenTypes: []string{"shutdown"},
+		ForbiddenTags:  map[string][]string{"env": {"prod"}},
+		MaxLength:      Duration(15 * time.Minute),
+		Overridable:    []string{"max_hosts"},
+	}
+
+	files := map[string]string{
+		"policy.yaml": "max_hosts: 10\nforbidden_types: [shutdown]\nforbidden_tags:\n  env: [prod]\nmax_length: 15m\noverridable: [max_hosts]\n",
+		"policy.json": `{"max_hosts": 10, "forbidden_types": ["shutdown"], "forbidden_tags": {"env": ["prod"]}, "max_length": 900, "overridable": ["max_hosts"]}`,
+	}
+
+	for name, content := range files {
+		path := writeTempFile(t, name, content)
+		defer os.RemoveAll(filepath.Dir(path))
+
+		got, err := LoadPolicyRules(path)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: expected %+v, but got %+v", name, want, got)
+		}
+	}
+}
+
+func TestLoadPolicyRulesWithUnknownKey(t *testing.T) {
+	path := writeTempFile(t, "policy.yml", "max_host: 10\n")
+	defer os.RemoveAll(filepath.Dir(path))
+
+	_, err := LoadPolicyRules(path)
+
+	if err == nil {
+		t.Errorf("Expected unknown key to result in error")
+	} else {
+		if got, want := err.Error(), "max_host"; !strings.Contains(got, want) {
+			t.Errorf("Expected error to match %q, but got %q", want, got)
+		}
+	}
+}
@@ -0,0 +1,213 @@
+package printer
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	gremlin "github.com/sonnysideup/go-gremlin"
+)
+
+// Column is one table column. Value receives an item of the type the column
+// was registered for, or a pointer to one.
+type Column struct {
+	Name  string
+	Value func(item interface{}) string
+
+	// Key, if set, returns the value the column sorts by, such as a
+	// time.Time or a number. Columns without one sort by Value.
+	Key func(item interface{}) interface{}
+
+	// Extra columns are only shown when selected by name.
+	Extra bool
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[reflect.Type][]Column)
+)
+
+// Register sets the table columns for the type of example. Registering a type
+// again replaces its columns.
+func Register(example interface{}, columns ...Column) {
+	typ := reflect.TypeOf(example)
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[typ] = columns
+}
+
+func columnsFor(typ reflect.Type) ([]Column, error) {
+	for typ != nil && typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	cols, ok := registry[typ]
+	if !ok {
+		return nil, fmt.Errorf("No table columns for %v, use another output format", typ)
+	}
+	return cols, nil
+}
+
+func findColumn(cols []Column, name string) (Column, error) {
+	names := make([]string, len(cols))
+	for i, c := range cols {
+		if strings.EqualFold(c.Name, name) {
+			return c, nil
+		}
+		names[i] = c.Name
+	}
+	return Column{}, fmt.Errorf("Unknown column %q, expected one of: %s", name, strings.Join(names, ", "))
+}
+
+// deref turns a pointer item into the value it points at, or the zero value
+// for a nil pointer.
+func deref(item interface{}) interface{} {
+	if rv := reflect.ValueOf(item); rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return reflect.Zero(rv.Type().Elem()).Interface()
+		}
+		return rv.Elem().Interface()
+	}
+	return item
+}
+
+// isNilPointer reports whether item is a typed nil pointer, which has no row.
+func isNilPointer(item interface{}) bool {
+	rv := reflect.ValueOf(item)
+	return rv.Kind() == reflect.Ptr && rv.IsNil()
+}
+
+// FormatTime renders a time for a table, or "-" if it is not set.
+func FormatTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Format(time.RFC3339)
+}
+
+// FormatMap renders a map as sorted, comma separated key=value pairs.
+func FormatMap(m map[string]string) string {
+	pairs := make([]string, 0, len(m))
+	for k, v := range m {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// FormatTarget renders a target in the selector syntax, e.g.
+// "random(10%) tags(service=checkout)".
+func FormatTarget(t gremlin.Target) string {
+	return (&gremlin.Selector{Target: t}).String()
+}
+
+func init() {
+	attack := func(f func(a gremlin.Attack) string) func(interface{}) string {
+		return func(item interface{}) string { return f(deref(item).(gremlin.Attack)) }
+	}
+	Register(gremlin.Attack{},
+		Column{Name: "GUID", Value: attack(func(a gremlin.Attack) string { return a.Guid.String() })},
+		Column{Name: "TYPE", Value: attack(func(a gremlin.Attack) string { return a.Command.Type })},
+		Column{Name: "STAGE", Value: attack(func(a gremlin.Attack) string { return string(a.Stage) })},
+		Column{Name: "CREATED", Value: attack(func(a gremlin.Attack) string { return FormatTime(a.CreatedAt) }),
+			Key: func(item interface{}) interface{} { return deref(item).(gremlin.Attack).CreatedAt }},
+		Column{Name: "ARGS", Extra: true, Value: attack(func(a gremlin.Attack) string { return strings.Join(a.Command.Args, " ") })},
+		Column{Name: "TARGET", Extra: true, Value: attack(func(a gremlin.Attack) string { return FormatTarget(a.Target) })},
+		Column{Name: "STARTED", Extra: true, Value: attack(func(a gremlin.Attack) string { return FormatTime(a.StartTime) }),
+			Key: func(item interface{}) interface{} { return deref(item).(gremlin.Attack).StartTime }},
+		Column{Name: "ENDED", Extra: true, Value: attack(func(a gremlin.Attack) string { return FormatTime(a.EndTime) }),
+			Key: func(item interface{}) interface{} { return deref(item).(gremlin.Attack).EndTime }},
+		Column{Name: "USER", Extra: true, Value: attack(func(a gremlin.Attack) string { return orDash(a.CreateUser) })},
+	)
+
+	execution := func(f func(e gremlin.Execution) string) func(interface{}) string {
+		return func(item interface{}) string { return f(deref(item).(gremlin.Execution)) }
+	}
+	Register(gremlin.Execution{},
+		Column{Name: "HOST", Value: execution(func(e gremlin.Execution) string { return e.HostID })},
+		Column{Name: "CONTAINER", Value: execution(func(e gremlin.Execution) string { return orDash(e.ContainerID) })},
+		Column{Name: "STAGE", Value: execution(func(e gremlin.Execution) string { return string(e.Stage) })},
+		Column{Name: "ERROR", Value: execution(func(e gremlin.Execution) string { return e.Error })},
+		Column{Name: "STARTED", Extra: true, Value: execution(func(e gremlin.Execution) string { return FormatTime(e.StartTime) }),
+			Key: func(item interface{}) interface{} { return deref(item).(gremlin.Execution).StartTime }},
+		Column{Name: "ENDED", Extra: true, Value: execution(func(e gremlin.Execution) string { return FormatTime(e.EndTime) }),
+			Key: func(item interface{}) interface{} { return deref(item).(gremlin.Execution).EndTime }},
+	)
+
+	host := func(f func(h gremlin.Host) string) func(interface{}) string {
+		return func(item interface{}) string { return f(deref(item).(gremlin.Host)) }
+	}
+	Register(gremlin.Host{},
+		Column{Name: "IDENTIFIER", Value: host(func(h gremlin.Host) string { return h.Identifier })},
+		Column{Name: "STATE", Value: host(func(h gremlin.Host) string { return h.State })},
+		Column{Name: "VERSION", Value: host(func(h gremlin.Host) string { return h.Version })},
+		Column{Name: "TAGS", Value: host(func(h gremlin.Host) string { return orDash(FormatMap(h.Tags)) })},
+		Column{Name: "LAST-ACTIVE", Extra: true, Value: host(func(h gremlin.Host) string { return FormatTime(h.LastActive) }),
+			Key: func(item interface{}) interface{} { return deref(item).(gremlin.Host).LastActive }},
+	)
+
+	container := func(f func(c gremlin.Container) string) func(interface{}) string {
+		return func(item interface{}) string { return f(deref(item).(gremlin.Container)) }
+	}
+	Register(gremlin.Container{},
+		Column{Name: "IDENTIFIER", Value: container(func(c gremlin.Container) string { return c.Identifier })},
+		Column{Name: "NAME", Value: container(func(c gremlin.Container) string { return c.Name })},
+		Column{Name: "HOST", Value: container(func(c gremlin.Container) string { return c.HostID })},
+		Column{Name: "LABELS", Value: container(func(c gremlin.Container) string { return orDash(FormatMap(c.Labels)) })},
+	)
+
+	tmpl := func(f func(t gremlin.Template) string) func(interface{}) string {
+		return func(item interface{}) string { return f(deref(item).(gremlin.Template)) }
+	}
+	Register(gremlin.Template{},
+		Column{Name: "GUID", Value: tmpl(func(t gremlin.Template) string { return t.Guid })},
+		Column{Name: "NAME", Value: tmpl(func(t gremlin.Template) string { return t.Name })},
+		Column{Name: "TYPE", Value: tmpl(func(t gremlin.Template) string { return t.Command.Type })},
+		Column{Name: "TARGET", Value: tmpl(func(t gremlin.Template) string { return FormatTarget(t.Target) })},
+		Column{Name: "DESCRIPTION", Extra: true, Value: tmpl(func(t gremlin.Template) string { return t.Description })},
+		Column{Name: "CREATED", Extra: true, Value: tmpl(func(t gremlin.Template) string { return FormatTime(t.CreatedAt) }),
+			Key: func(item interface{}) interface{} { return deref(item).(gremlin.Template).CreatedAt }},
+	)
+
+	schedule := func(f func(s gremlin.Schedule) string) func(interface{}) string {
+		return func(item interface{}) string { return f(deref(item).(gremlin.Schedule)) }
+	}
+	Register(gremlin.Schedule{},
+		Column{Name: "GUID", Value: schedule(func(s gremlin.Schedule) string { return s.Guid })},
+		Column{Name: "NAME", Value: schedule(func(s gremlin.Schedule) string { return s.Name })},
+		Column{Name: "TYPE", Value: schedule(func(s gremlin.Schedule) string { return s.Command.Type })},
+		Column{Name: "DAYS", Value: schedule(func(s gremlin.Schedule) string { return strings.Join(s.Trigger.Days, ",") })},
+		Column{Name: "WINDOW", Value: schedule(func(s gremlin.Schedule) string { return s.Trigger.Start + "-" + s.Trigger.End })},
+		Column{Name: "MAX-RUNS", Extra: true, Value: schedule(func(s gremlin.Schedule) string { return strconv.Itoa(s.Trigger.MaxRuns) }),
+			Key: func(item interface{}) interface{} { return deref(item).(gremlin.Schedule).Trigger.MaxRuns }},
+	)
+
+	scenario := func(f func(s gremlin.Scenario) string) func(interface{}) string {
+		return func(item interface{}) string { return f(deref(item).(gremlin.Scenario)) }
+	}
+	Register(gremlin.Scenario{},
+		Column{Name: "GUID", Value: scenario(func(s gremlin.Scenario) string { return s.Guid })},
+		Column{Name: "NAME", Value: scenario(func(s gremlin.Scenario) string { return s.Name })},
+		Column{Name: "STEPS", Value: scenario(func(s gremlin.Scenario) string { return strconv.Itoa(len(s.Steps)) }),
+			Key: func(item interface{}) interface{} { return len(deref(item).(gremlin.Scenario).Steps) }},
+		Column{Name: "LENGTH", Value: scenario(func(s gremlin.Scenario) string { return s.Length().String() }),
+			Key: func(item interface{}) interface{} { s := deref(item).(gremlin.Scenario); return s.Length() }},
+	)
+}
@@ -0,0 +1,49 @@
+package printer
+
+import (
+	"fmt"
+	"io"
+
+	gremlin "github.com/sonnysideup/go-gremlin"
+)
+
+// errorOutput is the structured form of an error in JSON and YAML.
+type errorOutput struct {
+	Error   string      `json:"error"`
+	Details interface{} `json:"details,omitempty"`
+}
+
+// detailer is implemented by errors from other packages that carry
+// structured details, such as *spec.ValidationError.
+type detailer interface {
+	Details() interface{}
+}
+
+// PrintError writes err in the printer's format. Tables get a plain
+// "Error: ..." line; JSON, YAML and templates get an object with an "error"
+// message and, for errors that carry them, structured "details": policy
+// failures, failed executions or spec validation errors.
+func (p *Printer) PrintError(w io.Writer, err error) error {
+	out := errorOutput{Error: err.Error()}
+	switch e := err.(type) {
+	case *gremlin.PolicyViolation:
+		out.Details = e.Failures
+	case *gremlin.AttackFailedError:
+		out.Details = e.Failures
+	case detailer:
+		out.Details = e.Details()
+	}
+
+	switch p.format {
+	case FormatJSON:
+		return writeJSON(w, out)
+	case FormatYAML:
+		return writeYAML(w, out)
+	case FormatTemplate:
+		// a template written for the result type rarely fits an error
+		return writeJSON(w, out)
+	default:
+		_, err := fmt.Fprintf(w, "Error: %v\n", err)
+		return err
+	}
+}
@@ -0,0 +1,271 @@
+// Package printer renders Gremlin API objects for people and scripts: as
+// aligned tables, JSON, YAML or the output of a Go template.
+//
+//	p, err := printer.New(printer.Options{Format: "table", SortBy: "stage"})
+//	p.Print(os.Stdout, attacks)
+//
+// Lists print one row (or template execution) per item. Tables use the columns
+// registered for the item type with Register; the package registers columns
+// for the gremlin types when it is loaded.
+package printer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+	"time"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+// Supported values for Options.Format
+const (
+	FormatTable    = "table"
+	FormatJSON     = "json"
+	FormatYAML     = "yaml"
+	FormatTemplate = "template"
+)
+
+// Options configure a Printer.
+type Options struct {
+	// Format is one of the Format constants. A template is given inline as
+	// "template={{.Guid}}". The default is a table.
+	Format string
+
+	// Columns selects and orders table columns by name, case-insensitively.
+	// By default every column not marked Extra is shown.
+	Columns []string
+
+	// SortBy orders list items by the value of a column, in any format.
+	SortBy string
+
+	// NoHeaders omits the table header row.
+	NoHeaders bool
+}
+
+// Printer writes values in a single output format.
+type Printer struct {
+	format    string
+	tmpl      *template.Template
+	columns   []string
+	sortBy    string
+	noHeaders bool
+}
+
+// New checks the options and returns a Printer for them.
+func New(opts Options) (*Printer, error) {
+	p := &Printer{
+		format:    opts.Format,
+		columns:   opts.Columns,
+		sortBy:    opts.SortBy,
+		noHeaders: opts.NoHeaders,
+	}
+
+	if strings.HasPrefix(p.format, FormatTemplate+"=") {
+		tmpl, err := template.New("output").Parse(strings.TrimPrefix(p.format, FormatTemplate+"="))
+		if err != nil {
+			return nil, fmt.Errorf("Invalid output template: %v", err)
+		}
+		p.format, p.tmpl = FormatTemplate, tmpl
+	}
+
+	switch p.format {
+	case "":
+		p.format = FormatTable
+	case FormatTable, FormatJSON, FormatYAML:
+	case FormatTemplate:
+		if p.tmpl == nil {
+			return nil, fmt.Errorf("Output format %q needs a template, e.g. template={{.Guid}}", p.format)
+		}
+	default:
+		return nil, fmt.Errorf("Unknown output format %q, expected one of: table, json, yaml, template=...", p.format)
+	}
+
+	return p, nil
+}
+
+// Format returns the output format, one of the Format constants.
+func (p *Printer) Format() string {
+	return p.format
+}
+
+// Print writes v, which may be a single value, a pointer or a slice.
+func (p *Printer) Print(w io.Writer, v interface{}) error {
+	items, typ, isList := listItems(v)
+	if isList {
+		if p.sortBy != "" {
+			if err := p.sort(typ, items); err != nil {
+				return err
+			}
+		}
+		// an empty list prints as [] rather than null
+		v = items
+	}
+
+	switch p.format {
+	case FormatJSON:
+		return writeJSON(w, v)
+	case FormatYAML:
+		return writeYAML(w, v)
+	case FormatTemplate:
+		for _, item := range items {
+			var buf bytes.Buffer
+			if err := p.tmpl.Execute(&buf, item); err != nil {
+				return fmt.Errorf("Failed to execute output template: %v", err)
+			}
+			if !bytes.HasSuffix(buf.Bytes(), []byte("\n")) {
+				buf.WriteByte('\n')
+			}
+			if _, err := w.Write(buf.Bytes()); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return p.writeTable(w, typ, items)
+	}
+}
+
+// listItems returns the elements of a slice and their type, or v itself as
+// the only item.
+func listItems(v interface{}) ([]interface{}, reflect.Type, bool) {
+	rv := reflect.ValueOf(v)
+	if !rv.IsValid() {
+		return []interface{}{v}, nil, false
+	}
+	if rv.Kind() != reflect.Slice {
+		return []interface{}{v}, rv.Type(), false
+	}
+	items := make([]interface{}, rv.Len())
+	for i := range items {
+		items[i] = rv.Index(i).Interface()
+	}
+	return items, rv.Type().Elem(), true
+}
+
+func (p *Printer) sort(typ reflect.Type, items []interface{}) error {
+	cols, err := columnsFor(typ)
+	if err != nil {
+		return err
+	}
+	col, err := findColumn(cols, p.sortBy)
+	if err != nil {
+		return err
+	}
+	key := col.Key
+	if key == nil {
+		key = func(item interface{}) interface{} { return col.Value(item) }
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		return less(key(items[i]), key(items[j]))
+	})
+	return nil
+}
+
+// less orders two sort keys of the same column: times chronologically,
+// numbers numerically and anything else by its text.
+func less(a, b interface{}) bool {
+	if ta, ok := a.(time.Time); ok {
+		return ta.Before(b.(time.Time))
+	}
+
+	va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+	switch va.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return va.Int() < vb.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return va.Uint() < vb.Uint()
+	case reflect.Float32, reflect.Float64:
+		return va.Float() < vb.Float()
+	case reflect.String:
+		return va.String() < vb.String()
+	}
+	return fmt.Sprint(a) < fmt.Sprint(b)
+}
+
+func (p *Printer) writeTable(w io.Writer, typ reflect.Type, items []interface{}) error {
+	cols, err := columnsFor(typ)
+	if err != nil {
+		return err
+	}
+	if cols, err = p.selectColumns(cols); err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	if !p.noHeaders {
+		names := make([]string, len(cols))
+		for i, c := range cols {
+			names[i] = c.Name
+		}
+		fmt.Fprintln(tw, strings.Join(names, "\t"))
+	}
+	for _, item := range items {
+		if isNilPointer(item) {
+			continue
+		}
+		values := make([]string, len(cols))
+		for i, c := range cols {
+			values[i] = c.Value(item)
+		}
+		fmt.Fprintln(tw, strings.Join(values, "\t"))
+	}
+	return tw.Flush()
+}
+
+func (p *Printer) selectColumns(cols []Column) ([]Column, error) {
+	if len(p.columns) == 0 {
+		var selected []Column
+		for _, c := range cols {
+			if !c.Extra {
+				selected = append(selected, c)
+			}
+		}
+		return selected, nil
+	}
+
+	selected := make([]Column, len(p.columns))
+	for i, name := range p.columns {
+		c, err := findColumn(cols, name)
+		if err != nil {
+			return nil, err
+		}
+		selected[i] = c
+	}
+	return selected, nil
+}
+
+func writeJSON(w io.Writer, v interface{}) error {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("Failed to marshal JSON: %v", err)
+	}
+	_, err = w.Write(append(b, '\n'))
+	return err
+}
+
+// writeYAML goes through JSON first so that YAML keys match the API's field
+// names.
+func writeYAML(w io.Writer, v interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("Failed to marshal YAML: %v", err)
+	}
+	var generic interface{}
+	if err := json.Unmarshal(b, &generic); err != nil {
+		return fmt.Errorf("Failed to marshal YAML: %v", err)
+	}
+
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(generic); err != nil {
+		return fmt.Errorf("Failed to marshal YAML: %v", err)
+	}
+	return enc.Close()
+}
@@ -0,0 +1,216 @@
+package printer
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+	gremlin "github.com/sonnysideup/go-gremlin"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+func testAttacks() []gremlin.Attack {
+	created := time.Date(2018, 3, 1, 12, 0, 0, 0, time.UTC)
+	return []gremlin.Attack{
+		{
+			Guid:      uuid.FromStringOrNil("123e4567-e89b-12d3-a456-426655440000"),
+			Stage:     gremlin.StageRunning,
+			Command:   gremlin.Command{Type: "latency", Args: []string{"-m", "100"}},
+			Target:    gremlin.Target{Type: gremlin.TargetRandom, Percent: 10},
+			CreatedAt: created,
+		},
+		{
+			Guid:      uuid.FromStringOrNil("00000000-e89b-12d3-a456-426655440001"),
+			Stage:     gremlin.StageSuccessful,
+			Command:   gremlin.Command{Type: "cpu"},
+			Target:    gremlin.Target{Type: gremlin.TargetExact, Exact: []string{"web-1"}},
+			CreatedAt: created.Add(-time.Hour),
+			StartTime: created.Add(-time.Hour),
+			EndTime:   created,
+		},
+	}
+}
+
+// golden compares got with testdata/name.golden, rewriting it with -update.
+func golden(t *testing.T, name string, got []byte) {
+	path := filepath.Join("testdata", name+".golden")
+	if *update {
+		if err := ioutil.WriteFile(path, got, 0644); err != nil {
+			t.Fatalf("Failed to update %s: %v", path, err)
+		}
+		return
+	}
+
+	want, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read %s: %v", path, err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s: output does not match the golden file\ngot:\n%s\nwant:\n%s", name, got, want)
+	}
+}
+
+func TestPrintGolden(t *testing.T) {
+	hosts := []gremlin.Host{
+		{Identifier: "web-2", State: gremlin.HostIdle, Version: "2.1.0"},
+		{Identifier: "web-1", State: gremlin.HostActive, Version: "2.1.0", Tags: map[string]string{"zone": "a", "role": "web"}},
+	}
+	templates := []gremlin.Template{
+		{Guid: "t-1", Name: "cpu spike", Command: gremlin.Command{Type: "cpu"}, Target: gremlin.Target{Type: gremlin.TargetRandom, Count: 2}},
+	}
+
+	tests := []struct {
+		name string
+		opts Options
+		v    interface{}
+	}{
+		{"attacks_table", Options{}, testAttacks()},
+		{"attacks_columns_sorted", Options{Columns: []string{"guid", "target", "ended"}, SortBy: "created"}, testAttacks()},
+		{"attacks_no_headers", Options{NoHeaders: true}, testAttacks()},
+		{"attacks_json", Options{Format: FormatJSON}, testAttacks()},
+		{"attacks_yaml", Options{Format: FormatYAML}, testAttacks()},
+		{"attacks_template", Options{Format: "template={{.Guid}} {{.Stage}}"}, testAttacks()},
+		{"attack_single", Options{}, &testAttacks()[0]},
+		{"clients_sorted", Options{SortBy: "IDENTIFIER"}, hosts},
+		{"templates_table", Options{}, templates},
+		{"empty_json", Options{Format: FormatJSON}, []gremlin.Attack{}},
+	}
+
+	for _, tt := range tests {
+		p, err := New(tt.opts)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.name, err)
+		}
+		var buf bytes.Buffer
+		if err := p.Print(&buf, tt.v); err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.name, err)
+		}
+		golden(t, tt.name, buf.Bytes())
+	}
+}
+
+func TestPrintErrorGolden(t *testing.T) {
+	violation := &gremlin.PolicyViolation{Failures: []gremlin.RuleFailure{
+		{Rule: gremlin.RuleForbiddenTypes, Message: `attack type "shutdown" is forbidden`},
+	}}
+
+	for _, format := range []string{FormatTable, FormatJSON, FormatYAML} {
+		p, _ := New(Options{Format: format})
+		var buf bytes.Buffer
+		if err := p.PrintError(&buf, violation); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		golden(t, "error_"+format, buf.Bytes())
+	}
+
+	p, _ := New(Options{Format: FormatJSON})
+	var buf bytes.Buffer
+	p.PrintError(&buf, errors.New("plain"))
+	if got, want := buf.String(), "{\n  \"error\": \"plain\"\n}\n"; got != want {
+		t.Errorf("Expected %q, but got %q", want, got)
+	}
+
+	buf.Reset()
+	p.PrintError(&buf, detailedError{})
+	if got, want := buf.String(), "{\n  \"error\": \"invalid\",\n  \"details\": [\n    \"line 1: bad\"\n  ]\n}\n"; got != want {
+		t.Errorf("Expected %q, but got %q", want, got)
+	}
+}
+
+func TestInvalidOptions(t *testing.T) {
+	tests := []Options{
+		{Format: "xml"},
+		{Format: "template"},
+		{Format: "template={{.Guid"},
+	}
+	for _, opts := range tests {
+		if _, err := New(opts); err == nil {
+			t.Errorf("Expected format %q to be rejected", opts.Format)
+		}
+	}
+
+	p, _ := New(Options{Columns: []string{"nope"}})
+	if err := p.Print(ioutil.Discard, testAttacks()); err == nil {
+		t.Errorf("Expected an unknown column to be rejected")
+	}
+
+	p, _ = New(Options{})
+	if err := p.Print(ioutil.Discard, []string{"no columns"}); err == nil {
+		t.Errorf("Expected a type without columns to be rejected")
+	}
+}
+
+func TestPrintSortsByValue(t *testing.T) {
+	scenarios := []gremlin.Scenario{
+		{Name: "ten", Steps: make([]gremlin.ScenarioStep, 10)},
+		{Name: "nine", Steps: make([]gremlin.ScenarioStep, 9)},
+		{Name: "two", Steps: make([]gremlin.ScenarioStep, 2)},
+	}
+
+	p, _ := New(Options{Columns: []string{"name"}, SortBy: "steps", NoHeaders: true})
+	var buf bytes.Buffer
+	if err := p.Print(&buf, scenarios); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got, want := buf.String(), "two\nnine\nten\n"; got != want {
+		t.Errorf("Expected scenarios sorted by step count %q, but got %q", want, got)
+	}
+}
+
+func TestPrintNil(t *testing.T) {
+	p, _ := New(Options{Format: FormatJSON})
+	var buf bytes.Buffer
+	if err := p.Print(&buf, nil); err != nil || buf.String() != "null\n" {
+		t.Errorf("Expected null, but got %q, %v", buf.String(), err)
+	}
+
+	p, _ = New(Options{})
+	if err := p.Print(ioutil.Discard, nil); err == nil {
+		t.Errorf("Expected nil to be rejected in a table")
+	}
+}
+
+func TestPrintNilPointers(t *testing.T) {
+	p, _ := New(Options{Columns: []string{"type", "target"}, SortBy: "created"})
+	attacks := testAttacks()
+	var buf bytes.Buffer
+	if err := p.Print(&buf, []*gremlin.Attack{&attacks[0], nil}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got, want := buf.String(), "TYPE     TARGET\nlatency  random(10%)\n"; got != want {
+		t.Errorf("Expected the nil attack to be skipped %q, but got %q", want, got)
+	}
+
+	buf.Reset()
+	if err := p.Print(&buf, (*gremlin.Attack)(nil)); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got, want := buf.String(), "TYPE  TARGET\n"; got != want {
+		t.Errorf("Expected only headers %q, but got %q", want, got)
+	}
+}
+
+func TestFormatTarget(t *testing.T) {
+	target := gremlin.Target{
+		Type:    gremlin.TargetRandom,
+		Count:   2,
+		Tags:    map[string]string{"service": "checkout"},
+		Exclude: []string{"web-1"},
+	}
+	if got, want := FormatTarget(target), "random(2) tags(service=checkout) exclude(web-1)"; got != want {
+		t.Errorf("Expected %q, but got %q", want, got)
+	}
+}
+
+// detailedError stands in for errors from other packages, such as
+// *spec.ValidationError, that carry structured details.
+type detailedError struct{}
+
+func (detailedError) Error() string        { return "invalid" }
+func (detailedError) Details() interface{} { return []string{"line 1: bad"} }
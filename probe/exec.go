@@ -0,0 +1,58 @@
+package probe
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// maxOutput is how much of a command's output is kept in the details.
+const maxOutput = 200
+
+// ExecProbe runs a local command and checks its exit code.
+type ExecProbe struct {
+	Command string
+	Args    []string
+	Dir     string
+	Env     []string // nil inherits the environment
+
+	// ExitCode is the exit code that counts as healthy (default 0).
+	ExitCode int
+}
+
+// Name returns the command line.
+func (p *ExecProbe) Name() string {
+	return "exec " + strings.Join(append([]string{p.Command}, p.Args...), " ")
+}
+
+// Run runs the command once. It is killed if ctx ends first.
+func (p *ExecProbe) Run(ctx context.Context) Result {
+	start := time.Now()
+
+	cmd := exec.CommandContext(ctx, p.Command, p.Args...)
+	cmd.Dir = p.Dir
+	cmd.Env = p.Env
+	out, err := cmd.CombinedOutput()
+
+	code := 0
+	if err != nil {
+		ee, ok := err.(*exec.ExitError)
+		if !ok {
+			return fail(start, "Failed to run: %v", err)
+		}
+		code = ee.ExitCode()
+	}
+
+	output := strings.TrimSpace(string(out))
+	if len(output) > maxOutput {
+		output = "..." + output[len(output)-maxOutput:]
+	}
+	if code != p.ExitCode {
+		if ctx.Err() != nil {
+			return fail(start, "Killed: %v", ctx.Err())
+		}
+		return fail(start, "Exit code %d: %s", code, output)
+	}
+	return pass(start, "exit code %d", code)
+}
@@ -0,0 +1,131 @@
+package probe
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+	gremlin "github.com/sonnysideup/go-gremlin"
+)
+
+// Guard halts an attack as soon as any of its monitors trips.
+type Guard struct {
+	API      gremlin.AttackService
+	Monitors []*Monitor
+
+	// Until is when the guard stops watching, normally the time the attack
+	// ends by itself. The zero value watches until the context ends.
+	Until time.Time
+
+	// HaltTimeout bounds the HaltAttack call (default 30s). It applies even
+	// if the context passed to Watch was cancelled.
+	HaltTimeout time.Duration
+
+	// PollInterval is how often CreateAttack checks whether the attack has
+	// ended when Until is zero (default 5s).
+	PollInterval time.Duration
+}
+
+// Watch runs the monitors concurrently until one trips, Until passes or ctx
+// ends. When a monitor trips the attack is halted and Watch returns the
+// *TripError, with HaltErr set if the halt failed. It returns nil once Until
+// passes and ctx.Err() if ctx ends first. Without monitors it returns at once.
+func (g *Guard) Watch(ctx context.Context, guid uuid.UUID) error {
+	var wctx context.Context
+	var cancel context.CancelFunc
+	if g.Until.IsZero() {
+		wctx, cancel = context.WithCancel(ctx)
+	} else {
+		wctx, cancel = context.WithDeadline(ctx, g.Until)
+	}
+	defer cancel()
+
+	trips := make(chan error, len(g.Monitors))
+	for _, m := range g.Monitors {
+		go func(m *Monitor) {
+			trips <- m.Run(wctx)
+		}(m)
+	}
+
+	var trip *TripError
+	for range g.Monitors {
+		err := <-trips
+		if te, ok := err.(*TripError); ok && trip == nil {
+			trip = te
+			cancel()
+		}
+	}
+
+	if trip != nil {
+		trip.HaltErr = g.halt(guid)
+		return trip
+	}
+	return ctx.Err()
+}
+
+func (g *Guard) halt(guid uuid.UUID) error {
+	timeout := g.HaltTimeout
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if err := g.API.HaltAttack(ctx, guid); err != nil {
+		return fmt.Errorf("Failed to halt attack %s: %v", guid, err)
+	}
+	return nil
+}
+
+// CreateAttack launches an attack and watches it in a background goroutine.
+// ctx only bounds the launch: the guard keeps watching after it is cancelled,
+// until Until passes or, if Until is zero, until the attack reaches a
+// terminal stage, however long it stays pending. Calling stop ends the watch
+// early. A *TripError from Watch is delivered on the returned channel, which
+// is closed when the guard stops.
+func (g *Guard) CreateAttack(ctx context.Context, ac gremlin.AttackCommand) (guid *uuid.UUID, errs <-chan error, stop func(), err error) {
+	guid, err = g.API.CreateAttackContext(ctx, ac)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	wctx, cancel := context.WithCancel(context.Background())
+	if g.Until.IsZero() {
+		go g.waitForEnd(wctx, cancel, *guid)
+	}
+
+	out := make(chan error, 1)
+	go func() {
+		defer close(out)
+		defer cancel()
+		if err := g.Watch(wctx, *guid); err != nil && err != wctx.Err() {
+			out <- err
+		}
+	}()
+
+	return guid, out, cancel, nil
+}
+
+// waitForEnd polls the attack and calls done once it reaches a terminal
+// stage. A failed poll is retried at the next interval.
+func (g *Guard) waitForEnd(ctx context.Context, done context.CancelFunc, guid uuid.UUID) {
+	interval := g.PollInterval
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if attack, err := g.API.GetAttack(ctx, guid); err == nil && attack.Stage.IsTerminal() {
+			done()
+			return
+		}
+	}
+}
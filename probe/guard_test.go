@@ -0,0 +1,131 @@
+package probe
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	gremlin "github.com/sonnysideup/go-gremlin"
+	"github.com/sonnysideup/go-gremlin/gremlintest"
+)
+
+func newFake() *gremlintest.Fake {
+	f := gremlintest.NewFake()
+	f.AddHost(gremlin.Host{Identifier: "web-1", State: gremlin.HostActive})
+	return f
+}
+
+func cpuAttack() gremlin.AttackCommand {
+	return gremlin.AttackCommand{
+		Command: gremlin.Command{Type: "cpu", Args: []string{"-l", "60"}},
+		Target:  gremlin.Target{Type: gremlin.TargetRandom},
+	}
+}
+
+func TestGuardHaltsOnTrip(t *testing.T) {
+	f := newFake()
+	g := &Guard{
+		API: f,
+		Monitors: []*Monitor{
+			{Probe: sequence(), Interval: time.Millisecond},
+			{Probe: sequence(true, false, false), Interval: time.Millisecond, Threshold: 2},
+		},
+	}
+
+	guid, errs, stop, err := g.CreateAttack(context.Background(), cpuAttack())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer stop()
+
+	select {
+	case err := <-errs:
+		if te, ok := err.(*TripError); !ok || te.HaltErr != nil {
+			t.Errorf("Expected a TripError without a halt error, but got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Expected the guard to trip")
+	}
+	f.AssertCalled(t, "HaltAttack", *guid)
+
+	attack, _ := f.GetAttack(context.Background(), *guid)
+	if attack.Stage != gremlin.StageUserHalted {
+		t.Errorf("Expected the attack to be halted, but got %s", attack.Stage)
+	}
+}
+
+func TestGuardReportsHaltFailure(t *testing.T) {
+	f := newFake()
+	f.InjectError("HaltAttack", errors.New("unavailable"), 1)
+	guid, _ := f.CreateAttack(cpuAttack())
+
+	g := &Guard{API: f, Monitors: []*Monitor{{Probe: sequence(false)}}}
+	err := g.Watch(context.Background(), *guid)
+
+	te, ok := err.(*TripError)
+	if !ok || te.HaltErr == nil {
+		t.Fatalf("Expected a TripError with a halt error, but got %v", err)
+	}
+	if want := `Probe "sequence" failed 1 times in a row: down; Failed to halt attack ` + guid.String() + `: unavailable`; err.Error() != want {
+		t.Errorf("Expected %q, but got %q", want, err.Error())
+	}
+}
+
+func TestGuardStopsAtUntil(t *testing.T) {
+	f := newFake()
+	guid, _ := f.CreateAttack(cpuAttack())
+
+	g := &Guard{
+		API:      f,
+		Monitors: []*Monitor{{Probe: sequence(), Interval: time.Millisecond}},
+		Until:    time.Now().Add(20 * time.Millisecond),
+	}
+	if err := g.Watch(context.Background(), *guid); err != nil {
+		t.Errorf("Expected the guard to stop cleanly, but got %v", err)
+	}
+	f.AssertNotCalled(t, "HaltAttack")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	g.Until = time.Time{}
+	if err := g.Watch(ctx, *guid); err != context.Canceled {
+		t.Errorf("Expected the cancelled context's error, but got %v", err)
+	}
+}
+
+func TestGuardWatchesUntilAttackEnds(t *testing.T) {
+	f := newFake()
+	g := &Guard{
+		API:          f,
+		Monitors:     []*Monitor{{Probe: sequence(), Interval: time.Millisecond}},
+		PollInterval: time.Millisecond,
+	}
+
+	// the launch context ending does not stop the guard
+	ctx, cancel := context.WithCancel(context.Background())
+	_, errs, stop, err := g.CreateAttack(ctx, cpuAttack())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer stop()
+	cancel()
+
+	// still pending, then running, well past the attack length in real time
+	select {
+	case err, ok := <-errs:
+		t.Fatalf("Expected the guard to keep watching, but got %v (open: %v)", err, ok)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	f.Clock.Advance(gremlintest.DefaultStartDelay + 61*time.Second)
+	select {
+	case err, ok := <-errs:
+		if ok {
+			t.Errorf("Expected the guard to stop cleanly, but got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Expected the guard to stop once the attack ended")
+	}
+	f.AssertNotCalled(t, "HaltAttack")
+}
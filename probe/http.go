@@ -0,0 +1,97 @@
+package probe
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"time"
+)
+
+// maxBody is how much of a response body the HTTP probe reads.
+const maxBody = 1 << 20
+
+// HTTPProbe requests a URL and checks the response.
+type HTTPProbe struct {
+	URL    string
+	Method string // default GET
+	Header http.Header
+
+	// Client defaults to http.DefaultClient.
+	Client *http.Client
+
+	// Status lists the accepted status codes. Empty accepts any 2xx.
+	Status []int
+
+	// Body, if set, must match the response body.
+	Body *regexp.Regexp
+
+	// MaxLatency fails the probe if the response takes longer. Zero
+	// accepts any latency.
+	MaxLatency time.Duration
+}
+
+// Name returns the method and URL.
+func (p *HTTPProbe) Name() string {
+	return "http " + p.method() + " " + p.URL
+}
+
+func (p *HTTPProbe) method() string {
+	if p.Method == "" {
+		return http.MethodGet
+	}
+	return p.Method
+}
+
+// Run makes one request.
+func (p *HTTPProbe) Run(ctx context.Context) Result {
+	start := time.Now()
+
+	req, err := http.NewRequest(p.method(), p.URL, nil)
+	if err != nil {
+		return fail(start, "Invalid request: %v", err)
+	}
+	for k, v := range p.Header {
+		req.Header[k] = v
+	}
+
+	client := p.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return fail(start, "Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxBody))
+	if err != nil {
+		return fail(start, "Failed to read response: %v", err)
+	}
+	latency := time.Since(start)
+
+	if !p.acceptStatus(resp.StatusCode) {
+		return fail(start, "Unexpected status %d", resp.StatusCode)
+	}
+	if p.Body != nil && !p.Body.Match(body) {
+		return fail(start, "Body does not match %q", p.Body)
+	}
+	if p.MaxLatency > 0 && latency > p.MaxLatency {
+		return fail(start, "Latency %v exceeds %v", latency, p.MaxLatency)
+	}
+	return Result{Passed: true, Latency: latency, Details: http.StatusText(resp.StatusCode)}
+}
+
+func (p *HTTPProbe) acceptStatus(code int) bool {
+	if len(p.Status) == 0 {
+		return code >= 200 && code < 300
+	}
+	for _, s := range p.Status {
+		if s == code {
+			return true
+		}
+	}
+	return false
+}
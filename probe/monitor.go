@@ -0,0 +1,121 @@
+package probe
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Monitor samples a probe and decides when it is unhealthy. A single failure
+// is tolerated until Threshold failures have happened in a row. A Monitor is
+// not safe for concurrent use.
+//
+// A Monitor also satisfies gameday.Probe, so an experiment can use it in
+// place of a plain check.
+type Monitor struct {
+	Probe Probe
+
+	// Interval between samples when the monitor runs by itself (default
+	// 10s).
+	Interval time.Duration
+
+	// Threshold is how many consecutive failures make the probe unhealthy
+	// (default 1).
+	Threshold int
+
+	// Timeout bounds each sample (default Interval).
+	Timeout time.Duration
+
+	failures int
+	last     Result
+}
+
+// TripError is returned when a monitor has seen Threshold failures in a row.
+type TripError struct {
+	Probe    string
+	Failures int
+	Last     Result
+
+	// HaltErr is set by a Guard if halting the attack failed.
+	HaltErr error
+}
+
+func (e *TripError) Error() string {
+	msg := fmt.Sprintf("Probe %q failed %d times in a row: %s", e.Probe, e.Failures, e.Last.Details)
+	if e.HaltErr != nil {
+		msg += "; " + e.HaltErr.Error()
+	}
+	return msg
+}
+
+// Details describes the trip without the probe's name, for callers that
+// already name the probe, e.g. "Unexpected status 503 (2 in a row)".
+func (e *TripError) Details() string {
+	return fmt.Sprintf("%s (%d in a row)", e.Last.Details, e.Failures)
+}
+
+func (m *Monitor) interval() time.Duration {
+	if m.Interval <= 0 {
+		return 10 * time.Second
+	}
+	return m.Interval
+}
+
+// Name returns the probe's name.
+func (m *Monitor) Name() string {
+	return m.Probe.Name()
+}
+
+// Last returns the result of the most recent sample.
+func (m *Monitor) Last() Result {
+	return m.last
+}
+
+// Check samples the probe once. It returns a *TripError if the probe has now
+// failed Threshold times in a row, and nil otherwise.
+func (m *Monitor) Check(ctx context.Context) error {
+	timeout := m.Timeout
+	if timeout <= 0 {
+		timeout = m.interval()
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	m.last = m.Probe.Run(ctx)
+	if m.last.Passed {
+		m.failures = 0
+		return nil
+	}
+
+	m.failures++
+	threshold := m.Threshold
+	if threshold <= 0 {
+		threshold = 1
+	}
+	if m.failures < threshold {
+		return nil
+	}
+	return &TripError{Probe: m.Name(), Failures: m.failures, Last: m.last}
+}
+
+// Run samples the probe every Interval until it trips, returning the
+// *TripError, or until ctx ends, returning ctx.Err().
+func (m *Monitor) Run(ctx context.Context) error {
+	ticker := time.NewTicker(m.interval())
+	defer ticker.Stop()
+
+	for {
+		if err := m.Check(ctx); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package probe
+
+import (
+	"context"
+	"net"
+	"strings"
+	"time"
+)
+
+// TCPProbe checks that a TCP connection can be opened.
+type TCPProbe struct {
+	Address string // host:port
+}
+
+// Name returns the address.
+func (p *TCPProbe) Name() string {
+	return "tcp " + p.Address
+}
+
+// Run opens and closes one connection.
+func (p *TCPProbe) Run(ctx context.Context) Result {
+	start := time.Now()
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", p.Address)
+	if err != nil {
+		return fail(start, "Failed to connect: %v", err)
+	}
+	conn.Close()
+	return pass(start, "connected")
+}
+
+// DNSProbe checks that a host name resolves.
+type DNSProbe struct {
+	Host string
+
+	// Resolver is the host:port of the DNS server to ask. Empty uses the
+	// system resolver.
+	Resolver string
+
+	// Expect lists addresses that must all be among the answers. Empty
+	// accepts any non-empty answer.
+	Expect []string
+}
+
+// Name returns the host name and the resolver, if one is set.
+func (p *DNSProbe) Name() string {
+	if p.Resolver != "" {
+		return "dns " + p.Host + " @" + p.Resolver
+	}
+	return "dns " + p.Host
+}
+
+func (p *DNSProbe) resolver() *net.Resolver {
+	if p.Resolver == "" {
+		return net.DefaultResolver
+	}
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, network, p.Resolver)
+		},
+	}
+}
+
+// Run resolves the host once.
+func (p *DNSProbe) Run(ctx context.Context) Result {
+	start := time.Now()
+
+	addrs, err := p.resolver().LookupHost(ctx, p.Host)
+	if err != nil {
+		return fail(start, "Failed to resolve: %v", err)
+	}
+	if len(addrs) == 0 {
+		return fail(start, "No addresses for %s", p.Host)
+	}
+
+	found := make(map[string]bool, len(addrs))
+	for _, a := range addrs {
+		found[a] = true
+	}
+	for _, want := range p.Expect {
+		if !found[want] {
+			return fail(start, "Expected %s among %s", want, strings.Join(addrs, ", "))
+		}
+	}
+	return pass(start, "%s", strings.Join(addrs, ", "))
+}
@@ -0,0 +1,67 @@
+// Package probe checks the health of the system under attack. It has HTTP,
+// TCP, DNS, exec and Prometheus probes, a Monitor that samples a probe on an
+// interval and trips after a number of consecutive failures, and a Guard that
+// halts an attack as soon as one of its monitors trips.
+package probe
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Result is the outcome of running a probe once.
+type Result struct {
+	Passed  bool
+	Latency time.Duration
+
+	// Details describes what was observed, e.g. "status 200" or why the
+	// probe failed.
+	Details string
+}
+
+// Probe checks one aspect of the system under test.
+type Probe interface {
+	Name() string
+	Run(ctx context.Context) Result
+}
+
+func pass(start time.Time, format string, args ...interface{}) Result {
+	return Result{Passed: true, Latency: time.Since(start), Details: fmt.Sprintf(format, args...)}
+}
+
+func fail(start time.Time, format string, args ...interface{}) Result {
+	return Result{Latency: time.Since(start), Details: fmt.Sprintf(format, args...)}
+}
+
+type funcProbe struct {
+	name string
+	fn   func(ctx context.Context) error
+}
+
+// Func returns a Probe that passes when fn returns nil.
+func Func(name string, fn func(ctx context.Context) error) Probe {
+	return funcProbe{name: name, fn: fn}
+}
+
+func (p funcProbe) Name() string { return p.name }
+
+func (p funcProbe) Run(ctx context.Context) Result {
+	start := time.Now()
+	if err := p.fn(ctx); err != nil {
+		return fail(start, "%v", err)
+	}
+	return pass(start, "ok")
+}
+
+type namedProbe struct {
+	Probe
+	name string
+}
+
+// Named gives a probe a name other than its default one.
+func Named(name string, p Probe) Probe {
+	return namedProbe{Probe: p, name: name}
+}
+
+func (p namedProbe) Name() string { return p.name }
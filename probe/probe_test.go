@@ -0,0 +1,224 @@
+package probe
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sonnysideup/go-gremlin/gameday"
+)
+
+var _ gameday.Probe = (*Monitor)(nil)
+
+func TestHTTPProbe(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/healthz":
+			fmt.Fprint(w, `{"status":"ok"}`)
+		case "/slow":
+			time.Sleep(50 * time.Millisecond)
+			fmt.Fprint(w, "ok")
+		default:
+			http.Error(w, "gone", http.StatusServiceUnavailable)
+		}
+	}))
+	defer ts.Close()
+
+	tests := []struct {
+		probe HTTPProbe
+		want  string // empty if the probe should pass
+	}{
+		{HTTPProbe{URL: ts.URL + "/healthz"}, ""},
+		{HTTPProbe{URL: ts.URL + "/healthz", Body: regexp.MustCompile(`"status":"ok"`)}, ""},
+		{HTTPProbe{URL: ts.URL + "/healthz", Body: regexp.MustCompile(`degraded`)}, "Body does not match"},
+		{HTTPProbe{URL: ts.URL + "/down"}, "Unexpected status 503"},
+		{HTTPProbe{URL: ts.URL + "/down", Status: []int{503}}, ""},
+		{HTTPProbe{URL: ts.URL + "/slow", MaxLatency: 10 * time.Millisecond}, "exceeds 10ms"},
+		{HTTPProbe{URL: "http://127.0.0.1:1/"}, "Request failed"},
+	}
+
+	for _, tt := range tests {
+		res := tt.probe.Run(context.Background())
+		if tt.want == "" && !res.Passed {
+			t.Errorf("%s: expected the probe to pass, but got %q", tt.probe.Name(), res.Details)
+		}
+		if tt.want != "" && (res.Passed || !strings.Contains(res.Details, tt.want)) {
+			t.Errorf("%s: expected a failure containing %q, but got %+v", tt.probe.Name(), tt.want, res)
+		}
+	}
+}
+
+func TestTCPProbe(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	addr := l.Addr().String()
+
+	p := &TCPProbe{Address: addr}
+	if res := p.Run(context.Background()); !res.Passed {
+		t.Errorf("Expected the probe to connect, but got %q", res.Details)
+	}
+
+	l.Close()
+	if res := p.Run(context.Background()); res.Passed || !strings.Contains(res.Details, "Failed to connect") {
+		t.Errorf("Expected the probe to fail after the listener closed, but got %+v", res)
+	}
+}
+
+// dnsServer answers A queries for web.test. with 10.0.0.1 and everything else
+// with no answers.
+func dnsServer(t *testing.T) (string, func()) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			conn.WriteTo(dnsAnswer(buf[:n]), addr)
+		}
+	}()
+	return conn.LocalAddr().String(), func() { conn.Close() }
+}
+
+func dnsAnswer(q []byte) []byte {
+	// the question follows the 12 byte header: a name, a type and a class
+	end := 12
+	for q[end] != 0 {
+		end += int(q[end]) + 1
+	}
+	name, qtype := q[12:end+1], binary.BigEndian.Uint16(q[end+1:])
+	question := q[12 : end+5]
+
+	resp := append([]byte{q[0], q[1], 0x81, 0x80, 0, 1, 0, 0, 0, 0, 0, 0}, question...)
+	if qtype == 1 && string(name) == "\x03web\x04test\x00" {
+		resp[7] = 1
+		resp = append(resp, 0xc0, 12, 0, 1, 0, 1, 0, 0, 0, 60, 0, 4, 10, 0, 0, 1)
+	}
+	return resp
+}
+
+func TestDNSProbe(t *testing.T) {
+	addr, stop := dnsServer(t)
+	defer stop()
+
+	tests := []struct {
+		probe DNSProbe
+		want  string
+	}{
+		{DNSProbe{Host: "web.test.", Resolver: addr}, ""},
+		{DNSProbe{Host: "web.test.", Resolver: addr, Expect: []string{"10.0.0.1"}}, ""},
+		{DNSProbe{Host: "web.test.", Resolver: addr, Expect: []string{"10.0.0.2"}}, "Expected 10.0.0.2 among 10.0.0.1"},
+		{DNSProbe{Host: "db.test.", Resolver: addr}, "Failed to resolve"},
+	}
+
+	for _, tt := range tests {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		res := tt.probe.Run(ctx)
+		cancel()
+
+		if tt.want == "" && !res.Passed {
+			t.Errorf("%s: expected the probe to pass, but got %q", tt.probe.Name(), res.Details)
+		}
+		if tt.want != "" && (res.Passed || !strings.Contains(res.Details, tt.want)) {
+			t.Errorf("%s: expected a failure containing %q, but got %+v", tt.probe.Name(), tt.want, res)
+		}
+	}
+}
+
+func TestExecProbe(t *testing.T) {
+	tests := []struct {
+		probe ExecProbe
+		want  string
+	}{
+		{ExecProbe{Command: "true"}, ""},
+		{ExecProbe{Command: "sh", Args: []string{"-c", "echo degraded; exit 2"}}, "Exit code 2: degraded"},
+		{ExecProbe{Command: "sh", Args: []string{"-c", "exit 2"}, ExitCode: 2}, ""},
+		{ExecProbe{Command: "/nonexistent/check"}, "Failed to run"},
+	}
+
+	for _, tt := range tests {
+		res := tt.probe.Run(context.Background())
+		if tt.want == "" && !res.Passed {
+			t.Errorf("%s: expected the probe to pass, but got %q", tt.probe.Name(), res.Details)
+		}
+		if tt.want != "" && (res.Passed || !strings.Contains(res.Details, tt.want)) {
+			t.Errorf("%s: expected a failure containing %q, but got %+v", tt.probe.Name(), tt.want, res)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	p := &ExecProbe{Command: "sleep", Args: []string{"5"}}
+	if res := p.Run(ctx); res.Passed || res.Latency > time.Second {
+		t.Errorf("Expected the command to be killed at the deadline, but got %+v", res)
+	}
+}
+
+// sequence is a probe that passes or fails as scripted, then passes.
+func sequence(results ...bool) Probe {
+	i := 0
+	return Func("sequence", func(context.Context) error {
+		i++
+		if i <= len(results) && !results[i-1] {
+			return errors.New("down")
+		}
+		return nil
+	})
+}
+
+func TestMonitorThreshold(t *testing.T) {
+	m := &Monitor{Probe: sequence(false, false, true, false, false, false), Threshold: 3}
+
+	var tripped []int
+	for i := 1; i <= 6; i++ {
+		if err := m.Check(context.Background()); err != nil {
+			tripped = append(tripped, i)
+		}
+	}
+	if len(tripped) != 1 || tripped[0] != 6 {
+		t.Errorf("Expected only the sixth check to trip, but got %v", tripped)
+	}
+
+	err := m.Check(context.Background())
+	if err != nil || !m.Last().Passed {
+		t.Errorf("Expected a success to reset the monitor, but got %v", err)
+	}
+}
+
+func TestMonitorRun(t *testing.T) {
+	m := &Monitor{Probe: Named("api", sequence(true, false, false)), Interval: time.Millisecond, Threshold: 2}
+
+	err := m.Run(context.Background())
+	te, ok := err.(*TripError)
+	if !ok {
+		t.Fatalf("Expected a TripError, but got %v", err)
+	}
+	if te.Failures != 2 || err.Error() != `Probe "api" failed 2 times in a row: down` {
+		t.Errorf("Unexpected trip: %v", err)
+	}
+	if got, want := te.Details(), "down (2 in a row)"; got != want {
+		t.Errorf("Expected details %q, but got %q", want, got)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	m = &Monitor{Probe: sequence(), Interval: time.Millisecond}
+	if err := m.Run(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Expected a healthy monitor to run until the deadline, but got %v", err)
+	}
+}
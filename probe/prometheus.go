@@ -0,0 +1,203 @@
+package probe
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PrometheusProbe runs an instant query against a Prometheus compatible HTTP
+// API and compares the result with a threshold. A scalar result must meet the
+// condition; for a vector result every sample must.
+type PrometheusProbe struct {
+	// URL is the base URL of the server, e.g. http://prometheus:9090. The
+	// probe queries URL/api/v1/query.
+	URL   string
+	Query string
+
+	// Condition is an operator and a number, e.g. "< 0.05" or ">= 99.9". The
+	// operators are <, <=, >, >=, == and !=.
+	Condition string
+
+	// EmptyPasses makes an empty vector pass instead of failing, for
+	// queries such as error rates that have no samples when all is well.
+	EmptyPasses bool
+
+	Header http.Header
+
+	// Client defaults to http.DefaultClient.
+	Client *http.Client
+}
+
+// Name returns the query and condition.
+func (p *PrometheusProbe) Name() string {
+	return "prometheus " + p.Query + " " + p.Condition
+}
+
+type condition struct {
+	op        string
+	threshold float64
+}
+
+// parseCondition parses an operator followed by a number.
+func parseCondition(s string) (condition, error) {
+	s = strings.TrimSpace(s)
+	for _, op := range []string{"<=", ">=", "==", "!=", "<", ">"} {
+		if strings.HasPrefix(s, op) {
+			f, err := strconv.ParseFloat(strings.TrimSpace(s[len(op):]), 64)
+			if err != nil {
+				return condition{}, fmt.Errorf("Invalid threshold in condition %q", s)
+			}
+			return condition{op: op, threshold: f}, nil
+		}
+	}
+	return condition{}, fmt.Errorf("Invalid condition %q: expected an operator and a number", s)
+}
+
+// Validate reports whether the condition can be parsed.
+func (p *PrometheusProbe) Validate() error {
+	_, err := parseCondition(p.Condition)
+	return err
+}
+
+func (c condition) holds(v float64) bool {
+	switch c.op {
+	case "<":
+		return v < c.threshold
+	case "<=":
+		return v <= c.threshold
+	case ">":
+		return v > c.threshold
+	case ">=":
+		return v >= c.threshold
+	case "==":
+		return v == c.threshold
+	default:
+		return v != c.threshold
+	}
+}
+
+// promResponse is the envelope of the Prometheus query API.
+type promResponse struct {
+	Status    string `json:"status"`
+	ErrorType string `json:"errorType"`
+	Error     string `json:"error"`
+	Data      struct {
+		ResultType string          `json:"resultType"`
+		Result     json.RawMessage `json:"result"`
+	} `json:"data"`
+}
+
+type promSample struct {
+	Metric map[string]string `json:"metric"`
+	Value  []interface{}     `json:"value"`
+}
+
+// sampleValue returns the value of a [timestamp, "value"] pair.
+func sampleValue(pair []interface{}) (float64, error) {
+	if len(pair) != 2 {
+		return 0, fmt.Errorf("Invalid sample %v", pair)
+	}
+	s, ok := pair[1].(string)
+	if !ok {
+		return 0, fmt.Errorf("Invalid sample value %v", pair[1])
+	}
+	return strconv.ParseFloat(s, 64)
+}
+
+// labels formats a metric's labels like PromQL does.
+func labels(metric map[string]string) string {
+	name := metric["__name__"]
+	var pairs []string
+	for k, v := range metric {
+		if k != "__name__" {
+			pairs = append(pairs, fmt.Sprintf("%s=%q", k, v))
+		}
+	}
+	sort.Strings(pairs)
+	return name + "{" + strings.Join(pairs, ", ") + "}"
+}
+
+// Run runs the query once.
+func (p *PrometheusProbe) Run(ctx context.Context) Result {
+	start := time.Now()
+
+	cond, err := parseCondition(p.Condition)
+	if err != nil {
+		return fail(start, "%v", err)
+	}
+
+	u := strings.TrimSuffix(p.URL, "/") + "/api/v1/query?" + url.Values{"query": {p.Query}}.Encode()
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return fail(start, "Invalid request: %v", err)
+	}
+	for k, v := range p.Header {
+		req.Header[k] = v
+	}
+
+	client := p.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return fail(start, "Query failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var pr promResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxBody)).Decode(&pr); err != nil {
+		return fail(start, "Failed to decode response (status %d): %v", resp.StatusCode, err)
+	}
+	if pr.Status != "success" {
+		return fail(start, "Query failed: %s: %s", pr.ErrorType, pr.Error)
+	}
+
+	switch pr.Data.ResultType {
+	case "scalar":
+		var pair []interface{}
+		if err := json.Unmarshal(pr.Data.Result, &pair); err != nil {
+			return fail(start, "Failed to decode scalar: %v", err)
+		}
+		v, err := sampleValue(pair)
+		if err != nil {
+			return fail(start, "%v", err)
+		}
+		if !cond.holds(v) {
+			return fail(start, "%g is not %s %g", v, cond.op, cond.threshold)
+		}
+		return pass(start, "%g", v)
+
+	case "vector":
+		var samples []promSample
+		if err := json.Unmarshal(pr.Data.Result, &samples); err != nil {
+			return fail(start, "Failed to decode vector: %v", err)
+		}
+		if len(samples) == 0 {
+			if p.EmptyPasses {
+				return pass(start, "no samples")
+			}
+			return fail(start, "Query returned no samples")
+		}
+		for _, s := range samples {
+			v, err := sampleValue(s.Value)
+			if err != nil {
+				return fail(start, "%v", err)
+			}
+			if !cond.holds(v) {
+				return fail(start, "%s = %g is not %s %g", labels(s.Metric), v, cond.op, cond.threshold)
+			}
+		}
+		return pass(start, "%d samples", len(samples))
+	}
+
+	return fail(start, "Unsupported result type %q", pr.Data.ResultType)
+}
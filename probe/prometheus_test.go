@@ -0,0 +1,88 @@
+package probe
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// promServer serves canned query API responses keyed by query.
+func promServer(responses map[string]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/query" {
+			http.NotFound(w, r)
+			return
+		}
+		resp, ok := responses[r.URL.Query().Get("query")]
+		if !ok {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"status":"error","errorType":"bad_data","error":"parse error"}`)
+			return
+		}
+		fmt.Fprint(w, resp)
+	}))
+}
+
+func TestPrometheusProbe(t *testing.T) {
+	ts := promServer(map[string]string{
+		"scalar(up)": `{"status":"success","data":{"resultType":"scalar","result":[1700000000.1,"1"]}}`,
+		"error_rate": `{"status":"success","data":{"resultType":"vector","result":[
+			{"metric":{"job":"api","instance":"web-1"},"value":[1700000000,"0.01"]},
+			{"metric":{"job":"api","instance":"web-2"},"value":[1700000000,"0.2"]}]}}`,
+		"none":   `{"status":"success","data":{"resultType":"vector","result":[]}}`,
+		"matrix": `{"status":"success","data":{"resultType":"matrix","result":[]}}`,
+	})
+	defer ts.Close()
+
+	tests := []struct {
+		probe PrometheusProbe
+		want  string
+	}{
+		{PrometheusProbe{Query: "scalar(up)", Condition: "== 1"}, ""},
+		{PrometheusProbe{Query: "scalar(up)", Condition: "< 1"}, "1 is not < 1"},
+		{PrometheusProbe{Query: "error_rate", Condition: "< 0.5"}, ""},
+		{PrometheusProbe{Query: "error_rate", Condition: "<0.05"}, `{instance="web-2", job="api"} = 0.2 is not < 0.05`},
+		{PrometheusProbe{Query: "none", Condition: "< 0.05"}, "no samples"},
+		{PrometheusProbe{Query: "none", Condition: "< 0.05", EmptyPasses: true}, ""},
+		{PrometheusProbe{Query: "matrix", Condition: "< 0.05"}, `Unsupported result type "matrix"`},
+		{PrometheusProbe{Query: "rate(", Condition: "< 0.05"}, "Query failed: bad_data: parse error"},
+		{PrometheusProbe{Query: "scalar(up)", Condition: "about 1"}, `Invalid condition "about 1"`},
+		{PrometheusProbe{Query: "scalar(up)", Condition: ">= lots"}, "Invalid threshold"},
+	}
+
+	for _, tt := range tests {
+		tt.probe.URL = ts.URL + "/"
+		res := tt.probe.Run(context.Background())
+		if tt.want == "" && !res.Passed {
+			t.Errorf("%s: expected the probe to pass, but got %q", tt.probe.Name(), res.Details)
+		}
+		if tt.want != "" && (res.Passed || !strings.Contains(res.Details, tt.want)) {
+			t.Errorf("%s: expected a failure containing %q, but got %+v", tt.probe.Name(), tt.want, res)
+		}
+	}
+}
+
+func TestPrometheusProbeHaltsAttack(t *testing.T) {
+	ts := promServer(map[string]string{
+		"error_rate": `{"status":"success","data":{"resultType":"vector","result":[{"metric":{},"value":[1700000000,"0.3"]}]}}`,
+	})
+	defer ts.Close()
+
+	f := newFake()
+	guid, _ := f.CreateAttack(cpuAttack())
+	g := &Guard{API: f, Monitors: []*Monitor{{
+		Probe:     &PrometheusProbe{URL: ts.URL, Query: "error_rate", Condition: "< 0.05"},
+		Interval:  time.Millisecond,
+		Threshold: 2,
+	}}}
+
+	err := g.Watch(context.Background(), *guid)
+	if te, ok := err.(*TripError); !ok || te.Failures != 2 {
+		t.Fatalf("Expected the guard to trip after two failures, but got %v", err)
+	}
+	f.AssertCalled(t, "HaltAttack", *guid)
+}
@@ -0,0 +1,74 @@
+package gremlin
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// rateLimiter is a token bucket shared by every request a Client sends.
+type rateLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration // time to earn one token
+	burst    int
+	tokens   float64
+	last     time.Time
+}
+
+// WithRateLimit caps the Client at perSecond API requests, allowing bursts of
+// up to burst requests. Requests over the limit wait for their turn, or fail
+// once their context ends.
+func WithRateLimit(perSecond float64, burst int) ConfigOption {
+	return func(c *Client) error {
+		if perSecond <= 0 {
+			return fmt.Errorf("Invalid rate limit %v: must be positive", perSecond)
+		}
+		if burst < 1 {
+			burst = 1
+		}
+		c.limiter = &rateLimiter{
+			interval: time.Duration(float64(time.Second) / perSecond),
+			burst:    burst,
+			tokens:   float64(burst),
+		}
+		return nil
+	}
+}
+
+// wait blocks until a request may be sent.
+func (l *rateLimiter) wait(ctx context.Context) error {
+	l.mu.Lock()
+	now := time.Now()
+	if !l.last.IsZero() {
+		l.tokens += float64(now.Sub(l.last)) / float64(l.interval)
+		if l.tokens > float64(l.burst) {
+			l.tokens = float64(l.burst)
+		}
+	}
+	l.last = now
+
+	// take the token now, even if it is still owed, so that waiters queue up
+	// behind each other instead of all waking at once
+	l.tokens--
+	delay := time.Duration(-l.tokens * float64(l.interval))
+	l.mu.Unlock()
+
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		// give the token back for the next caller
+		l.mu.Lock()
+		l.tokens++
+		l.mu.Unlock()
+		return fmt.Errorf("Request failed: %v", ctx.Err())
+	}
+}
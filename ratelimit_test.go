@@ -0,0 +1,48 @@
+package gremlin
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRateLimit(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("[]"))
+	}))
+	defer server.Close()
+
+	client := NewClient("Test Org", "user@domain.com", "secret", WithURL(server.URL), WithRateLimit(50, 2))
+	client.Token = &AccessToken{Header: "Bearer fake-token"}
+
+	// two requests use the burst, the next three wait 20ms each
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		if _, err := client.ListAttacks(context.Background()); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 55*time.Millisecond {
+		t.Errorf("Expected the requests to be spread over 60ms, but they took %v", elapsed)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	client.limiter.tokens = -10
+	_, err := client.ListAttacks(ctx)
+	if err == nil || !strings.Contains(err.Error(), "context deadline exceeded") {
+		t.Errorf("Expected a waiting request to fail with its context, but got %v", err)
+	}
+}
+
+func TestWithRateLimitInvalid(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil || !strings.Contains(r.(error).Error(), "Invalid rate limit") {
+			t.Errorf("Expected NewClient to panic on an invalid rate limit, but got %v", r)
+		}
+	}()
+	NewClient("Test Org", "user@domain.com", "secret", WithRateLimit(0, 1))
+}
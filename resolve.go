@@ -0,0 +1,150 @@
+package gremlin
+
+import (
+	"context"
+	"fmt"
+)
+
+// TargetResolution describes what an AttackCommand would hit if it were
+// launched now.
+type TargetResolution struct {
+	// Hosts are the candidate hosts for the attack. For Random targets the
+	// attack will hit SelectionSize of these, chosen by Gremlin.
+	Hosts []Host
+
+	// Containers are the candidate containers when the attack has Labels.
+	Containers []Container
+
+	// SelectionSize is the number of hosts, or containers when Labels are
+	// set, that the attack will actually hit.
+	SelectionSize int
+
+	// Warnings explain surprises such as Exact hosts that are idle or unknown.
+	Warnings []string
+}
+
+// ResolveTarget performs a dry run of the target selection for an attack
+// against the current client inventory. Nothing is launched.
+func (c *Client) ResolveTarget(ctx context.Context, ac AttackCommand) (*TargetResolution, error) {
+	hosts, err := c.ListClients(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var containers []Container
+	if len(ac.Labels) > 0 {
+		if containers, err = c.ListContainers(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	return resolveTarget(ac, hosts, containers), nil
+}
+
+// resolveTarget applies the Exact/Random/Tags/Labels semantics to an inventory.
+func resolveTarget(ac AttackCommand, hosts []Host, containers []Container) *TargetResolution {
+	res := &TargetResolution{}
+
+	byID := make(map[string]Host, len(hosts))
+	for _, h := range hosts {
+		byID[h.Identifier] = h
+	}
+
+	if ac.Target.Type == TargetExact {
+		for _, id := range ac.Target.Exact {
+			h, ok := byID[id]
+			switch {
+			case !ok:
+				res.Warnings = append(res.Warnings, fmt.Sprintf("Exact host %q is not a known client", id))
+			case h.State != HostActive:
+				res.Warnings = append(res.Warnings, fmt.Sprintf("Exact host %q is %s", id, h.State))
+			default:
+				res.Hosts = append(res.Hosts, h)
+			}
+		}
+	} else {
+		excluded := make(map[string]bool, len(ac.Target.Exclude))
+		for _, id := range ac.Target.Exclude {
+			excluded[id] = true
+			if _, ok := byID[id]; !ok {
+				res.Warnings = append(res.Warnings, fmt.Sprintf("Excluded host %q is not a known client", id))
+			}
+		}
+
+		for _, h := range hosts {
+			if h.State == HostActive && !excluded[h.Identifier] && matchesAll(h.Tags, ac.Target.Tags) {
+				res.Hosts = append(res.Hosts, h)
+			}
+		}
+	}
+
+	candidates := len(res.Hosts)
+
+	// labels narrow the attack down to containers on the candidate hosts
+	if len(ac.Labels) > 0 {
+		onHost := make(map[string]bool)
+		for _, ctr := range containers {
+			if isCandidate(res.Hosts, ctr.HostID) && matchesAll(ctr.Labels, ac.Labels) {
+				res.Containers = append(res.Containers, ctr)
+				onHost[ctr.HostID] = true
+			}
+		}
+
+		var withContainers []Host
+		for _, h := range res.Hosts {
+			if onHost[h.Identifier] {
+				withContainers = append(withContainers, h)
+			}
+		}
+		res.Hosts = withContainers
+		candidates = len(res.Containers)
+	}
+
+	if candidates == 0 {
+		res.Warnings = append(res.Warnings, "No active hosts or containers match the target")
+	}
+
+	res.SelectionSize = selectionSize(ac.Target, candidates)
+
+	return res
+}
+
+// selectionSize returns how many of n candidates a target will hit. Random
+// targets without a count or percentage hit a single candidate.
+func selectionSize(t Target, n int) int {
+	if t.Type == TargetExact {
+		return n
+	}
+
+	size := 1
+	switch {
+	case t.Count > 0:
+		size = t.Count
+	case t.Percent > 0:
+		size = (n*t.Percent + 99) / 100
+	}
+
+	if size > n {
+		size = n
+	}
+	return size
+}
+
+// matchesAll reports whether every key/value in want is present in have.
+func matchesAll(have map[string]string, want map[string]string) bool {
+	for k, v := range want {
+		if got, ok := have[k]; !ok || got != v {
+			return false
+		}
+	}
+	return true
+}
+
+func isCandidate(hosts []Host, id string) bool {
+	for _, h := range hosts {
+		if h.Identifier == id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,108 @@
+package gremlin
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var testInventory = []Host{
+	{Identifier: "web-1", State: HostActive, Tags: map[string]string{"zone": "a", "service": "web"}},
+	{Identifier: "web-2", State: HostActive, Tags: map[string]string{"zone": "b", "service": "web"}},
+	{Identifier: "web-3", State: HostActive, Tags: map[string]string{"zone": "a", "service": "web"}},
+	{Identifier: "db-1", State: HostIdle, Tags: map[string]string{"zone": "a", "service": "db"}},
+}
+
+var testContainers = []Container{
+	{Identifier: "c1", Name: "api", HostID: "web-1", Labels: map[string]string{"app": "api"}},
+	{Identifier: "c2", Name: "api", HostID: "web-2", Labels: map[string]string{"app": "api"}},
+	{Identifier: "c3", Name: "worker", HostID: "web-3", Labels: map[string]string{"app": "worker"}},
+}
+
+func hostIDs(hosts []Host) []string {
+	var ids []string
+	for _, h := range hosts {
+		ids = append(ids, h.Identifier)
+	}
+	return ids
+}
+
+func TestResolveTargetSemantics(t *testing.T) {
+	tests := []struct {
+		selector string
+		hosts    []string
+		size     int
+		warnings int
+	}{
+		{"random", []string{"web-1", "web-2", "web-3"}, 1, 0},
+		{"random(2) tags(service=web)", []string{"web-1", "web-2", "web-3"}, 2, 0},
+		{"random(50%) tags(zone=a)", []string{"web-1", "web-3"}, 1, 0},
+		{"random(10) tags(zone=a) exclude(web-3)", []string{"web-1"}, 1, 0},
+		{"exclude(web-9)", []string{"web-1", "web-2", "web-3"}, 1, 1},
+		{"exact(web-2, db-1, nope)", []string{"web-2"}, 1, 2},
+		{"random(100%) labels(app=api)", []string{"web-1", "web-2"}, 2, 0},
+		{"tags(zone=c)", nil, 0, 1},
+	}
+
+	for _, tt := range tests {
+		ac := MustParseSelector(tt.selector).AttackCommand(Command{Type: "cpu"})
+		res := resolveTarget(ac, testInventory, testContainers)
+
+		if got := hostIDs(res.Hosts); !reflect.DeepEqual(got, tt.hosts) {
+			t.Errorf("%s: hosts = %v, want %v", tt.selector, got, tt.hosts)
+		}
+		if res.SelectionSize != tt.size {
+			t.Errorf("%s: selection size = %d, want %d", tt.selector, res.SelectionSize, tt.size)
+		}
+		if len(res.Warnings) != tt.warnings {
+			t.Errorf("%s: warnings = %q, want %d of them", tt.selector, res.Warnings, tt.warnings)
+		}
+	}
+}
+
+func TestResolveTargetSuccess(t *testing.T) {
+	mux, client, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/clients", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"identifier":"web-1","state":"ACTIVE"},{"identifier":"web-2","state":"IDLE"}]`)
+	})
+	mux.HandleFunc("/containers", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"identifier":"c1","client_id":"web-1","labels":{"app":"api"}}]`)
+	})
+
+	ac := MustParseSelector("exact(web-1, web-2) labels(app=api)").AttackCommand(Command{Type: "cpu"})
+	res, err := client.ResolveTarget(context.Background(), ac)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if got, want := len(res.Containers), 1; got != want {
+		t.Errorf("Expected %d container, but got %d", want, got)
+	}
+	if got, want := strings.Join(res.Warnings, "\n"), `Exact host "web-2" is IDLE`; got != want {
+		t.Errorf("Expected warnings to be %q, but got %q", want, got)
+	}
+}
+
+func TestResolveTargetWithServiceUnavailable(t *testing.T) {
+	mux, client, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/clients", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+
+	_, err := client.ResolveTarget(context.Background(), buildAttack())
+
+	if err == nil {
+		t.Errorf("Expected service unavailable to result in error")
+	} else {
+		if got, want := err.Error(), "status: 503"; !strings.Contains(got, want) {
+			t.Errorf("Expected error to match %q, but got %q", want, got)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package gremlin
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// ListScenarios returns the scenarios saved in the organization.
+func (c *Client) ListScenarios(ctx context.Context) ([]Scenario, error) {
+	var scenarios []Scenario
+	if err := c.getJSON(ctx, "scenarios", &scenarios); err != nil {
+		return nil, err
+	}
+	return scenarios, nil
+}
+
+// GetScenario fetches a single scenario.
+func (c *Client) GetScenario(ctx context.Context, guid string) (*Scenario, error) {
+	scenario := &Scenario{}
+	if err := c.getJSON(ctx, "scenarios/"+guid, scenario); err != nil {
+		return nil, err
+	}
+	return scenario, nil
+}
+
+// CreateScenario saves a new scenario and returns it as stored by Gremlin,
+// including its GUID.
+func (c *Client) CreateScenario(ctx context.Context, s Scenario) (*Scenario, error) {
+	created := &Scenario{}
+	if err := c.sendJSON(ctx, "POST", "scenarios", s, http.StatusCreated, created); err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+// RunScenario starts a run of a saved scenario. Like CreateAttackContext, every
+// step must satisfy the client Policy, and the whole run, including delays,
+// must fit outside the blackout calendar.
+func (c *Client) RunScenario(ctx context.Context, guid string) (*ScenarioRun, error) {
+	scenario, err := c.GetScenario(ctx, guid)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.checkBlackouts(scenario.Length()); err != nil {
+		return nil, err
+	}
+	for _, step := range scenario.Steps {
+		if err := c.checkPolicy(ctx, step.AttackCommand()); err != nil {
+			return nil, err
+		}
+	}
+
+	run := &ScenarioRun{}
+	if err := c.sendJSON(ctx, "POST", "scenarios/"+guid+"/runs", struct{}{}, http.StatusCreated, run); err != nil {
+		return nil, err
+	}
+	return run, nil
+}
+
+// Length returns how long a run of the scenario takes, including delays.
+func (s *Scenario) Length() time.Duration {
+	var total time.Duration
+	for _, step := range s.Steps {
+		total += time.Duration(step.Delay)*time.Second + step.Command.Length()
+	}
+	return total
+}
+
+// AttackCommand returns the attack the step launches.
+func (s ScenarioStep) AttackCommand() AttackCommand {
+	return AttackCommand{Command: s.Command, Target: s.Target, Labels: s.Labels}
+}
@@ -0,0 +1,66 @@
+package gremlin
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+const scenarioJSON = `{"guid":"sc-1","name":"failover","steps":[
+	{"command":{"type":"shutdown"},"target":{"type":"Random"}},
+	{"command":{"type":"cpu","args":["--length","120"]},"target":{"type":"Random"},"delay":300}]}`
+
+func TestRunScenarioSuccess(t *testing.T) {
+	mux, client, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/scenarios/sc-1", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, scenarioJSON)
+	})
+	mux.HandleFunc("/scenarios/sc-1/runs", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprint(w, `{"guid":"run-1","scenario_id":"sc-1","stage":"Pending"}`)
+	})
+
+	run, err := client.RunScenario(context.Background(), "sc-1")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got, want := run.Guid, "run-1"; got != want {
+		t.Errorf("Expected run guid %q, but got %q", want, got)
+	}
+}
+
+func TestRunScenarioPreflight(t *testing.T) {
+	mux, client, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/scenarios/sc-1", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, scenarioJSON)
+	})
+	mux.HandleFunc("/scenarios/sc-1/runs", func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("Expected the run to be blocked")
+	})
+
+	WithPolicy(&PolicyRules{ForbiddenTypes: []string{"cpu"}})(client)
+	if _, err := client.RunScenario(context.Background(), "sc-1"); err == nil {
+		t.Errorf("Expected the second step to violate the policy")
+	} else if _, ok := err.(*PolicyViolation); !ok {
+		t.Errorf("Expected a *PolicyViolation, but got %T: %v", err, err)
+	}
+
+	// the whole run lasts 60s + 300s + 120s, so a window starting in five
+	// minutes still blocks it
+	cal := NewCalendar(nil)
+	cal.AddRange("freeze", time.Now().Add(5*time.Minute), time.Now().Add(time.Hour))
+	client.policy = nil
+	WithBlackouts(cal)(client)
+	if _, err := client.RunScenario(context.Background(), "sc-1"); err == nil {
+		t.Errorf("Expected the run to overlap the blackout")
+	} else if _, ok := err.(*BlackoutError); !ok {
+		t.Errorf("Expected a *BlackoutError, but got %T: %v", err, err)
+	}
+}
@@ -0,0 +1,77 @@
+package scheduler
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestScheduleNext(t *testing.T) {
+	// Monday 5 January 2026
+	from := time.Date(2026, time.January, 5, 9, 59, 30, 0, time.UTC)
+
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{"* * * * *", "2026-01-05T10:00:00Z"},
+		{"*/15 * * * *", "2026-01-05T10:00:00Z"},
+		{"5/15 * * * *", "2026-01-05T10:05:00Z"},
+		{"30 9 * * *", "2026-01-06T09:30:00Z"},
+		{"0 10-12 * * mon-fri", "2026-01-05T10:00:00Z"},
+		{"0 9 * * sat,SUN", "2026-01-10T09:00:00Z"},
+		{"0 9 * * 7", "2026-01-11T09:00:00Z"},
+		{"0 0 1 * *", "2026-02-01T00:00:00Z"},
+		{"0 0 31 * *", "2026-01-31T00:00:00Z"},
+		{"0 0 29 feb *", "2028-02-29T00:00:00Z"},
+		{"0 0 13 * fri", "2026-01-09T00:00:00Z"}, // either field matches
+		{"0 0 * dec *", "2026-12-01T00:00:00Z"},
+		{"@hourly", "2026-01-05T10:00:00Z"},
+		{"@weekly", "2026-01-11T00:00:00Z"},
+		{"@yearly", "2027-01-01T00:00:00Z"},
+		{"0 0 30 2 *", "0001-01-01T00:00:00Z"},
+	}
+
+	for _, tt := range tests {
+		s, err := Parse(tt.expr)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.expr, err)
+			continue
+		}
+		if got := s.Next(from).Format(time.RFC3339); got != tt.want {
+			t.Errorf("%s: expected %s, but got %s", tt.expr, tt.want, got)
+		}
+	}
+}
+
+func TestScheduleNextInLocation(t *testing.T) {
+	loc := time.FixedZone("UTC+2", 2*60*60)
+	s, _ := Parse("0 9 * * *")
+
+	got := s.Next(time.Date(2026, time.January, 5, 8, 0, 0, 0, time.UTC).In(loc))
+	if want := time.Date(2026, time.January, 6, 7, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("Expected %v, but got %v", want, got)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{"* * * *", "expected 5 fields, got 4"},
+		{"@often", "unknown macro"},
+		{"60 * * * *", "minute 60 is outside 0-59"},
+		{"* * 0 * *", "day of month 0 is outside 1-31"},
+		{"* * * foo *", `invalid value "foo" in month field`},
+		{"*/0 * * * *", `invalid step "0" in minute field`},
+		{"* 5-1 * * *", `range "5-1" in hour field ends before it starts`},
+	}
+
+	for _, tt := range tests {
+		_, err := Parse(tt.expr)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: expected an error containing %q, but got %v", tt.expr, tt.want, err)
+		}
+	}
+}
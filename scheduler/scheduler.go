@@ -0,0 +1,405 @@
+// Package scheduler runs chaos experiments on cron schedules inside the
+// current process. Unlike Gremlin's own schedules, every run loads its
+// experiment afresh, so targets and spec files are resolved at run time; it
+// must pass the client's blackout and policy checks before anything launches;
+// and the experiment's probes watch it while it runs.
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+
+	gremlin "github.com/sonnysideup/go-gremlin"
+	"github.com/sonnysideup/go-gremlin/gameday"
+)
+
+// Clock is the scheduler's time source. gremlintest.Clock satisfies it, so
+// tests can move time on without sleeping. Other packages that wait on a
+// clock, such as monkey, share it.
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+// SystemClock is the Clock of the real time.
+type SystemClock struct{}
+
+// Now returns time.Now().
+func (SystemClock) Now() time.Time { return time.Now() }
+
+// After returns time.After(d).
+func (SystemClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// MissedPolicy says what a job does about the runs it missed, because its
+// previous run was still going or the process was not running on time.
+type MissedPolicy string
+
+// Missed-run policies
+const (
+	// SkipMissed drops missed runs and waits for the next scheduled time.
+	SkipMissed MissedPolicy = "skip"
+
+	// RunOnce makes up for any number of missed runs with a single run
+	// straight away.
+	RunOnce MissedPolicy = "run-once"
+)
+
+// Job is an experiment run on a schedule. A job never runs twice at once.
+type Job struct {
+	Name string
+
+	// Schedule is a cron expression (see Parse), evaluated in the
+	// scheduler's Location.
+	Schedule string
+
+	// Jitter delays each run by a random duration of up to Jitter, so that
+	// jobs on the same schedule do not all start together.
+	Jitter time.Duration
+
+	// Missed is the missed-run policy (default SkipMissed).
+	Missed MissedPolicy
+
+	// Grace is how late a run may start before it counts as missed (default
+	// 1m).
+	Grace time.Duration
+
+	// Experiment returns the experiment to run. It is called for every run;
+	// see SpecExperiment.
+	Experiment func(ctx context.Context) (*gameday.Experiment, error)
+}
+
+// RunStatus is the outcome of a single run of a job.
+type RunStatus string
+
+// Run outcomes
+const (
+	// RunPassed means the experiment passed.
+	RunPassed RunStatus = "passed"
+
+	// RunFailed means the experiment ran and did not pass.
+	RunFailed RunStatus = "failed"
+
+	// RunBlocked means a blackout window or the policy refused one of the
+	// experiment's attacks, so nothing was launched.
+	RunBlocked RunStatus = "blocked"
+
+	// RunError means the experiment could not be loaded or is invalid.
+	RunError RunStatus = "error"
+)
+
+// Run records a single run of a job.
+type Run struct {
+	// Scheduled is the time the schedule fired for, before jitter.
+	Scheduled time.Time `json:"scheduled"`
+	StartTime time.Time `json:"start_time"`
+	EndTime   time.Time `json:"end_time"`
+
+	Status RunStatus       `json:"status"`
+	Reason string          `json:"reason,omitempty"`
+	Result *gameday.Result `json:"result,omitempty"`
+}
+
+// JobStatus is a snapshot of a job.
+type JobStatus struct {
+	Name     string `json:"name"`
+	Schedule string `json:"schedule"`
+
+	// Next is when the job will next run, jitter included. It is zero
+	// while the job is running and once the schedule has no more times.
+	Next    time.Time `json:"next,omitempty"`
+	Running bool      `json:"running"`
+
+	Runs     int `json:"runs"`
+	Failures int `json:"failures"`
+	Blocked  int `json:"blocked"`
+	Missed   int `json:"missed"`
+
+	Last *Run `json:"last,omitempty"`
+}
+
+// Scheduler runs jobs on their schedules.
+type Scheduler struct {
+	// Client checks every attack against the blackout calendar and policy
+	// before a run (see gremlin.Client.Preflight). It is also the Runner's
+	// API unless the Runner has one.
+	Client *gremlin.Client
+
+	// Runner runs the experiments.
+	Runner gameday.Runner
+
+	// Clock defaults to the real clock.
+	Clock Clock
+
+	// Location the cron expressions are evaluated in (default time.Local).
+	Location *time.Location
+
+	// Rand is the source of jitter (default seeded from the time).
+	Rand *rand.Rand
+
+	mu      sync.Mutex
+	jobs    []*job
+	ctx     context.Context
+	wg      sync.WaitGroup
+	started bool
+}
+
+type job struct {
+	Job
+	schedule *Schedule
+	status   JobStatus
+}
+
+func (s *Scheduler) clock() Clock {
+	if s.Clock == nil {
+		return SystemClock{}
+	}
+	return s.Clock
+}
+
+func (s *Scheduler) location() *time.Location {
+	if s.Location == nil {
+		return time.Local
+	}
+	return s.Location
+}
+
+// Add adds a job. Jobs added while the scheduler runs start straight away.
+func (s *Scheduler) Add(j Job) error {
+	if j.Name == "" {
+		return fmt.Errorf("Job has no name")
+	}
+	if j.Experiment == nil {
+		return fmt.Errorf("Job %q has no Experiment function", j.Name)
+	}
+	switch j.Missed {
+	case "":
+		j.Missed = SkipMissed
+	case SkipMissed, RunOnce:
+	default:
+		return fmt.Errorf("Job %q has an invalid missed-run policy %q", j.Name, j.Missed)
+	}
+	if j.Grace <= 0 {
+		j.Grace = time.Minute
+	}
+	sched, err := Parse(j.Schedule)
+	if err != nil {
+		return fmt.Errorf("Job %q: %v", j.Name, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, other := range s.jobs {
+		if other.Name == j.Name {
+			return fmt.Errorf("Job %q already exists", j.Name)
+		}
+	}
+
+	jb := &job{Job: j, schedule: sched, status: JobStatus{Name: j.Name, Schedule: j.Schedule}}
+	s.jobs = append(s.jobs, jb)
+	if s.started && s.ctx.Err() == nil {
+		s.start(jb)
+	}
+	return nil
+}
+
+// Run runs the jobs until ctx is cancelled, then waits for the runs in
+// progress to halt their attacks and returns ctx.Err().
+func (s *Scheduler) Run(ctx context.Context) error {
+	if s.Client == nil && s.Runner.API == nil {
+		return fmt.Errorf("Scheduler has no Client")
+	}
+	if s.Runner.API == nil {
+		s.Runner.API = s.Client
+	}
+
+	s.mu.Lock()
+	if s.started {
+		s.mu.Unlock()
+		return fmt.Errorf("Scheduler is already running")
+	}
+	if s.Rand == nil {
+		s.Rand = rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+	s.started, s.ctx = true, ctx
+	for _, jb := range s.jobs {
+		s.start(jb)
+	}
+	s.mu.Unlock()
+
+	<-ctx.Done()
+
+	// Add only starts jobs while ctx is live and holds s.mu to do so, so
+	// once s.mu has been taken here no more can join s.wg
+	s.mu.Lock()
+	s.mu.Unlock()
+	s.wg.Wait()
+
+	s.mu.Lock()
+	s.started = false
+	s.mu.Unlock()
+	return ctx.Err()
+}
+
+// start runs a job's loop. Callers must hold s.mu.
+func (s *Scheduler) start(jb *job) {
+	ctx := s.ctx
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.loop(ctx, jb)
+	}()
+}
+
+// loop waits for each scheduled time and runs the job, applying its
+// missed-run policy to the times that went by while it ran or slept.
+func (s *Scheduler) loop(ctx context.Context, jb *job) {
+	clock := s.clock()
+	next := jb.schedule.Next(clock.Now().In(s.location()))
+
+	for !next.IsZero() {
+		delay := s.jitter(jb.Jitter)
+		s.update(jb, func(st *JobStatus) { st.Next = next.Add(delay) })
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-clock.After(next.Add(delay).Sub(clock.Now())):
+		}
+
+		now := clock.Now().In(s.location())
+		due, last := 0, next
+		for t := next; !t.IsZero() && !t.After(now); t = jb.schedule.Next(t) {
+			due, last = due+1, t
+		}
+		if due == 0 {
+			// the clock went backwards; wait for the same time again
+			continue
+		}
+		next = jb.schedule.Next(now)
+
+		missed := due - 1
+		late := now.Sub(last) > jb.Grace+delay
+		if late && jb.Missed == SkipMissed {
+			s.update(jb, func(st *JobStatus) { st.Missed += due })
+			continue
+		}
+		s.update(jb, func(st *JobStatus) {
+			st.Missed += missed
+			st.Next, st.Running = time.Time{}, true
+		})
+		s.execute(ctx, jb, last)
+	}
+	s.update(jb, func(st *JobStatus) { st.Next = time.Time{} })
+}
+
+func (s *Scheduler) jitter(max time.Duration) time.Duration {
+	if max <= 0 {
+		return 0
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return time.Duration(s.Rand.Int63n(int64(max)))
+}
+
+// execute runs the job once and records the outcome.
+func (s *Scheduler) execute(ctx context.Context, jb *job, scheduled time.Time) {
+	clock := s.clock()
+	run := &Run{Scheduled: scheduled, StartTime: clock.Now()}
+
+	exp, err := jb.Experiment(ctx)
+	switch {
+	case err != nil:
+		run.Status, run.Reason = RunError, err.Error()
+	default:
+		if err := s.preflight(ctx, exp); err != nil {
+			run.Status, run.Reason = RunBlocked, err.Error()
+			break
+		}
+		res, err := s.Runner.Run(ctx, *exp)
+		if err != nil {
+			run.Status, run.Reason = RunError, err.Error()
+			break
+		}
+		run.Result, run.Reason = res, res.Reason
+		if res.Passed() {
+			run.Status = RunPassed
+		} else {
+			run.Status = RunFailed
+		}
+	}
+	run.EndTime = clock.Now()
+
+	s.update(jb, func(st *JobStatus) {
+		st.Running, st.Last = false, run
+		st.Runs++
+		switch run.Status {
+		case RunFailed, RunError:
+			st.Failures++
+		case RunBlocked:
+			st.Blocked++
+		}
+	})
+}
+
+// preflight checks every attack of the experiment before any is launched,
+// so a blocked attack never leaves a scenario half run.
+func (s *Scheduler) preflight(ctx context.Context, exp *gameday.Experiment) error {
+	if s.Client == nil {
+		return nil
+	}
+	for _, step := range exp.Steps {
+		if step.Attack == nil {
+			continue
+		}
+		if err := s.Client.Preflight(ctx, *step.Attack); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Scheduler) update(jb *job, fn func(st *JobStatus)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fn(&jb.status)
+}
+
+// Status returns a snapshot of every job, in the order they were added.
+func (s *Scheduler) Status() []JobStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	statuses := []JobStatus{}
+	for _, jb := range s.jobs {
+		statuses = append(statuses, jb.status)
+	}
+	return statuses
+}
+
+// Job returns a snapshot of the named job.
+func (s *Scheduler) Job(name string) (JobStatus, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, jb := range s.jobs {
+		if jb.Name == name {
+			return jb.status, true
+		}
+	}
+	return JobStatus{}, false
+}
+
+// ServeHTTP serves the status of every job as JSON, so a scheduler can be
+// mounted on a process's debug or admin server.
+func (s *Scheduler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(s.Status())
+}
@@ -0,0 +1,283 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	gremlin "github.com/sonnysideup/go-gremlin"
+	"github.com/sonnysideup/go-gremlin/gameday"
+	"github.com/sonnysideup/go-gremlin/gremlintest"
+)
+
+// newScheduler returns a scheduler on a fake clock stopped at 10:00:30 UTC.
+// Its runner uses a fake API on a clock of its own, which every runner sleep
+// advances, so experiments finish without waiting.
+func newScheduler() (*Scheduler, *gremlintest.Clock, *gremlintest.Fake) {
+	f := gremlintest.NewFake()
+	f.AddHost(gremlin.Host{Identifier: "web-1", State: gremlin.HostActive})
+
+	clock := gremlintest.NewClock(time.Date(2026, time.January, 5, 10, 0, 30, 0, time.UTC))
+	s := &Scheduler{
+		Runner: gameday.Runner{
+			API:      f,
+			Interval: 5 * time.Second,
+			Now:      f.Clock.Now,
+			Sleep: func(ctx context.Context, d time.Duration) error {
+				if err := ctx.Err(); err != nil {
+					return err
+				}
+				f.Clock.Advance(d)
+				return nil
+			},
+		},
+		Clock:    clock,
+		Location: time.UTC,
+	}
+	return s, clock, f
+}
+
+func cpuExperiment(ctx context.Context) (*gameday.Experiment, error) {
+	return &gameday.Experiment{
+		Name: "cpu",
+		Steps: []gameday.Step{{Attack: &gremlin.AttackCommand{
+			Command: gremlin.Command{Type: "cpu", Args: []string{"-l", "10"}},
+			Target:  gremlin.Target{Type: gremlin.TargetRandom},
+		}}},
+	}, nil
+}
+
+// start runs the scheduler until the returned function is called.
+func start(t *testing.T, s *Scheduler) func() {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- s.Run(ctx) }()
+
+	return func() {
+		cancel()
+		if err := <-done; err != context.Canceled {
+			t.Errorf("Expected Run to return context.Canceled, but got %v", err)
+		}
+	}
+}
+
+func status(t *testing.T, s *Scheduler, name string) JobStatus {
+	st, ok := s.Job(name)
+	if !ok {
+		t.Fatalf("Expected job %q to exist", name)
+	}
+	return st
+}
+
+func TestSchedulerRunsJobs(t *testing.T) {
+	s, clock, f := newScheduler()
+	if err := s.Add(Job{Name: "cpu", Schedule: "*/5 * * * *", Experiment: cpuExperiment}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	stop := start(t, s)
+	defer stop()
+
+	clock.BlockUntil(1)
+	if st := status(t, s, "cpu"); !st.Next.Equal(time.Date(2026, time.January, 5, 10, 5, 0, 0, time.UTC)) || st.Runs != 0 {
+		t.Errorf("Expected the first run at 10:05, but got %+v", st)
+	}
+
+	clock.Advance(4*time.Minute + 30*time.Second)
+	clock.BlockUntil(1)
+
+	st := status(t, s, "cpu")
+	if st.Runs != 1 || st.Last == nil || st.Last.Status != RunPassed || st.Last.Result == nil {
+		t.Fatalf("Expected one passing run, but got %+v", st)
+	}
+	if !st.Last.Scheduled.Equal(time.Date(2026, time.January, 5, 10, 5, 0, 0, time.UTC)) {
+		t.Errorf("Expected the run to be scheduled for 10:05, but got %v", st.Last.Scheduled)
+	}
+	if !st.Next.Equal(time.Date(2026, time.January, 5, 10, 10, 0, 0, time.UTC)) || st.Running {
+		t.Errorf("Expected the next run at 10:10, but got %+v", st)
+	}
+	f.AssertCallCount(t, "CreateAttackContext", 1)
+}
+
+func TestSchedulerJitter(t *testing.T) {
+	s, clock, _ := newScheduler()
+	s.Add(Job{Name: "cpu", Schedule: "@hourly", Jitter: 10 * time.Minute, Experiment: cpuExperiment})
+	stop := start(t, s)
+	defer stop()
+
+	clock.BlockUntil(1)
+	hour := time.Date(2026, time.January, 5, 11, 0, 0, 0, time.UTC)
+	next := status(t, s, "cpu").Next
+	if next.Before(hour) || !next.Before(hour.Add(10*time.Minute)) {
+		t.Fatalf("Expected the run within 10m of 11:00, but got %v", next)
+	}
+
+	clock.Set(next.Add(-time.Second))
+	if st := status(t, s, "cpu"); st.Runs != 0 {
+		t.Errorf("Expected no run before the jitter elapsed, but got %+v", st)
+	}
+	clock.Set(next)
+	clock.BlockUntil(1)
+	if st := status(t, s, "cpu"); st.Runs != 1 {
+		t.Errorf("Expected a run once the jitter elapsed, but got %+v", st)
+	}
+}
+
+func TestSchedulerMissedRuns(t *testing.T) {
+	s, clock, _ := newScheduler()
+	s.Add(Job{Name: "skip", Schedule: "* * * * *", Grace: 10 * time.Second, Experiment: cpuExperiment})
+	s.Add(Job{Name: "once", Schedule: "* * * * *", Grace: 10 * time.Second, Missed: RunOnce, Experiment: cpuExperiment})
+	stop := start(t, s)
+	defer stop()
+
+	// the process sleeps through 10:01 to 10:05 and wakes at 10:05:30
+	clock.BlockUntil(2)
+	clock.Advance(5 * time.Minute)
+	clock.BlockUntil(2)
+
+	if st := status(t, s, "skip"); st.Runs != 0 || st.Missed != 5 {
+		t.Errorf("Expected the skip job to miss all five runs, but got %+v", st)
+	}
+	if st := status(t, s, "once"); st.Runs != 1 || st.Missed != 4 || !st.Last.Scheduled.Equal(time.Date(2026, time.January, 5, 10, 5, 0, 0, time.UTC)) {
+		t.Errorf("Expected the run-once job to run once for 10:05, but got %+v", st)
+	}
+}
+
+func TestSchedulerNeverOverlaps(t *testing.T) {
+	s, clock, _ := newScheduler()
+	started, release := make(chan bool, 10), make(chan bool)
+	s.Add(Job{Name: "slow", Schedule: "* * * * *", Experiment: func(ctx context.Context) (*gameday.Experiment, error) {
+		started <- true
+		<-release
+		return cpuExperiment(ctx)
+	}})
+	stop := start(t, s)
+	defer stop()
+
+	clock.BlockUntil(1)
+	clock.Advance(30 * time.Second)
+	<-started
+	if st := status(t, s, "slow"); !st.Running {
+		t.Errorf("Expected the job to be running, but got %+v", st)
+	}
+
+	// 10:02 and 10:03 pass while the first run is still going
+	clock.Advance(2 * time.Minute)
+	close(release)
+	clock.BlockUntil(1)
+
+	st := status(t, s, "slow")
+	if st.Runs != 2 || st.Missed != 1 || st.Running {
+		t.Errorf("Expected a single catch-up run for 10:03, but got %+v", st)
+	}
+	if len(started) != 1 {
+		t.Errorf("Expected the runs not to overlap, but %d more started", len(started))
+	}
+}
+
+func TestSchedulerBlackout(t *testing.T) {
+	s, clock, f := newScheduler()
+	cal := gremlin.NewCalendar(nil)
+	cal.AddRange("freeze", time.Now().Add(-time.Hour), time.Now().Add(time.Hour))
+	s.Client = gremlin.NewClient("Test Org", "user@domain.com", "secret", gremlin.WithBlackouts(cal))
+
+	s.Add(Job{Name: "cpu", Schedule: "* * * * *", Experiment: cpuExperiment})
+	stop := start(t, s)
+	defer stop()
+
+	clock.BlockUntil(1)
+	clock.Advance(30 * time.Second)
+	clock.BlockUntil(1)
+
+	st := status(t, s, "cpu")
+	if st.Blocked != 1 || st.Last.Status != RunBlocked || !strings.Contains(st.Last.Reason, `blackout window "freeze"`) {
+		t.Errorf("Expected the run to be blocked by the freeze, but got %+v", st.Last)
+	}
+	f.AssertNotCalled(t, "CreateAttackContext")
+}
+
+func TestSchedulerAdd(t *testing.T) {
+	s := &Scheduler{}
+	tests := []struct {
+		job  Job
+		want string
+	}{
+		{Job{Schedule: "@daily", Experiment: cpuExperiment}, "Job has no name"},
+		{Job{Name: "a", Schedule: "@daily"}, `Job "a" has no Experiment function`},
+		{Job{Name: "a", Schedule: "daily", Experiment: cpuExperiment}, `Job "a": Invalid cron expression "daily"`},
+		{Job{Name: "a", Schedule: "@daily", Missed: "all", Experiment: cpuExperiment}, `invalid missed-run policy "all"`},
+	}
+	for _, tt := range tests {
+		if err := s.Add(tt.job); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Expected an error containing %q, but got %v", tt.want, err)
+		}
+	}
+
+	s.Add(Job{Name: "a", Schedule: "@daily", Experiment: cpuExperiment})
+	if err := s.Add(Job{Name: "a", Schedule: "@daily", Experiment: cpuExperiment}); err == nil || err.Error() != `Job "a" already exists` {
+		t.Errorf("Expected a duplicate job error, but got %v", err)
+	}
+	if err := s.Run(context.Background()); err == nil || err.Error() != "Scheduler has no Client" {
+		t.Errorf("Expected a missing client error, but got %v", err)
+	}
+}
+
+func TestSchedulerAddWhileStopping(t *testing.T) {
+	s, clock, _ := newScheduler()
+	s.Add(Job{Name: "cpu", Schedule: "@daily", Experiment: cpuExperiment})
+	stop := start(t, s)
+	clock.BlockUntil(1)
+
+	added := make(chan bool)
+	go func() {
+		for i := 0; i < 50; i++ {
+			s.Add(Job{Name: fmt.Sprintf("job-%d", i), Schedule: "@daily", Experiment: cpuExperiment})
+		}
+		close(added)
+	}()
+	stop()
+	<-added
+}
+
+func TestSchedulerServeHTTP(t *testing.T) {
+	s, _, _ := newScheduler()
+	s.Add(Job{Name: "cpu", Schedule: "@daily", Experiment: cpuExperiment})
+
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+
+	var statuses []JobStatus
+	if err := json.Unmarshal(w.Body.Bytes(), &statuses); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(statuses) != 1 || statuses[0].Name != "cpu" || statuses[0].Schedule != "@daily" {
+		t.Errorf("Unexpected statuses: %+v", statuses)
+	}
+}
+
+func TestSpecExperiment(t *testing.T) {
+	dir, err := ioutil.TempDir("", "scheduler")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "cpu.yaml")
+	ioutil.WriteFile(path, []byte("apiVersion: gremlin/v1\nkind: Attack\nmetadata: {name: web-cpu}\nspec: {command: {type: cpu}}\n"), 0600)
+
+	exp, err := SpecExperiment("web-cpu", path)(context.Background())
+	if err != nil || exp.Name != "web-cpu" || len(exp.Steps) != 1 {
+		t.Errorf("Expected the web-cpu experiment, but got %+v, %v", exp, err)
+	}
+
+	_, err = SpecExperiment("db-cpu", path)(context.Background())
+	if err == nil || !strings.Contains(err.Error(), `No Attack or Scenario named "db-cpu"`) {
+		t.Errorf("Expected a missing experiment error, but got %v", err)
+	}
+}
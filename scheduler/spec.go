@@ -0,0 +1,28 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sonnysideup/go-gremlin/gameday"
+	"github.com/sonnysideup/go-gremlin/spec"
+)
+
+// SpecExperiment returns a Job.Experiment function that loads the spec files
+// at every run and returns the experiment of the Attack or Scenario document
+// with the given name. Reloading means edits to the files and the environment
+// variables they use take effect at the next run.
+func SpecExperiment(name string, paths ...string) func(ctx context.Context) (*gameday.Experiment, error) {
+	return func(ctx context.Context) (*gameday.Experiment, error) {
+		docs, err := spec.Load(paths...)
+		if err != nil {
+			return nil, err
+		}
+		for i := range docs {
+			if docs[i].Name == name && (docs[i].Kind == spec.KindAttack || docs[i].Kind == spec.KindScenario) {
+				return docs[i].Experiment()
+			}
+		}
+		return nil, fmt.Errorf("No Attack or Scenario named %q in %v", name, paths)
+	}
+}
@@ -0,0 +1,39 @@
+package gremlin
+
+import (
+	"context"
+	"net/http"
+)
+
+// ListSchedules returns the scheduled attacks in the organization.
+func (c *Client) ListSchedules(ctx context.Context) ([]Schedule, error) {
+	var schedules []Schedule
+	if err := c.getJSON(ctx, "schedules/attacks", &schedules); err != nil {
+		return nil, err
+	}
+	return schedules, nil
+}
+
+// CreateSchedule schedules an attack and returns the schedule as stored by
+// Gremlin, including its GUID.
+func (c *Client) CreateSchedule(ctx context.Context, s Schedule) (*Schedule, error) {
+	created := &Schedule{}
+	if err := c.sendJSON(ctx, "POST", "schedules/attacks", s, http.StatusCreated, created); err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+// UpdateSchedule replaces the scheduled attack with the same GUID.
+func (c *Client) UpdateSchedule(ctx context.Context, s Schedule) (*Schedule, error) {
+	updated := &Schedule{}
+	if err := c.sendJSON(ctx, "PUT", "schedules/"+s.Guid, s, http.StatusOK, updated); err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+// DeleteSchedule removes a scheduled attack.
+func (c *Client) DeleteSchedule(ctx context.Context, guid string) error {
+	return c.deleteResource(ctx, "schedules/"+guid)
+}
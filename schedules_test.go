@@ -0,0 +1,50 @@
+package gremlin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestCreateAndListSchedules(t *testing.T) {
+	mux, client, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/schedules/attacks", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "GET" {
+			fmt.Fprint(w, `[{"guid":"s-1","name":"weekday cpu","trigger":{"activeDays":["M","T"],"start":"10:00","end":"16:00"}}]`)
+			return
+		}
+
+		testMethod(t, r, "POST")
+		var s Schedule
+		json.NewDecoder(r.Body).Decode(&s)
+		if got, want := s.Trigger.MaxRuns, 2; got != want {
+			t.Errorf("Expected maxRuns %d, but got %d", want, got)
+		}
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprint(w, `{"guid":"s-1"}`)
+	})
+
+	created, err := client.CreateSchedule(context.Background(), Schedule{
+		Name:    "weekday cpu",
+		Command: Command{Type: "cpu"},
+		Trigger: ScheduleTrigger{Days: []string{"M", "T"}, Start: "10:00", End: "16:00", MaxRuns: 2},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got, want := created.Guid, "s-1"; got != want {
+		t.Errorf("Expected guid %q, but got %q", want, got)
+	}
+
+	schedules, err := client.ListSchedules(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got, want := schedules[0].Trigger.Days, []string{"M", "T"}; len(got) != len(want) || got[1] != want[1] {
+		t.Errorf("Expected days %v, but got %v", want, got)
+	}
+}
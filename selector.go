@@ -0,0 +1,435 @@
+package gremlin
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Selector is the parsed form of a target selector expression such as
+//
+//	tags(zone=us-east-1a, service=checkout) random(10%) exclude(host-7)
+//
+// The grammar is a whitespace separated list of clauses:
+//
+//	exact(host, ...)       target exactly these hosts
+//	random                 target one random host
+//	random(N) random(N%)   target N hosts, or N percent of the matching hosts
+//	tags(key=value, ...)   restrict random targets to hosts with these tags
+//	labels(key=value, ...) restrict the attack to containers with these labels
+//	exclude(host, ...)     never select these hosts for a random target
+//
+// Keys, values and hosts may be written bare when they only contain letters,
+// digits and any of "_.:/@*-", otherwise they must be double quoted using Go
+// string syntax. When neither exact() nor random is given the target is Random.
+type Selector struct {
+	Target Target
+
+	// Labels are applied to the AttackCommand to target Docker containers
+	Labels map[string]string
+}
+
+// SelectorError describes a syntax or semantic error in a selector expression.
+// Column is 1-based and counted in characters.
+type SelectorError struct {
+	Column int
+	Msg    string
+}
+
+func (e *SelectorError) Error() string {
+	return fmt.Sprintf("Invalid selector at column %d: %s", e.Column, e.Msg)
+}
+
+// ParseSelector parses a selector expression. Formatting the result with
+// String and parsing it again always yields an identical Selector.
+func ParseSelector(s string) (*Selector, error) {
+	p := &selectorParser{src: s}
+	if err := p.parse(); err != nil {
+		return nil, err
+	}
+	return p.sel, nil
+}
+
+// MustParseSelector is like ParseSelector but panics if the expression is invalid.
+func MustParseSelector(s string) *Selector {
+	sel, err := ParseSelector(s)
+	if err != nil {
+		panic(err)
+	}
+	return sel
+}
+
+// AttackCommand combines the selector with a command into an attack request.
+func (s *Selector) AttackCommand(cmd Command) AttackCommand {
+	return AttackCommand{Command: cmd, Target: s.Target, Labels: s.Labels}
+}
+
+// String formats the selector in its canonical form: the exact or random
+// clause first, followed by tags, labels and exclude, with map keys sorted.
+func (s *Selector) String() string {
+	var clauses []string
+
+	if s.Target.Type == TargetExact {
+		clauses = append(clauses, "exact("+formatSelectorList(s.Target.Exact)+")")
+	} else {
+		switch {
+		case s.Target.Percent > 0:
+			clauses = append(clauses, fmt.Sprintf("random(%d%%)", s.Target.Percent))
+		case s.Target.Count > 0:
+			clauses = append(clauses, fmt.Sprintf("random(%d)", s.Target.Count))
+		default:
+			clauses = append(clauses, "random")
+		}
+	}
+
+	if len(s.Target.Tags) > 0 {
+		clauses = append(clauses, "tags("+formatSelectorMap(s.Target.Tags)+")")
+	}
+	if len(s.Labels) > 0 {
+		clauses = append(clauses, "labels("+formatSelectorMap(s.Labels)+")")
+	}
+	if len(s.Target.Exclude) > 0 {
+		clauses = append(clauses, "exclude("+formatSelectorList(s.Target.Exclude)+")")
+	}
+
+	return strings.Join(clauses, " ")
+}
+
+func formatSelectorList(values []string) string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = formatSelectorWord(v)
+	}
+	return strings.Join(quoted, ", ")
+}
+
+func formatSelectorMap(m map[string]string) string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, len(keys))
+	for i, k := range keys {
+		pairs[i] = formatSelectorWord(k) + "=" + formatSelectorWord(m[k])
+	}
+	return strings.Join(pairs, ", ")
+}
+
+func formatSelectorWord(s string) string {
+	if s == "" {
+		return `""`
+	}
+	for i := 0; i < len(s); i++ {
+		if !isSelectorWordByte(s[i]) {
+			return strconv.Quote(s)
+		}
+	}
+	return s
+}
+
+func isSelectorWordByte(b byte) bool {
+	switch {
+	case 'a' <= b && b <= 'z', 'A' <= b && b <= 'Z', '0' <= b && b <= '9':
+		return true
+	}
+	return strings.IndexByte("_.:/@*-", b) >= 0
+}
+
+// lexer
+
+type selectorTokenKind int
+
+const (
+	tokEOF selectorTokenKind = iota
+	tokWord
+	tokString
+	tokLParen
+	tokRParen
+	tokComma
+	tokEquals
+	tokPercent
+)
+
+type selectorToken struct {
+	kind  selectorTokenKind
+	value string
+	pos   int // byte offset into the source
+}
+
+func (t selectorToken) describe() string {
+	switch t.kind {
+	case tokEOF:
+		return "end of input"
+	case tokWord:
+		return fmt.Sprintf("%q", t.value)
+	case tokString:
+		return "quoted string " + strconv.Quote(t.value)
+	}
+	return fmt.Sprintf("%q", t.value)
+}
+
+type selectorParser struct {
+	src  string
+	pos  int
+	tok  selectorToken
+	sel  *Selector
+	seen map[string]bool
+}
+
+func (p *selectorParser) errorf(pos int, format string, args ...interface{}) error {
+	return &SelectorError{
+		Column: utf8.RuneCountInString(p.src[:pos]) + 1,
+		Msg:    fmt.Sprintf(format, args...),
+	}
+}
+
+// next advances p.tok to the following token in the source.
+func (p *selectorParser) next() error {
+	for p.pos < len(p.src) && strings.IndexByte(" \t\r\n", p.src[p.pos]) >= 0 {
+		p.pos++
+	}
+
+	start := p.pos
+	if start >= len(p.src) {
+		p.tok = selectorToken{kind: tokEOF, pos: start}
+		return nil
+	}
+
+	punct := map[byte]selectorTokenKind{
+		'(': tokLParen, ')': tokRParen, ',': tokComma, '=': tokEquals, '%': tokPercent,
+	}
+
+	c := p.src[start]
+	switch {
+	case punct[c] != 0:
+		p.pos++
+		p.tok = selectorToken{kind: punct[c], value: string(c), pos: start}
+	case c == '"':
+		end := start + 1
+		for ; end < len(p.src) && p.src[end] != '"'; end++ {
+			if p.src[end] == '\\' {
+				end++
+			}
+		}
+		if end >= len(p.src) {
+			return p.errorf(start, "unterminated quoted string")
+		}
+		value, err := strconv.Unquote(p.src[start : end+1])
+		if err != nil {
+			return p.errorf(start, "invalid quoted string %s", p.src[start:end+1])
+		}
+		p.pos = end + 1
+		p.tok = selectorToken{kind: tokString, value: value, pos: start}
+	case isSelectorWordByte(c):
+		for p.pos < len(p.src) && isSelectorWordByte(p.src[p.pos]) {
+			p.pos++
+		}
+		p.tok = selectorToken{kind: tokWord, value: p.src[start:p.pos], pos: start}
+	default:
+		r, _ := utf8.DecodeRuneInString(p.src[start:])
+		return p.errorf(start, "unexpected character %q", r)
+	}
+
+	return nil
+}
+
+func (p *selectorParser) expect(kind selectorTokenKind, what string) (selectorToken, error) {
+	tok := p.tok
+	if tok.kind != kind {
+		return tok, p.errorf(tok.pos, "expected %s, found %s", what, tok.describe())
+	}
+	return tok, p.next()
+}
+
+// parser
+
+func (p *selectorParser) parse() error {
+	p.sel = &Selector{Target: Target{Type: TargetRandom}}
+	p.seen = make(map[string]bool)
+
+	if err := p.next(); err != nil {
+		return err
+	}
+
+	for p.tok.kind != tokEOF {
+		if err := p.parseClause(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (p *selectorParser) parseClause() error {
+	name, err := p.expect(tokWord, "clause name")
+	if err != nil {
+		return err
+	}
+
+	switch name.value {
+	case "exact", "random", "tags", "labels", "exclude":
+	default:
+		return p.errorf(name.pos, "unknown clause %q (expected exact, random, tags, labels or exclude)", name.value)
+	}
+
+	if p.seen[name.value] {
+		return p.errorf(name.pos, "duplicate %s clause", name.value)
+	}
+	p.seen[name.value] = true
+
+	// exact() selects hosts by name so the random-only clauses make no sense with it
+	conflicts := map[string][]string{
+		"exact":   {"random", "tags", "exclude"},
+		"random":  {"exact"},
+		"tags":    {"exact"},
+		"exclude": {"exact"},
+	}
+	for _, other := range conflicts[name.value] {
+		if p.seen[other] {
+			return p.errorf(name.pos, "%s cannot be combined with %s", name.value, other)
+		}
+	}
+
+	switch name.value {
+	case "exact":
+		hosts, err := p.parseList(name)
+		if err != nil {
+			return err
+		}
+		p.sel.Target.Type = TargetExact
+		p.sel.Target.Exact = hosts
+	case "random":
+		return p.parseRandom()
+	case "tags":
+		tags, err := p.parseMap(name)
+		if err != nil {
+			return err
+		}
+		p.sel.Target.Tags = tags
+	case "labels":
+		labels, err := p.parseMap(name)
+		if err != nil {
+			return err
+		}
+		p.sel.Labels = labels
+	case "exclude":
+		hosts, err := p.parseList(name)
+		if err != nil {
+			return err
+		}
+		p.sel.Target.Exclude = hosts
+	}
+
+	return nil
+}
+
+func (p *selectorParser) parseRandom() error {
+	// a bare "random" keeps the default selection size
+	if p.tok.kind != tokLParen {
+		return nil
+	}
+	if err := p.next(); err != nil {
+		return err
+	}
+
+	num, err := p.expect(tokWord, "number of hosts or percentage")
+	if err != nil {
+		return err
+	}
+	n, err := strconv.Atoi(num.value)
+	if err != nil || n < 1 {
+		return p.errorf(num.pos, "expected a positive number, found %s", num.describe())
+	}
+
+	if p.tok.kind == tokPercent {
+		if n > 100 {
+			return p.errorf(num.pos, "percentage must be between 1 and 100, found %d", n)
+		}
+		p.sel.Target.Percent = n
+		if err := p.next(); err != nil {
+			return err
+		}
+	} else {
+		p.sel.Target.Count = n
+	}
+
+	_, err = p.expect(tokRParen, `")"`)
+	return err
+}
+
+func (p *selectorParser) parseValue(what string) (selectorToken, error) {
+	if p.tok.kind == tokString {
+		return p.expect(tokString, what)
+	}
+	return p.expect(tokWord, what)
+}
+
+func (p *selectorParser) parseList(clause selectorToken) ([]string, error) {
+	if _, err := p.expect(tokLParen, `"("`); err != nil {
+		return nil, err
+	}
+	if p.tok.kind == tokRParen {
+		return nil, p.errorf(p.tok.pos, "%s() requires at least one host", clause.value)
+	}
+
+	var values []string
+	for {
+		v, err := p.parseValue("host name")
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, v.value)
+
+		if p.tok.kind != tokComma {
+			break
+		}
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+	}
+
+	_, err := p.expect(tokRParen, `"," or ")"`)
+	return values, err
+}
+
+func (p *selectorParser) parseMap(clause selectorToken) (map[string]string, error) {
+	if _, err := p.expect(tokLParen, `"("`); err != nil {
+		return nil, err
+	}
+	if p.tok.kind == tokRParen {
+		return nil, p.errorf(p.tok.pos, "%s() requires at least one key=value pair", clause.value)
+	}
+
+	m := make(map[string]string)
+	for {
+		k, err := p.parseValue("key")
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := m[k.value]; ok {
+			return nil, p.errorf(k.pos, "duplicate key %s in %s()", k.describe(), clause.value)
+		}
+		if _, err := p.expect(tokEquals, `"="`); err != nil {
+			return nil, err
+		}
+		v, err := p.parseValue("value")
+		if err != nil {
+			return nil, err
+		}
+		m[k.value] = v.value
+
+		if p.tok.kind != tokComma {
+			break
+		}
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+	}
+
+	_, err := p.expect(tokRParen, `"," or ")"`)
+	return m, err
+}
@@ -0,0 +1,145 @@
+package gremlin
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseSelector(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Selector
+	}{
+		{"", Selector{Target: Target{Type: TargetRandom}}},
+		{"random", Selector{Target: Target{Type: TargetRandom}}},
+		{"random(3)", Selector{Target: Target{Type: TargetRandom, Count: 3}}},
+		{
+			"tags(zone=us-east-1a, service=checkout) random(10%) exclude(host-7)",
+			Selector{Target: Target{
+				Type:    TargetRandom,
+				Percent: 10,
+				Tags:    map[string]string{"zone": "us-east-1a", "service": "checkout"},
+				Exclude: []string{"host-7"},
+			}},
+		},
+		{
+			`exact(web-1,"web 2") labels(app=api)`,
+			Selector{
+				Target: Target{Type: TargetExact, Exact: []string{"web-1", "web 2"}},
+				Labels: map[string]string{"app": "api"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseSelector(tt.in)
+		if err != nil {
+			t.Errorf("ParseSelector(%q) returned unexpected error: %v", tt.in, err)
+			continue
+		}
+		if !reflect.DeepEqual(*got, tt.want) {
+			t.Errorf("ParseSelector(%q) = %+v, want %+v", tt.in, *got, tt.want)
+		}
+	}
+}
+
+func TestSelectorString(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"", "random"},
+		{"exclude(a) random( 5 )", "random(5) exclude(a)"},
+		{"tags(b=2, a=1) random(10%)", "random(10%) tags(a=1, b=2)"},
+		{`exact(h1, "h 2") labels("k\n"=v)`, `exact(h1, "h 2") labels("k\n"=v)`},
+		{`tags(env="")`, `random tags(env="")`},
+	}
+
+	for _, tt := range tests {
+		if got := MustParseSelector(tt.in).String(); got != tt.want {
+			t.Errorf("ParseSelector(%q).String() = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseSelectorErrors(t *testing.T) {
+	tests := []struct {
+		in     string
+		column int
+		msg    string
+	}{
+		{"bogus(a)", 1, `unknown clause "bogus"`},
+		{"random random", 8, "duplicate random clause"},
+		{"exact(a) random", 10, "random cannot be combined with exact"},
+		{"tags(a=1) exact(b)", 11, "exact cannot be combined with tags"},
+		{"random(0)", 8, "expected a positive number"},
+		{"random(101%)", 8, "percentage must be between 1 and 100"},
+		{"tags()", 6, "tags() requires at least one key=value pair"},
+		{"tags(a=1, a=2)", 11, "duplicate key"},
+		{"tags(a 1)", 8, `expected "="`},
+		{"exact(a b)", 9, `expected "," or ")"`},
+		{`exact("a)`, 7, "unterminated quoted string"},
+		{`exact("ä", b) #`, 15, "unexpected character '#'"},
+		{"exact(a", 8, "found end of input"},
+	}
+
+	for _, tt := range tests {
+		_, err := ParseSelector(tt.in)
+		serr, ok := err.(*SelectorError)
+		if !ok {
+			t.Errorf("ParseSelector(%q) error = %v, want *SelectorError", tt.in, err)
+			continue
+		}
+		if serr.Column != tt.column {
+			t.Errorf("ParseSelector(%q) error column = %d, want %d (%v)", tt.in, serr.Column, tt.column, err)
+		}
+		if !strings.Contains(serr.Msg, tt.msg) {
+			t.Errorf("ParseSelector(%q) error = %q, want it to contain %q", tt.in, serr.Msg, tt.msg)
+		}
+	}
+}
+
+func TestSelectorAttackCommand(t *testing.T) {
+	sel := MustParseSelector("exact(web-1) labels(app=api)")
+	cmd := Command{Type: "cpu"}
+
+	got := sel.AttackCommand(cmd)
+	want := AttackCommand{
+		Command: cmd,
+		Target:  Target{Type: TargetExact, Exact: []string{"web-1"}},
+		Labels:  map[string]string{"app": "api"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("AttackCommand() = %+v, want %+v", got, want)
+	}
+}
+
+func FuzzParseSelector(f *testing.F) {
+	f.Add("")
+	f.Add("tags(zone=us-east-1a, service=checkout) random(10%) exclude(host-7)")
+	f.Add(`exact(web-1, "web \"2\"") labels(app=api)`)
+	f.Add("random(3) tags(a=\"\\xff\")")
+
+	f.Fuzz(func(t *testing.T, in string) {
+		sel, err := ParseSelector(in)
+		if err != nil {
+			if _, ok := err.(*SelectorError); !ok {
+				t.Fatalf("ParseSelector(%q) returned %T, want *SelectorError", in, err)
+			}
+			return
+		}
+
+		formatted := sel.String()
+		again, err := ParseSelector(formatted)
+		if err != nil {
+			t.Fatalf("ParseSelector(%q) of formatted %q failed: %v", in, formatted, err)
+		}
+		if !reflect.DeepEqual(sel, again) {
+			t.Fatalf("round trip of %q via %q = %+v, want %+v", in, formatted, again, sel)
+		}
+		if got := again.String(); got != formatted {
+			t.Fatalf("String() not stable: %q then %q", formatted, got)
+		}
+	})
+}
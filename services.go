@@ -0,0 +1,70 @@
+package gremlin
+
+import (
+	"context"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+// AuthService obtains access tokens.
+type AuthService interface {
+	Authenticate() (*AccessToken, error)
+}
+
+// AttackService launches, inspects and halts attacks.
+type AttackService interface {
+	CreateAttack(ac AttackCommand) (*uuid.UUID, error)
+	CreateAttackContext(ctx context.Context, ac AttackCommand) (*uuid.UUID, error)
+	ListAttacks(ctx context.Context) ([]Attack, error)
+	ListActiveAttacks(ctx context.Context) ([]Attack, error)
+	ListCompletedAttacks(ctx context.Context) ([]Attack, error)
+	GetAttack(ctx context.Context, guid uuid.UUID) (*Attack, error)
+	ListExecutions(ctx context.Context, guid uuid.UUID) ([]Execution, error)
+	HaltAttack(ctx context.Context, guid uuid.UUID) error
+	HaltAllAttacks(ctx context.Context) error
+}
+
+// ClientService lists the hosts ("clients") and containers that can be
+// attacked.
+type ClientService interface {
+	ListClients(ctx context.Context) ([]Host, error)
+	ListContainers(ctx context.Context) ([]Container, error)
+}
+
+// TemplateService manages saved attack templates.
+type TemplateService interface {
+	ListTemplates(ctx context.Context) ([]Template, error)
+	CreateTemplate(ctx context.Context, t Template) (*Template, error)
+	UpdateTemplate(ctx context.Context, t Template) (*Template, error)
+	DeleteTemplate(ctx context.Context, guid string) error
+}
+
+// ScheduleService manages scheduled attacks.
+type ScheduleService interface {
+	ListSchedules(ctx context.Context) ([]Schedule, error)
+	CreateSchedule(ctx context.Context, s Schedule) (*Schedule, error)
+	UpdateSchedule(ctx context.Context, s Schedule) (*Schedule, error)
+	DeleteSchedule(ctx context.Context, guid string) error
+}
+
+// ScenarioService manages and runs scenarios.
+type ScenarioService interface {
+	ListScenarios(ctx context.Context) ([]Scenario, error)
+	GetScenario(ctx context.Context, guid string) (*Scenario, error)
+	CreateScenario(ctx context.Context, s Scenario) (*Scenario, error)
+	RunScenario(ctx context.Context, guid string) (*ScenarioRun, error)
+}
+
+// API is every Gremlin endpoint. *Client implements it; code that accepts an
+// API, or one of the narrower services, can be tested with a fake such as
+// gremlintest.Fake instead.
+type API interface {
+	AuthService
+	AttackService
+	ClientService
+	TemplateService
+	ScheduleService
+	ScenarioService
+}
+
+var _ API = (*Client)(nil)
@@ -0,0 +1,268 @@
+package gremlin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+// SessionOptions configures a Session.
+type SessionOptions struct {
+	// StatePath is a file the session keeps the list of launched attacks in,
+	// so Recover can halt them after a crash. Leave empty to disable.
+	StatePath string
+
+	// HandleSignals halts every attack on SIGINT or SIGTERM. Once the attacks
+	// are halted the signal is re-raised so the process still terminates.
+	HandleSignals bool
+
+	// HaltTimeout bounds how long Close spends halting attacks (default 30s).
+	HaltTimeout time.Duration
+}
+
+// Session launches attacks and guarantees they are halted when the session
+// ends, whether through Close, cancellation of its context, or a signal.
+type Session struct {
+	client *Client
+	opts   SessionOptions
+
+	mu      sync.Mutex
+	attacks []uuid.UUID
+	closed  bool
+
+	// inherited are the attacks an earlier session left in the state file,
+	// kept in every write until Recover halts them. loaded is set once the
+	// file has been read.
+	inherited []uuid.UUID
+	loaded    bool
+
+	closeOnce sync.Once
+	closeErr  error
+	done      chan struct{}
+}
+
+// sessionState is the on-disk format of SessionOptions.StatePath.
+type sessionState struct {
+	PID     int         `json:"pid"`
+	Attacks []uuid.UUID `json:"attacks"`
+}
+
+// NewSession starts a session that is closed, halting its attacks, when ctx
+// is cancelled.
+func (c *Client) NewSession(ctx context.Context, opts SessionOptions) *Session {
+	if opts.HaltTimeout <= 0 {
+		opts.HaltTimeout = 30 * time.Second
+	}
+
+	s := &Session{client: c, opts: opts, done: make(chan struct{})}
+
+	signals := make(chan os.Signal, 1)
+	if opts.HandleSignals {
+		signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	}
+
+	go func() {
+		defer signal.Stop(signals)
+
+		select {
+		case <-ctx.Done():
+			s.Close()
+		case sig := <-signals:
+			s.Close()
+			signal.Stop(signals)
+			if p, err := os.FindProcess(os.Getpid()); err == nil {
+				p.Signal(sig)
+			}
+		case <-s.done:
+		}
+	}()
+
+	return s
+}
+
+// CreateAttack launches an attack and records it in the session.
+func (s *Session) CreateAttack(ctx context.Context, ac AttackCommand) (*uuid.UUID, error) {
+	s.mu.Lock()
+	closed := s.closed
+	s.mu.Unlock()
+	if closed {
+		return nil, fmt.Errorf("Session is closed")
+	}
+
+	guid, err := s.client.CreateAttackContext(ctx, ac)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// the session may have been closed while the attack was being launched
+	if s.closed {
+		if err := s.client.HaltAttack(context.Background(), *guid); err != nil {
+			return guid, fmt.Errorf("Session closed while launching attack %s and halting it failed: %v", guid, err)
+		}
+		return guid, fmt.Errorf("Session closed while launching attack %s; it has been halted", guid)
+	}
+
+	s.attacks = append(s.attacks, *guid)
+	return guid, s.persist()
+}
+
+// Attacks returns the attacks launched through the session.
+func (s *Session) Attacks() []uuid.UUID {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]uuid.UUID(nil), s.attacks...)
+}
+
+// Close halts every attack launched through the session. It is safe to call
+// more than once; later calls return the result of the first. Once every
+// attack has been halted the state file is removed, unless it still lists
+// attacks of an earlier session that Recover has not halted.
+func (s *Session) Close() error {
+	s.closeOnce.Do(func() {
+		s.mu.Lock()
+		s.closed = true
+		attacks := append([]uuid.UUID(nil), s.attacks...)
+		s.mu.Unlock()
+
+		ctx, cancel := context.WithTimeout(context.Background(), s.opts.HaltTimeout)
+		defer cancel()
+
+		s.closeErr = haltAll(ctx, s.client, attacks)
+		if s.closeErr == nil && s.opts.StatePath != "" {
+			s.mu.Lock()
+			switch {
+			case !s.loaded:
+				// never written by this session; leave it for Recover
+			case len(s.inherited) > 0:
+				s.closeErr = s.write(s.inherited)
+			default:
+				os.Remove(s.opts.StatePath)
+			}
+			s.mu.Unlock()
+		}
+		close(s.done)
+	})
+
+	return s.closeErr
+}
+
+// Recover halts the attacks recorded in the state file by an earlier session
+// that never closed, e.g. because its process crashed. The halted attacks
+// are returned and the file is rewritten to contain only this session's.
+// Attacks may be launched before Recover runs; the state file keeps the
+// earlier session's attacks until they are halted.
+func (s *Session) Recover(ctx context.Context) ([]uuid.UUID, error) {
+	if s.opts.StatePath == "" {
+		return nil, fmt.Errorf("Session has no StatePath to recover from")
+	}
+
+	s.mu.Lock()
+	orphans, err := s.orphans()
+	s.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := haltAll(ctx, s.client, orphans); err != nil {
+		return orphans, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.inherited, s.loaded = nil, true
+	return orphans, s.persist()
+}
+
+// orphans returns the attacks in the state file that were not launched by
+// this session. Callers must hold s.mu.
+func (s *Session) orphans() ([]uuid.UUID, error) {
+	data, err := ioutil.ReadFile(s.opts.StatePath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("Failed to read session state: %v", err)
+	}
+
+	var state sessionState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("Failed to parse session state: %v", err)
+	}
+
+	ours := make(map[uuid.UUID]bool)
+	for _, guid := range s.attacks {
+		ours[guid] = true
+	}
+
+	var orphans []uuid.UUID
+	for _, guid := range state.Attacks {
+		if !ours[guid] {
+			orphans = append(orphans, guid)
+		}
+	}
+	return orphans, nil
+}
+
+// persist writes the attack list to the state file, together with any
+// attacks an earlier session left there, so that launching before Recover
+// never loses them. Callers must hold s.mu.
+func (s *Session) persist() error {
+	if s.opts.StatePath == "" {
+		return nil
+	}
+
+	if !s.loaded {
+		inherited, err := s.orphans()
+		if err != nil {
+			return err
+		}
+		s.inherited, s.loaded = inherited, true
+	}
+
+	return s.write(append(append([]uuid.UUID(nil), s.inherited...), s.attacks...))
+}
+
+// write replaces the state file with the given attacks.
+func (s *Session) write(attacks []uuid.UUID) error {
+	data, err := json.Marshal(sessionState{PID: os.Getpid(), Attacks: attacks})
+	if err != nil {
+		return fmt.Errorf("Failed to marshal session state: %v", err)
+	}
+
+	// write then rename so a crash never leaves a truncated file behind
+	tmp := s.opts.StatePath + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("Failed to write session state: %v", err)
+	}
+	if err := os.Rename(tmp, s.opts.StatePath); err != nil {
+		return fmt.Errorf("Failed to write session state: %v", err)
+	}
+
+	return nil
+}
+
+// haltAll attempts to halt every attack and reports all failures together.
+func haltAll(ctx context.Context, c *Client, attacks []uuid.UUID) error {
+	var failures []string
+	for _, guid := range attacks {
+		if err := c.HaltAttack(ctx, guid); err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", guid, err))
+		}
+	}
+
+	if len(failures) > 0 {
+		return fmt.Errorf("Failed to halt %d of %d attacks: %s", len(failures), len(attacks), strings.Join(failures, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,225 @@
+package gremlin
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+// haltRecorder serves attack launches with fresh GUIDs and records halts.
+type haltRecorder struct {
+	mu     sync.Mutex
+	halted []string
+}
+
+func (h *haltRecorder) register(mux *http.ServeMux) {
+	mux.HandleFunc("/attacks/new", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprint(w, uuid.NewV4().String())
+	})
+	mux.HandleFunc("/attacks/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "DELETE" {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		h.mu.Lock()
+		h.halted = append(h.halted, strings.TrimPrefix(r.URL.Path, "/attacks/"))
+		h.mu.Unlock()
+	})
+}
+
+func (h *haltRecorder) Halted() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	halted := append([]string(nil), h.halted...)
+	sort.Strings(halted)
+	return halted
+}
+
+func guidStrings(guids []uuid.UUID) []string {
+	var ss []string
+	for _, g := range guids {
+		ss = append(ss, g.String())
+	}
+	sort.Strings(ss)
+	return ss
+}
+
+func TestHaltAttackSuccess(t *testing.T) {
+	mux, client, teardown := setup()
+	defer teardown()
+
+	guid := uuid.Must(uuid.FromString("123e4567-e89b-12d3-a456-426655440000"))
+
+	mux.HandleFunc("/attacks/"+guid.String(), func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "DELETE")
+		testHeader(t, r, "Authorization", "Bearer fake-token")
+	})
+
+	if err := client.HaltAttack(context.Background(), guid); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestHaltAllAttacksWithServiceUnavailable(t *testing.T) {
+	mux, client, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/attacks", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "DELETE")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+
+	err := client.HaltAllAttacks(context.Background())
+
+	if err == nil {
+		t.Errorf("Expected service unavailable to result in error")
+	} else {
+		if got, want := err.Error(), "status: 503"; !strings.Contains(got, want) {
+			t.Errorf("Expected error to match %q, but got %q", want, got)
+		}
+	}
+}
+
+func TestSessionCloseHaltsAttacks(t *testing.T) {
+	mux, client, teardown := setup()
+	defer teardown()
+	recorder := &haltRecorder{}
+	recorder.register(mux)
+
+	dir, _ := ioutil.TempDir("", "gremlin")
+	defer os.RemoveAll(dir)
+	statePath := filepath.Join(dir, "session.json")
+
+	session := client.NewSession(context.Background(), SessionOptions{StatePath: statePath})
+	for i := 0; i < 2; i++ {
+		if _, err := session.CreateAttack(context.Background(), buildAttack()); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	if _, err := os.Stat(statePath); err != nil {
+		t.Errorf("Expected state file to be written: %v", err)
+	}
+
+	if err := session.Close(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if got, want := recorder.Halted(), guidStrings(session.Attacks()); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected halted attacks %v, but got %v", want, got)
+	}
+	if _, err := os.Stat(statePath); !os.IsNotExist(err) {
+		t.Errorf("Expected state file to be removed after close, but got %v", err)
+	}
+	if _, err := session.CreateAttack(context.Background(), buildAttack()); err == nil {
+		t.Errorf("Expected launch on closed session to result in error")
+	}
+}
+
+func TestSessionHaltsAttacksOnContextCancel(t *testing.T) {
+	mux, client, teardown := setup()
+	defer teardown()
+	recorder := &haltRecorder{}
+	recorder.register(mux)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	session := client.NewSession(ctx, SessionOptions{})
+	guid, err := session.CreateAttack(ctx, buildAttack())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	cancel()
+
+	deadline := time.Now().Add(time.Second)
+	for len(recorder.Halted()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+
+	if got, want := recorder.Halted(), []string{guid.String()}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected halted attacks %v, but got %v", want, got)
+	}
+}
+
+func TestSessionRecoverHaltsOrphans(t *testing.T) {
+	mux, client, teardown := setup()
+	defer teardown()
+	recorder := &haltRecorder{}
+	recorder.register(mux)
+
+	dir, _ := ioutil.TempDir("", "gremlin")
+	defer os.RemoveAll(dir)
+	statePath := filepath.Join(dir, "session.json")
+
+	// a session that crashed without closing
+	crashed := client.NewSession(context.Background(), SessionOptions{StatePath: statePath})
+	crashed.CreateAttack(context.Background(), buildAttack())
+
+	session := client.NewSession(context.Background(), SessionOptions{StatePath: statePath})
+	orphans, err := session.Recover(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if got, want := guidStrings(orphans), guidStrings(crashed.Attacks()); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected orphans %v, but got %v", want, got)
+	}
+	if got, want := recorder.Halted(), guidStrings(crashed.Attacks()); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected halted attacks %v, but got %v", want, got)
+	}
+
+	orphans, _ = session.Recover(context.Background())
+	if len(orphans) != 0 {
+		t.Errorf("Expected recovered attacks to be forgotten, but got %v", orphans)
+	}
+}
+
+func TestSessionKeepsOrphansUntilRecovered(t *testing.T) {
+	mux, client, teardown := setup()
+	defer teardown()
+	recorder := &haltRecorder{}
+	recorder.register(mux)
+
+	dir, _ := ioutil.TempDir("", "gremlin")
+	defer os.RemoveAll(dir)
+	statePath := filepath.Join(dir, "session.json")
+
+	crashed := client.NewSession(context.Background(), SessionOptions{StatePath: statePath})
+	crashed.CreateAttack(context.Background(), buildAttack())
+
+	// a new process launches before recovering, then exits cleanly
+	first := client.NewSession(context.Background(), SessionOptions{StatePath: statePath})
+	if _, err := first.CreateAttack(context.Background(), buildAttack()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := first.Close(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	session := client.NewSession(context.Background(), SessionOptions{StatePath: statePath})
+	orphans, err := session.Recover(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got, want := guidStrings(orphans), guidStrings(crashed.Attacks()); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected the crashed session's attacks %v to survive, but got %v", want, got)
+	}
+	if err := session.Close(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := os.Stat(statePath); !os.IsNotExist(err) {
+		t.Errorf("Expected state file to be removed once recovered, but got %v", err)
+	}
+}
@@ -0,0 +1,67 @@
+package spec
+
+import (
+	"context"
+	"fmt"
+
+	gremlin "github.com/sonnysideup/go-gremlin"
+)
+
+// Result records what applying a single document created in Gremlin.
+type Result struct {
+	Document *Document
+
+	// GUID of the created attack, template, schedule or scenario
+	GUID string
+}
+
+// Apply creates the object described by each document in order, stopping at
+// the first failure. Attacks go through CreateAttackContext, so the client
+// Policy and blackout calendar apply to them. The results of the documents
+// applied before a failure are returned alongside the error.
+func Apply(ctx context.Context, client *gremlin.Client, docs []Document) ([]Result, error) {
+	results := make([]Result, 0, len(docs))
+
+	for i := range docs {
+		doc := &docs[i]
+
+		guid, err := apply(ctx, client, doc)
+		if err != nil {
+			return results, fmt.Errorf("Failed to apply %s %q (%s): %v", doc.Kind, doc.Name, doc.Source, err)
+		}
+		results = append(results, Result{Document: doc, GUID: guid})
+	}
+
+	return results, nil
+}
+
+func apply(ctx context.Context, client *gremlin.Client, doc *Document) (string, error) {
+	switch doc.Kind {
+	case KindAttack:
+		guid, err := client.CreateAttackContext(ctx, *doc.Attack)
+		if err != nil {
+			return "", err
+		}
+		return guid.String(), nil
+	case KindTemplate:
+		t, err := client.CreateTemplate(ctx, *doc.Template)
+		if err != nil {
+			return "", err
+		}
+		return t.Guid, nil
+	case KindSchedule:
+		s, err := client.CreateSchedule(ctx, *doc.Schedule)
+		if err != nil {
+			return "", err
+		}
+		return s.Guid, nil
+	case KindScenario:
+		s, err := client.CreateScenario(ctx, *doc.Scenario)
+		if err != nil {
+			return "", err
+		}
+		return s.Guid, nil
+	default:
+		return "", fmt.Errorf("unknown kind %q", doc.Kind)
+	}
+}
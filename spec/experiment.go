@@ -0,0 +1,163 @@
+package spec
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+
+	gremlin "github.com/sonnysideup/go-gremlin"
+	"github.com/sonnysideup/go-gremlin/gameday"
+	"github.com/sonnysideup/go-gremlin/probe"
+)
+
+// Probe is a health probe declared in an Attack or Scenario document:
+//
+//	spec:
+//	  hypothesis: Checkout keeps serving while one host burns CPU
+//	  probes:
+//	    - http: {url: "https://shop.example.com/healthz", maxLatency: 500ms}
+//	      threshold: 3
+//	    - prometheus:
+//	        url: http://prometheus:9090
+//	        query: sum(rate(http_errors_total[1m]))
+//	        condition: "< 0.05"
+//
+// Each probe sets exactly one of http, tcp, dns, exec and prometheus.
+type Probe struct {
+	probe.Probe
+
+	// Threshold is how many consecutive failures fail the experiment, and
+	// Timeout bounds each sample. Zero values use the probe.Monitor
+	// defaults.
+	Threshold int
+	Timeout   time.Duration
+}
+
+// Monitor returns a new monitor for the probe.
+func (p Probe) Monitor() *probe.Monitor {
+	return &probe.Monitor{Probe: p.Probe, Threshold: p.Threshold, Timeout: p.Timeout}
+}
+
+type probeBody struct {
+	Name      string           `yaml:"name"`
+	Threshold int              `yaml:"threshold"`
+	Timeout   gremlin.Duration `yaml:"timeout"`
+
+	HTTP *struct {
+		URL        string           `yaml:"url"`
+		Method     string           `yaml:"method"`
+		Status     int              `yaml:"status"`
+		Body       string           `yaml:"body"`
+		MaxLatency gremlin.Duration `yaml:"maxLatency"`
+	} `yaml:"http"`
+	TCP *struct {
+		Address string `yaml:"address"`
+	} `yaml:"tcp"`
+	DNS *struct {
+		Host     string   `yaml:"host"`
+		Resolver string   `yaml:"resolver"`
+		Expect   []string `yaml:"expect"`
+	} `yaml:"dns"`
+	Exec *struct {
+		Command  string   `yaml:"command"`
+		Args     []string `yaml:"args"`
+		ExitCode int      `yaml:"exitCode"`
+	} `yaml:"exec"`
+	Prometheus *struct {
+		URL       string `yaml:"url"`
+		Query     string `yaml:"query"`
+		Condition string `yaml:"condition"`
+	} `yaml:"prometheus"`
+}
+
+func (b probeBody) probe() (Probe, error) {
+	var p probe.Probe
+	switch {
+	case b.HTTP != nil:
+		h := &probe.HTTPProbe{URL: b.HTTP.URL, Method: b.HTTP.Method, MaxLatency: time.Duration(b.HTTP.MaxLatency)}
+		if b.HTTP.Status != 0 {
+			h.Status = []int{b.HTTP.Status}
+		}
+		if b.HTTP.Body != "" {
+			re, err := regexp.Compile(b.HTTP.Body)
+			if err != nil {
+				return Probe{}, err
+			}
+			h.Body = re
+		}
+		p = h
+	case b.TCP != nil:
+		p = &probe.TCPProbe{Address: b.TCP.Address}
+	case b.DNS != nil:
+		p = &probe.DNSProbe{Host: b.DNS.Host, Resolver: b.DNS.Resolver, Expect: b.DNS.Expect}
+	case b.Exec != nil:
+		p = &probe.ExecProbe{Command: b.Exec.Command, Args: b.Exec.Args, ExitCode: b.Exec.ExitCode}
+	case b.Prometheus != nil:
+		p = &probe.PrometheusProbe{URL: b.Prometheus.URL, Query: b.Prometheus.Query, Condition: b.Prometheus.Condition}
+	default:
+		return Probe{}, fmt.Errorf("probe has no kind")
+	}
+
+	if b.Name != "" {
+		p = probe.Named(b.Name, p)
+	}
+	return Probe{Probe: p, Threshold: b.Threshold, Timeout: time.Duration(b.Timeout)}, nil
+}
+
+// experimentBody holds the keys shared by the kinds that can be run as an
+// experiment.
+type experimentBody struct {
+	Hypothesis string      `yaml:"hypothesis"`
+	Probes     []probeBody `yaml:"probes"`
+}
+
+func (b experimentBody) apply(doc *Document) error {
+	doc.Hypothesis = b.Hypothesis
+	for _, pb := range b.Probes {
+		p, err := pb.probe()
+		if err != nil {
+			return err
+		}
+		doc.Probes = append(doc.Probes, p)
+	}
+	return nil
+}
+
+// Experiment returns the experiment an Attack or Scenario document describes.
+// Its hypothesis is that every probe passes before the attacks and keeps
+// passing while they run. An Attack is a single step named after the
+// document. A Scenario has a step per attack, preceded by a wait step for
+// each delay, and runs them one after the other like Gremlin does.
+func (d *Document) Experiment() (*gameday.Experiment, error) {
+	exp := &gameday.Experiment{Name: d.Name, Description: d.Hypothesis}
+
+	for _, p := range d.Probes {
+		steady := p.Monitor()
+		steady.Threshold = 1
+		exp.SteadyState = append(exp.SteadyState, steady)
+		exp.Probes = append(exp.Probes, p.Monitor())
+	}
+
+	switch d.Kind {
+	case KindAttack:
+		exp.Steps = []gameday.Step{{Name: d.Name, Attack: d.Attack}}
+	case KindScenario:
+		for i, s := range d.Scenario.Steps {
+			if s.Delay > 0 {
+				exp.Steps = append(exp.Steps, gameday.Step{
+					Name: fmt.Sprintf("delay before step %d", i+1),
+					Wait: time.Duration(s.Delay) * time.Second,
+				})
+			}
+			ac := s.AttackCommand()
+			exp.Steps = append(exp.Steps, gameday.Step{
+				Name:   fmt.Sprintf("step %d (%s attack)", i+1, ac.Command.Type),
+				Attack: &ac,
+			})
+		}
+	default:
+		return nil, fmt.Errorf("%s %q (%s) is not an experiment: only Attack and Scenario documents can be run", d.Kind, d.Name, d.Source)
+	}
+
+	return exp, exp.Validate()
+}
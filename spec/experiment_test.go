@@ -0,0 +1,92 @@
+package spec
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sonnysideup/go-gremlin/probe"
+)
+
+const experimentSpec = `apiVersion: gremlin/v1
+kind: Attack
+metadata: {name: checkout-cpu}
+spec:
+  command: {type: cpu, args: ["-l", "60"]}
+  hypothesis: Checkout keeps serving
+  probes:
+    - name: checkout
+      http: {url: "https://shop/healthz", status: 204, body: ok, maxLatency: 500ms}
+      threshold: 3
+      timeout: 2s
+    - prometheus: {url: "http://prom:9090", query: "sum(errors)", condition: "< 0.05"}
+---
+apiVersion: gremlin/v1
+kind: Scenario
+metadata: {name: failover}
+spec:
+  probes:
+    - tcp: {address: "db:5432"}
+  steps:
+    - command: {type: shutdown}
+    - command: {type: latency}
+      delay: 5m
+---
+apiVersion: gremlin/v1
+kind: Template
+metadata: {name: t}
+spec:
+  command: {type: cpu}
+`
+
+func TestExperiment(t *testing.T) {
+	docs, err := Parse("x.yaml", []byte(experimentSpec), nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	attack := docs[0]
+	if attack.Hypothesis != "Checkout keeps serving" || len(attack.Probes) != 2 {
+		t.Fatalf("Expected a hypothesis and two probes, but got %q and %v", attack.Hypothesis, attack.Probes)
+	}
+	checkout := attack.Probes[0]
+	if checkout.Name() != "checkout" || checkout.Threshold != 3 || checkout.Timeout != 2*time.Second {
+		t.Errorf("Expected the named probe's settings, but got %+v", checkout)
+	}
+	if _, ok := attack.Probes[1].Probe.(*probe.PrometheusProbe); !ok {
+		t.Errorf("Expected a Prometheus probe, but got %T", attack.Probes[1].Probe)
+	}
+
+	exp, err := attack.Experiment()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(exp.Steps) != 1 || exp.Steps[0].Name != "checkout-cpu" || len(exp.SteadyState) != 2 || len(exp.Probes) != 2 {
+		t.Errorf("Expected one step and two probes, but got %+v", exp)
+	}
+	if m := exp.SteadyState[0].(*probe.Monitor); m.Threshold != 1 {
+		t.Errorf("Expected the steady state to fail on the first failure, but got threshold %d", m.Threshold)
+	}
+	if m := exp.Probes[0].(*probe.Monitor); m.Threshold != 3 {
+		t.Errorf("Expected the probe threshold, but got %d", m.Threshold)
+	}
+
+	exp, err = docs[1].Experiment()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var names []string
+	for _, s := range exp.Steps {
+		names = append(names, s.Name)
+	}
+	if got, want := strings.Join(names, ", "), "step 1 (shutdown attack), delay before step 2, step 2 (latency attack)"; got != want {
+		t.Errorf("Expected steps %q, but got %q", want, got)
+	}
+	if exp.Steps[1].Wait != 5*time.Minute {
+		t.Errorf("Expected a 5m wait, but got %v", exp.Steps[1].Wait)
+	}
+
+	if _, err := docs[2].Experiment(); err == nil || !strings.Contains(err.Error(), "is not an experiment") {
+		t.Errorf("Expected templates to be rejected, but got %v", err)
+	}
+}
@@ -0,0 +1,488 @@
+package spec
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+
+	gremlin "github.com/sonnysideup/go-gremlin"
+)
+
+// ManagedByLabel is the annotation a Reconciler stores on the templates and
+// schedules it owns.
+const ManagedByLabel = "gremlin.com/managed-by"
+
+// Action is what a Change does to a remote object.
+type Action string
+
+// Supported values for Change.Action
+const (
+	ActionCreate Action = "create"
+	ActionUpdate Action = "update"
+	ActionDelete Action = "delete"
+
+	// ActionConflict is a document whose name is taken by an object owned
+	// by someone else. A plan with conflicts cannot be applied.
+	ActionConflict Action = "conflict"
+)
+
+// FieldChange is a single differing field, with both sides rendered as JSON.
+// Old is empty for created fields and New for removed ones.
+type FieldChange struct {
+	Path string
+	Old  string
+	New  string
+}
+
+// Change is one step of a Plan. Desired is nil for deletes and Current is nil
+// for creates.
+type Change struct {
+	Action Action
+	Kind   string
+	Name   string
+	GUID   string
+	Fields []FieldChange
+
+	// Owner is set for conflicts to the managed-by annotation of Current
+	Owner string
+
+	Desired *Document
+
+	// Current is the remote *gremlin.Template or *gremlin.Schedule
+	Current interface{}
+}
+
+// Plan is the ordered list of changes that brings Gremlin in line with a set
+// of spec documents.
+type Plan struct {
+	Changes []Change
+}
+
+// Reconciler computes and applies Plans for Template and Schedule documents.
+type Reconciler struct {
+	Client *gremlin.Client
+
+	// Owner identifies the set of spec files being reconciled and must not
+	// be empty. Remote objects annotated with ManagedByLabel set to Owner are
+	// deleted once they no longer appear in the spec. Objects without the
+	// annotation are adopted when a document has the same name; objects
+	// owned by someone else are never touched and show up as conflicts.
+	Owner string
+}
+
+// ApplyError is returned when a Plan fails part way through. The changes
+// already made are rolled back; any that could not be are listed in
+// RollbackErrors and need manual attention.
+type ApplyError struct {
+	Change         Change
+	Err            error
+	RollbackErrors []error
+}
+
+func (e *ApplyError) Error() string {
+	msg := fmt.Sprintf("Failed to %s %s %q: %v", e.Change.Action, e.Change.Kind, e.Change.Name, e.Err)
+	if len(e.RollbackErrors) == 0 {
+		return msg + " (rolled back)"
+	}
+	for _, err := range e.RollbackErrors {
+		msg += "\n  rollback: " + err.Error()
+	}
+	return msg
+}
+
+// Plan compares docs with the templates and schedules in Gremlin. Only
+// Template and Schedule documents can be reconciled.
+func (r *Reconciler) Plan(ctx context.Context, docs []Document) (*Plan, error) {
+	if r.Owner == "" {
+		return nil, fmt.Errorf("Reconciler needs an Owner to tell its objects from everyone else's")
+	}
+
+	templates, err := r.Client.ListTemplates(ctx)
+	if err != nil {
+		return nil, err
+	}
+	schedules, err := r.Client.ListSchedules(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	remote := make(map[string][]remoteObject)
+	for i := range templates {
+		t := &templates[i]
+		remote[KindTemplate] = append(remote[KindTemplate], remoteObject{t.Name, t.Guid, t.Annotations, t})
+	}
+	for i := range schedules {
+		s := &schedules[i]
+		remote[KindSchedule] = append(remote[KindSchedule], remoteObject{s.Name, s.Guid, s.Annotations, s})
+	}
+
+	plan := &Plan{}
+	matched := make(map[string]bool)
+	seen := make(map[string]bool)
+
+	for i := range docs {
+		doc := &docs[i]
+		if doc.Kind != KindTemplate && doc.Kind != KindSchedule {
+			return nil, fmt.Errorf("%s: only Template and Schedule documents can be reconciled, not %s", doc.Source, doc.Kind)
+		}
+		key := doc.Kind + "/" + doc.Name
+		if seen[key] {
+			return nil, fmt.Errorf("%s: duplicate %s %q", doc.Source, doc.Kind, doc.Name)
+		}
+		seen[key] = true
+
+		desired := r.desired(doc)
+		current, taken := r.match(remote[doc.Kind], doc.Name)
+		if current == nil && taken != nil {
+			plan.Changes = append(plan.Changes, Change{
+				Action:  ActionConflict,
+				Kind:    doc.Kind,
+				Name:    doc.Name,
+				GUID:    taken.guid,
+				Owner:   taken.annotations[ManagedByLabel],
+				Desired: doc,
+				Current: taken.object,
+			})
+			continue
+		}
+		if current == nil {
+			plan.Changes = append(plan.Changes, Change{
+				Action:  ActionCreate,
+				Kind:    doc.Kind,
+				Name:    doc.Name,
+				Fields:  diff(nil, desired),
+				Desired: doc,
+			})
+			continue
+		}
+
+		matched[current.guid] = true
+		if fields := diff(current.object, desired); len(fields) > 0 {
+			plan.Changes = append(plan.Changes, Change{
+				Action:  ActionUpdate,
+				Kind:    doc.Kind,
+				Name:    doc.Name,
+				GUID:    current.guid,
+				Fields:  fields,
+				Desired: doc,
+				Current: current.object,
+			})
+		}
+	}
+
+	var deletes []Change
+	for _, kind := range []string{KindTemplate, KindSchedule} {
+		for _, o := range remote[kind] {
+			// only delete what these spec files created
+			owner, ok := o.annotations[ManagedByLabel]
+			if matched[o.guid] || !ok || owner != r.Owner {
+				continue
+			}
+			deletes = append(deletes, Change{
+				Action:  ActionDelete,
+				Kind:    kind,
+				Name:    o.name,
+				GUID:    o.guid,
+				Fields:  diff(o.object, nil),
+				Current: o.object,
+			})
+		}
+	}
+	sort.Slice(deletes, func(i, j int) bool {
+		if deletes[i].Kind != deletes[j].Kind {
+			return deletes[i].Kind > deletes[j].Kind // templates first
+		}
+		return deletes[i].Name < deletes[j].Name
+	})
+	plan.Changes = append(plan.Changes, deletes...)
+
+	return plan, nil
+}
+
+type remoteObject struct {
+	name        string
+	guid        string
+	annotations map[string]string
+	object      interface{}
+}
+
+// match finds the object a document named name should update: one owned by
+// this reconciler, or else one that nobody owns. When neither exists, taken
+// is an object with that name owned by someone else, if there is one.
+func (r *Reconciler) match(objects []remoteObject, name string) (found, taken *remoteObject) {
+	for i := range objects {
+		o := &objects[i]
+		if o.name != name {
+			continue
+		}
+		owner, ok := o.annotations[ManagedByLabel]
+		switch {
+		case ok && owner == r.Owner:
+			return o, nil
+		case !ok && found == nil:
+			found = o
+		case ok && taken == nil:
+			taken = o
+		}
+	}
+	if found != nil {
+		return found, nil
+	}
+	return nil, taken
+}
+
+// desired returns the object a document should produce, annotated as owned by
+// the reconciler.
+func (r *Reconciler) desired(doc *Document) interface{} {
+	annotations := map[string]string{ManagedByLabel: r.Owner}
+	switch doc.Kind {
+	case KindTemplate:
+		t := *doc.Template
+		for k, v := range t.Annotations {
+			annotations[k] = v
+		}
+		t.Annotations = annotations
+		return &t
+	default:
+		s := *doc.Schedule
+		for k, v := range s.Annotations {
+			annotations[k] = v
+		}
+		s.Annotations = annotations
+		return &s
+	}
+}
+
+// Apply makes the changes in the plan in order. If one fails, the changes
+// made so far are undone in reverse order and an *ApplyError is returned.
+// Nothing is changed when the plan has conflicts.
+func (r *Reconciler) Apply(ctx context.Context, plan *Plan) error {
+	if n := plan.Conflicts(); n > 0 {
+		return fmt.Errorf("Plan has %d conflict(s) with objects managed by someone else; rename the documents or remove the %s annotation", n, ManagedByLabel)
+	}
+
+	var undo []func() error
+
+	for _, change := range plan.Changes {
+		rollback, err := r.apply(ctx, change)
+		if err != nil {
+			aerr := &ApplyError{Change: change, Err: err}
+			for i := len(undo) - 1; i >= 0; i-- {
+				if err := undo[i](); err != nil {
+					aerr.RollbackErrors = append(aerr.RollbackErrors, err)
+				}
+			}
+			return aerr
+		}
+		undo = append(undo, rollback)
+	}
+
+	return nil
+}
+
+// apply makes a single change and returns a function that reverts it. The
+// rollback runs with a fresh context, since ctx may be why the plan failed.
+func (r *Reconciler) apply(ctx context.Context, change Change) (func() error, error) {
+	c := r.Client
+	bg := context.Background()
+
+	switch change.Action {
+	case ActionCreate:
+		if change.Kind == KindTemplate {
+			t, err := c.CreateTemplate(ctx, *r.desired(change.Desired).(*gremlin.Template))
+			if err != nil {
+				return nil, err
+			}
+			return func() error { return c.DeleteTemplate(bg, t.Guid) }, nil
+		}
+		s, err := c.CreateSchedule(ctx, *r.desired(change.Desired).(*gremlin.Schedule))
+		if err != nil {
+			return nil, err
+		}
+		return func() error { return c.DeleteSchedule(bg, s.Guid) }, nil
+
+	case ActionUpdate:
+		if change.Kind == KindTemplate {
+			t := r.desired(change.Desired).(*gremlin.Template)
+			t.Guid = change.GUID
+			if _, err := c.UpdateTemplate(ctx, *t); err != nil {
+				return nil, err
+			}
+			old := *change.Current.(*gremlin.Template)
+			return func() error { _, err := c.UpdateTemplate(bg, old); return err }, nil
+		}
+		s := r.desired(change.Desired).(*gremlin.Schedule)
+		s.Guid = change.GUID
+		if _, err := c.UpdateSchedule(ctx, *s); err != nil {
+			return nil, err
+		}
+		old := *change.Current.(*gremlin.Schedule)
+		return func() error { _, err := c.UpdateSchedule(bg, old); return err }, nil
+
+	case ActionDelete:
+		// a deleted object comes back with a new GUID
+		if change.Kind == KindTemplate {
+			if err := c.DeleteTemplate(ctx, change.GUID); err != nil {
+				return nil, err
+			}
+			old := *change.Current.(*gremlin.Template)
+			old.Guid = ""
+			return func() error { _, err := c.CreateTemplate(bg, old); return err }, nil
+		}
+		if err := c.DeleteSchedule(ctx, change.GUID); err != nil {
+			return nil, err
+		}
+		old := *change.Current.(*gremlin.Schedule)
+		old.Guid = ""
+		return func() error { _, err := c.CreateSchedule(bg, old); return err }, nil
+	}
+
+	return nil, fmt.Errorf("unknown action %q", change.Action)
+}
+
+// Empty reports whether Gremlin already matches the spec.
+func (p *Plan) Empty() bool {
+	return len(p.Changes) == 0
+}
+
+// Conflicts returns the number of documents whose name is taken by an object
+// owned by someone else.
+func (p *Plan) Conflicts() int {
+	n := 0
+	for _, c := range p.Changes {
+		if c.Action == ActionConflict {
+			n++
+		}
+	}
+	return n
+}
+
+// WriteTo prints the plan in a terraform-like format:
+//
+//	$ gremlin plan -owner chaos-repo chaos/templates.yaml chaos/schedules.yaml
+//	+ Template "cpu-spike"
+//	~ Schedule "weekday-cpu" (s-1)
+//	    trigger.maxRuns: 1 => 2
+//	- Template "retired" (t-9)
+//	! Template "io-stall" (t-4) is managed by "web-team"
+//
+//	Plan: 1 to create, 1 to update, 1 to delete, 1 in conflict.
+func (p *Plan) WriteTo(w io.Writer) (int64, error) {
+	var buf bytes.Buffer
+	counts := make(map[Action]int)
+
+	for _, c := range p.Changes {
+		counts[c.Action]++
+		symbol := map[Action]string{ActionCreate: "+", ActionUpdate: "~", ActionDelete: "-", ActionConflict: "!"}[c.Action]
+		fmt.Fprintf(&buf, "%s %s %q", symbol, c.Kind, c.Name)
+		if c.GUID != "" {
+			fmt.Fprintf(&buf, " (%s)", c.GUID)
+		}
+		if c.Action == ActionConflict {
+			fmt.Fprintf(&buf, " is managed by %q", c.Owner)
+		}
+		buf.WriteString("\n")
+
+		if c.Action != ActionUpdate {
+			continue
+		}
+		for _, f := range c.Fields {
+			before, after := f.Old, f.New
+			if before == "" {
+				before = "(none)"
+			}
+			if after == "" {
+				after = "(none)"
+			}
+			fmt.Fprintf(&buf, "    %s: %s => %s\n", f.Path, before, after)
+		}
+	}
+
+	if p.Empty() {
+		buf.WriteString("No changes.\n")
+	} else {
+		fmt.Fprintf(&buf, "\nPlan: %d to create, %d to update, %d to delete",
+			counts[ActionCreate], counts[ActionUpdate], counts[ActionDelete])
+		if n := counts[ActionConflict]; n > 0 {
+			fmt.Fprintf(&buf, ", %d in conflict", n)
+		}
+		buf.WriteString(".\n")
+	}
+
+	n, err := w.Write(buf.Bytes())
+	return int64(n), err
+}
+
+func (p *Plan) String() string {
+	var buf bytes.Buffer
+	p.WriteTo(&buf)
+	return buf.String()
+}
+
+// diff compares two objects field by field through their JSON encoding, so it
+// follows the API's view of which fields exist. Server-assigned fields are
+// ignored. Either side may be nil.
+func diff(before interface{}, after interface{}) []FieldChange {
+	var changes []FieldChange
+	diffValues("", toGeneric(before), toGeneric(after), &changes)
+	return changes
+}
+
+var ignoredFields = map[string]bool{"guid": true, "created_at": true}
+
+// wholeFields are maps that read better compared as a whole than key by key.
+var wholeFields = map[string]bool{"labels": true, "annotations": true, "target.tags": true}
+
+func toGeneric(v interface{}) interface{} {
+	if v == nil || reflect.ValueOf(v).IsNil() {
+		return nil
+	}
+	m := make(map[string]interface{})
+	b, _ := json.Marshal(v)
+	json.Unmarshal(b, &m)
+	for k := range ignoredFields {
+		delete(m, k)
+	}
+	return m
+}
+
+func diffValues(path string, before interface{}, after interface{}, changes *[]FieldChange) {
+	beforeMap, beforeIsMap := before.(map[string]interface{})
+	afterMap, afterIsMap := after.(map[string]interface{})
+
+	if (beforeIsMap || afterIsMap) && !wholeFields[path] {
+		keys := make(map[string]bool)
+		for k := range beforeMap {
+			keys[k] = true
+		}
+		for k := range afterMap {
+			keys[k] = true
+		}
+		sorted := make([]string, 0, len(keys))
+		for k := range keys {
+			sorted = append(sorted, k)
+		}
+		sort.Strings(sorted)
+		for _, k := range sorted {
+			diffValues(join(path, k), beforeMap[k], afterMap[k], changes)
+		}
+		return
+	}
+
+	if reflect.DeepEqual(before, after) {
+		return
+	}
+	*changes = append(*changes, FieldChange{Path: path, Old: render(before), New: render(after)})
+}
+
+func render(v interface{}) string {
+	if v == nil {
+		return ""
+	}
+	b, _ := json.Marshal(v)
+	return string(b)
+}
@@ -0,0 +1,304 @@
+package spec
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	gremlin "github.com/sonnysideup/go-gremlin"
+)
+
+// fakeStore is a minimal in-memory templates and schedules API.
+type fakeStore struct {
+	sync.Mutex
+	templates map[string]gremlin.Template
+	schedules map[string]gremlin.Schedule
+	next      int
+	requests  []string
+
+	// fail makes the request with this "METHOD path" return a 500
+	fail string
+}
+
+func newFakeStore(t *testing.T) (*fakeStore, *gremlin.Client, func()) {
+	store := &fakeStore{templates: map[string]gremlin.Template{}, schedules: map[string]gremlin.Schedule{}}
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+
+	mux.HandleFunc("/users/auth", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"org_name":"Test Org","header":"Bearer fake-token"}]`)
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		store.Lock()
+		defer store.Unlock()
+
+		req := r.Method + " " + r.URL.Path
+		if r.Method != "GET" {
+			store.requests = append(store.requests, req)
+		}
+		if req == store.fail {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		path := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+		switch {
+		case r.URL.Path == "/templates" && r.Method == "GET":
+			list := []gremlin.Template{}
+			for _, tmpl := range store.templates {
+				list = append(list, tmpl)
+			}
+			json.NewEncoder(w).Encode(list)
+		case r.URL.Path == "/schedules/attacks" && r.Method == "GET":
+			list := []gremlin.Schedule{}
+			for _, s := range store.schedules {
+				list = append(list, s)
+			}
+			json.NewEncoder(w).Encode(list)
+		case r.URL.Path == "/templates" && r.Method == "POST":
+			var tmpl gremlin.Template
+			json.NewDecoder(r.Body).Decode(&tmpl)
+			store.next++
+			tmpl.Guid = fmt.Sprintf("t-%d", store.next)
+			store.templates[tmpl.Guid] = tmpl
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(tmpl)
+		case r.URL.Path == "/schedules/attacks" && r.Method == "POST":
+			var s gremlin.Schedule
+			json.NewDecoder(r.Body).Decode(&s)
+			store.next++
+			s.Guid = fmt.Sprintf("s-%d", store.next)
+			store.schedules[s.Guid] = s
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(s)
+		case path[0] == "templates" && r.Method == "PUT":
+			var tmpl gremlin.Template
+			json.NewDecoder(r.Body).Decode(&tmpl)
+			store.templates[path[1]] = tmpl
+			json.NewEncoder(w).Encode(tmpl)
+		case path[0] == "schedules" && r.Method == "PUT":
+			var s gremlin.Schedule
+			json.NewDecoder(r.Body).Decode(&s)
+			store.schedules[path[1]] = s
+			json.NewEncoder(w).Encode(s)
+		case path[0] == "templates" && r.Method == "DELETE":
+			delete(store.templates, path[1])
+		case path[0] == "schedules" && r.Method == "DELETE":
+			delete(store.schedules, path[1])
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+
+	client := gremlin.NewClient("Test Org", "user@domain.com", "secret", gremlin.WithURL(server.URL))
+	if _, err := client.Authenticate(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	return store, client, server.Close
+}
+
+const reconcileSpec = `
+apiVersion: gremlin/v1
+kind: Template
+metadata: {name: cpu-spike}
+spec:
+  command: {type: cpu, args: ["-c", "2"]}
+  selector: random(1)
+---
+apiVersion: gremlin/v1
+kind: Template
+metadata: {name: new-latency}
+spec:
+  command: {type: latency}
+---
+apiVersion: gremlin/v1
+kind: Schedule
+metadata: {name: weekday-cpu}
+spec:
+  command: {type: cpu}
+  trigger: {days: [M], start: "10:00", end: "11:00", maxRuns: 2}
+`
+
+func seedStore(store *fakeStore) {
+	managed := map[string]string{ManagedByLabel: "chaos-repo"}
+	store.templates["t-a"] = gremlin.Template{
+		Guid: "t-a", Name: "cpu-spike",
+		Command: gremlin.Command{Type: "cpu", Args: []string{"-c", "1"}},
+		Target:  gremlin.Target{Type: gremlin.TargetRandom},
+	}
+	store.templates["t-b"] = gremlin.Template{Guid: "t-b", Name: "retired", Command: gremlin.Command{Type: "io"}, Annotations: managed}
+	store.templates["t-c"] = gremlin.Template{Guid: "t-c", Name: "someone-elses", Command: gremlin.Command{Type: "io"}}
+	store.schedules["s-a"] = gremlin.Schedule{
+		Guid: "s-a", Name: "weekday-cpu", Annotations: managed,
+		Command: gremlin.Command{Type: "cpu"},
+		Target:  gremlin.Target{Type: gremlin.TargetRandom},
+		Trigger: gremlin.ScheduleTrigger{Days: []string{"M"}, Start: "10:00", End: "11:00", MaxRuns: 2},
+	}
+}
+
+func TestReconcilerPlan(t *testing.T) {
+	store, client, teardown := newFakeStore(t)
+	defer teardown()
+	seedStore(store)
+
+	docs, err := Parse("chaos.yaml", []byte(reconcileSpec), nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	r := &Reconciler{Client: client, Owner: "chaos-repo"}
+	plan, err := r.Plan(context.Background(), docs)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	want := `~ Template "cpu-spike" (t-a)
+    annotations: (none) => {"gremlin.com/managed-by":"chaos-repo"}
+    command.args: ["-c","1"] => ["-c","2"]
+    target.count: (none) => 1
++ Template "new-latency"
+- Template "retired" (t-b)
+
+Plan: 1 to create, 1 to update, 1 to delete.
+`
+	if got := plan.String(); got != want {
+		t.Errorf("Expected plan:\n%s\nbut got:\n%s", want, got)
+	}
+
+	// applying the plan converges, so the next plan is empty
+	if err := r.Apply(context.Background(), plan); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	plan, err = r.Plan(context.Background(), docs)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !plan.Empty() {
+		t.Errorf("Expected an empty plan after applying, but got:\n%s", plan)
+	}
+	if _, ok := store.templates["t-c"]; !ok {
+		t.Errorf("Expected an unmanaged template to be left alone")
+	}
+}
+
+func TestReconcilerRollback(t *testing.T) {
+	store, client, teardown := newFakeStore(t)
+	defer teardown()
+	seedStore(store)
+
+	docs, _ := Parse("chaos.yaml", []byte(reconcileSpec), nil)
+	r := &Reconciler{Client: client, Owner: "chaos-repo"}
+	plan, err := r.Plan(context.Background(), docs)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	before := map[string]gremlin.Template{}
+	for guid, tmpl := range store.templates {
+		before[guid] = tmpl
+	}
+	store.fail = "DELETE /templates/t-b"
+
+	err = r.Apply(context.Background(), plan)
+	aerr, ok := err.(*ApplyError)
+	if !ok {
+		t.Fatalf("Expected an *ApplyError, but got %v", err)
+	}
+	if got, want := aerr.Change.Name, "retired"; got != want {
+		t.Errorf("Expected the delete of %q to fail, but got %q", want, got)
+	}
+	if len(aerr.RollbackErrors) > 0 {
+		t.Errorf("Expected a clean rollback, but got %v", aerr.RollbackErrors)
+	}
+
+	want := []string{
+		"PUT /templates/t-a",
+		"POST /templates",
+		"DELETE /templates/t-b",
+		"DELETE /templates/t-1",
+		"PUT /templates/t-a",
+	}
+	if !reflect.DeepEqual(store.requests, want) {
+		t.Errorf("Expected requests %v, but got %v", want, store.requests)
+	}
+	if !reflect.DeepEqual(store.templates, before) {
+		t.Errorf("Expected templates to be restored to %v, but got %v", before, store.templates)
+	}
+}
+
+func TestReconcilerOnlyDeletesOwnObjects(t *testing.T) {
+	store, client, teardown := newFakeStore(t)
+	defer teardown()
+	seedStore(store)
+	store.templates["t-d"] = gremlin.Template{Guid: "t-d", Name: "from-the-ui", Command: gremlin.Command{Type: "cpu"}, Annotations: map[string]string{"team": "web"}}
+
+	r := &Reconciler{Client: client, Owner: "chaos-repo"}
+	plan, err := r.Plan(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var deleted []string
+	for _, c := range plan.Changes {
+		deleted = append(deleted, c.GUID)
+	}
+	if want := []string{"t-b", "s-a"}; !reflect.DeepEqual(deleted, want) {
+		t.Errorf("Expected only the owned objects %v to be deleted, but got %v", want, deleted)
+	}
+
+	r.Owner = ""
+	if _, err := r.Plan(context.Background(), nil); err == nil || !strings.Contains(err.Error(), "needs an Owner") {
+		t.Errorf("Expected an empty Owner to be rejected, but got %v", err)
+	}
+}
+
+func TestReconcilerReportsConflicts(t *testing.T) {
+	store, client, teardown := newFakeStore(t)
+	defer teardown()
+	seedStore(store)
+	store.templates["t-d"] = gremlin.Template{
+		Guid: "t-d", Name: "new-latency", Command: gremlin.Command{Type: "latency"},
+		Annotations: map[string]string{ManagedByLabel: "web-team"},
+	}
+
+	docs, _ := Parse("chaos.yaml", []byte(reconcileSpec), nil)
+	r := &Reconciler{Client: client, Owner: "chaos-repo"}
+	plan, err := r.Plan(context.Background(), docs)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if got, want := plan.Conflicts(), 1; got != want {
+		t.Fatalf("Expected %d conflict, but got %d:\n%s", want, got, plan)
+	}
+	out := plan.String()
+	if want := `! Template "new-latency" (t-d) is managed by "web-team"`; !strings.Contains(out, want) {
+		t.Errorf("Expected the plan to contain %q, but got:\n%s", want, out)
+	}
+	if want := "Plan: 0 to create, 1 to update, 1 to delete, 1 in conflict."; !strings.Contains(out, want) {
+		t.Errorf("Expected the plan to contain %q, but got:\n%s", want, out)
+	}
+
+	if err := r.Apply(context.Background(), plan); err == nil || !strings.Contains(err.Error(), "conflict") {
+		t.Errorf("Expected a plan with conflicts to be refused, but got %v", err)
+	}
+	if len(store.requests) > 0 {
+		t.Errorf("Expected no changes to be made, but got %v", store.requests)
+	}
+}
+
+func TestReconcilerRejectsAttacks(t *testing.T) {
+	_, client, teardown := newFakeStore(t)
+	defer teardown()
+
+	docs, _ := Load("testdata/experiments.yaml")
+	r := &Reconciler{Client: client, Owner: "chaos-repo"}
+	if _, err := r.Plan(context.Background(), docs); err == nil || !strings.Contains(err.Error(), "not Attack") {
+		t.Errorf("Expected Attack documents to be rejected, but got %v", err)
+	}
+}
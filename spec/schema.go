@@ -0,0 +1,332 @@
+package spec
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	gremlin "github.com/sonnysideup/go-gremlin"
+	"github.com/sonnysideup/go-gremlin/probe"
+	yaml "gopkg.in/yaml.v3"
+)
+
+type fieldKind int
+
+const (
+	kindString fieldKind = iota
+	kindInt
+	kindDuration
+	kindStringList
+	kindStringMap
+	kindObject
+	kindList
+)
+
+func (k fieldKind) String() string {
+	return [...]string{"a string", "an integer", "a duration", "a list of strings", "a map of strings", "a mapping", "a list"}[k]
+}
+
+// field describes the expected shape of one node in a spec document.
+type field struct {
+	kind     fieldKind
+	required bool
+
+	// fields are the keys of a kindObject, elem the items of a kindList
+	fields map[string]*field
+	elem   *field
+
+	// check performs extra validation once the shape is known to be right.
+	// It returns an error message, or "" if the node is valid.
+	check func(n *yaml.Node) string
+}
+
+func str(check func(string) string) *field {
+	f := &field{kind: kindString}
+	if check != nil {
+		f.check = func(n *yaml.Node) string { return check(n.Value) }
+	}
+	return f
+}
+
+func required(f *field) *field {
+	copied := *f
+	copied.required = true
+	return &copied
+}
+
+func object(fields map[string]*field) *field {
+	return &field{kind: kindObject, fields: fields}
+}
+
+// attackFields returns the keys shared by every kind that describes an attack.
+func attackFields(extra map[string]*field) map[string]*field {
+	fields := map[string]*field{
+		"command": required(object(map[string]*field{
+			"type": required(str(oneOf("attack type", gremlin.AttackTypes))),
+			"args": {kind: kindStringList},
+		})),
+		"selector": str(func(s string) string {
+			if _, err := gremlin.ParseSelector(s); err != nil {
+				return err.Error()
+			}
+			return ""
+		}),
+		"target": object(map[string]*field{
+			"type":    required(str(oneOf("target type", []string{gremlin.TargetExact, gremlin.TargetRandom}))),
+			"exact":   {kind: kindStringList},
+			"tags":    {kind: kindStringMap},
+			"percent": {kind: kindInt},
+			"count":   {kind: kindInt},
+			"exclude": {kind: kindStringList},
+		}),
+		"labels": {kind: kindStringMap},
+	}
+	for k, v := range extra {
+		fields[k] = v
+	}
+	return fields
+}
+
+// attackObject is an attack body, which may give its target either as a
+// selector expression or as a target mapping, but not both.
+func attackObject(extra map[string]*field) *field {
+	f := object(attackFields(extra))
+	f.check = func(n *yaml.Node) string {
+		if mappingValue(n, "selector") != nil && mappingValue(n, "target") != nil {
+			return "selector and target are mutually exclusive"
+		}
+		return ""
+	}
+	return f
+}
+
+// probeKinds are the keys of a probe, exactly one of which must be set.
+var probeKinds = []string{"http", "tcp", "dns", "exec", "prometheus"}
+
+// probeObject is a health probe in an experiment.
+func probeObject() *field {
+	f := object(map[string]*field{
+		"name":      str(nil),
+		"threshold": {kind: kindInt},
+		"timeout":   {kind: kindDuration},
+		"http": object(map[string]*field{
+			"url":    required(str(nil)),
+			"method": str(nil),
+			"status": {kind: kindInt},
+			"body": str(func(s string) string {
+				if _, err := regexp.Compile(s); err != nil {
+					return err.Error()
+				}
+				return ""
+			}),
+			"maxLatency": {kind: kindDuration},
+		}),
+		"tcp": object(map[string]*field{
+			"address": required(str(nil)),
+		}),
+		"dns": object(map[string]*field{
+			"host":     required(str(nil)),
+			"resolver": str(nil),
+			"expect":   {kind: kindStringList},
+		}),
+		"exec": object(map[string]*field{
+			"command":  required(str(nil)),
+			"args":     {kind: kindStringList},
+			"exitCode": {kind: kindInt},
+		}),
+		"prometheus": object(map[string]*field{
+			"url":   required(str(nil)),
+			"query": required(str(nil)),
+			"condition": required(str(func(s string) string {
+				if err := (&probe.PrometheusProbe{Condition: s}).Validate(); err != nil {
+					return err.Error()
+				}
+				return ""
+			})),
+		}),
+	})
+	f.check = func(n *yaml.Node) string {
+		var set []string
+		for _, k := range probeKinds {
+			if mappingValue(n, k) != nil {
+				set = append(set, k)
+			}
+		}
+		if len(set) != 1 {
+			return "expected exactly one of: " + strings.Join(probeKinds, ", ")
+		}
+		return ""
+	}
+	return f
+}
+
+// experimentFields are the keys that make an attack or scenario an
+// experiment that can be run in CI.
+func experimentFields(extra map[string]*field) map[string]*field {
+	fields := map[string]*field{
+		"hypothesis": str(nil),
+		"probes":     {kind: kindList, elem: probeObject()},
+	}
+	for k, v := range extra {
+		fields[k] = v
+	}
+	return fields
+}
+
+// specSchemas maps each kind to the schema of its spec section.
+var specSchemas = map[string]*field{
+	KindAttack: attackObject(experimentFields(nil)),
+	KindTemplate: attackObject(map[string]*field{
+		"description": str(nil),
+	}),
+	KindSchedule: attackObject(map[string]*field{
+		"trigger": required(object(map[string]*field{
+			"days":     required(&field{kind: kindStringList}),
+			"start":    required(str(clock)),
+			"end":      required(str(clock)),
+			"timezone": str(nil),
+			"maxRuns":  {kind: kindInt},
+		})),
+	}),
+	KindScenario: object(experimentFields(map[string]*field{
+		"description": str(nil),
+		"steps": required(&field{kind: kindList, elem: attackObject(map[string]*field{
+			"delay": {kind: kindDuration},
+		})}),
+	})),
+}
+
+var documentSchema = object(map[string]*field{
+	"apiVersion": required(str(oneOf("apiVersion", []string{APIVersion}))),
+	"kind":       required(str(oneOf("kind", []string{KindAttack, KindTemplate, KindSchedule, KindScenario}))),
+	"metadata": required(object(map[string]*field{
+		"name":   required(str(nil)),
+		"labels": {kind: kindStringMap},
+	})),
+	"spec": required(object(nil)), // validated against specSchemas by kind
+})
+
+func oneOf(what string, allowed []string) func(string) string {
+	return func(s string) string {
+		for _, a := range allowed {
+			if s == a {
+				return ""
+			}
+		}
+		return fmt.Sprintf("unknown %s %q, expected one of: %s", what, s, strings.Join(allowed, ", "))
+	}
+}
+
+func clock(s string) string {
+	var h, m int
+	if n, _ := fmt.Sscanf(s, "%d:%d", &h, &m); n != 2 || h < 0 || h > 23 || m < 0 || m > 59 {
+		return fmt.Sprintf("invalid time of day %q, expected HH:MM", s)
+	}
+	return ""
+}
+
+// validator walks a node tree against a schema, collecting every error.
+type validator struct {
+	file   string
+	errors []FieldError
+}
+
+func (v *validator) errorf(n *yaml.Node, path string, format string, args ...interface{}) {
+	v.errors = append(v.errors, FieldError{
+		File:    v.file,
+		Line:    n.Line,
+		Column:  n.Column,
+		Path:    path,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+func (v *validator) validate(n *yaml.Node, f *field, path string) {
+	if n.Kind == yaml.AliasNode {
+		n = n.Alias
+	}
+
+	ok := true
+	switch f.kind {
+	case kindString:
+		ok = n.Kind == yaml.ScalarNode && n.ShortTag() != "!!null"
+	case kindInt:
+		var i int
+		ok = n.Kind == yaml.ScalarNode && n.Decode(&i) == nil
+	case kindDuration:
+		var d gremlin.Duration
+		ok = n.Kind == yaml.ScalarNode && n.Decode(&d) == nil
+	case kindStringList:
+		var l []string
+		ok = n.Kind == yaml.SequenceNode && n.Decode(&l) == nil
+	case kindStringMap:
+		var m map[string]string
+		ok = n.Kind == yaml.MappingNode && n.Decode(&m) == nil
+	case kindObject:
+		ok = n.Kind == yaml.MappingNode
+	case kindList:
+		ok = n.Kind == yaml.SequenceNode
+	}
+	if !ok {
+		v.errorf(n, path, "expected %s", f.kind)
+		return
+	}
+
+	switch f.kind {
+	case kindObject:
+		if f.fields == nil {
+			break
+		}
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			key, value := n.Content[i], n.Content[i+1]
+			child, known := f.fields[key.Value]
+			if !known {
+				v.errorf(key, join(path, key.Value), "unknown field")
+				continue
+			}
+			v.validate(value, child, join(path, key.Value))
+		}
+		for _, name := range sortedKeys(f.fields) {
+			if f.fields[name].required && mappingValue(n, name) == nil {
+				v.errorf(n, join(path, name), "required field is missing")
+			}
+		}
+	case kindList:
+		for i, item := range n.Content {
+			v.validate(item, f.elem, fmt.Sprintf("%s[%d]", path, i))
+		}
+	}
+
+	if f.check != nil {
+		if msg := f.check(n); msg != "" {
+			v.errorf(n, path, "%s", msg)
+		}
+	}
+}
+
+func join(path string, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func sortedKeys(m map[string]*field) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// mappingValue returns the value node for key in a mapping node, or nil.
+func mappingValue(n *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if n.Content[i].Value == key {
+			return n.Content[i+1]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,406 @@
+// Package spec loads declarative Gremlin specification files so experiments
+// can be reviewed as code and applied from CI.
+//
+// A spec file holds one or more YAML (or JSON) documents, each naming its
+// format version and kind:
+//
+//	apiVersion: gremlin/v1
+//	kind: Attack
+//	metadata:
+//	  name: checkout-cpu
+//	spec:
+//	  command:
+//	    type: cpu
+//	    args: ["-c", "1", "--length", "${LENGTH:-60}"]
+//	  selector: tags(service=checkout) random(10%)
+//
+// The kinds are Attack, Template, Schedule and Scenario. Attack-like bodies
+// give their target either as a selector expression (see
+// gremlin.ParseSelector) or as a target mapping mirroring gremlin.Target.
+// String values may reference environment variables as ${NAME} or
+// ${NAME:-default}; write $$ for a literal dollar sign.
+package spec
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	gremlin "github.com/sonnysideup/go-gremlin"
+	yaml "gopkg.in/yaml.v3"
+)
+
+// APIVersion is the only spec format version understood by this package.
+const APIVersion = "gremlin/v1"
+
+// Supported values for Document.Kind
+const (
+	KindAttack   = "Attack"
+	KindTemplate = "Template"
+	KindSchedule = "Schedule"
+	KindScenario = "Scenario"
+)
+
+// Document is a single validated spec document. Exactly one of Attack,
+// Template, Schedule and Scenario is set, according to Kind.
+type Document struct {
+	APIVersion string
+	Kind       string
+	Name       string
+	Labels     map[string]string
+
+	// Source is the "file:line" the document starts at
+	Source string
+
+	Attack   *gremlin.AttackCommand
+	Template *gremlin.Template
+	Schedule *gremlin.Schedule
+	Scenario *gremlin.Scenario
+
+	// Hypothesis and Probes make an Attack or Scenario an experiment; see
+	// Document.Experiment.
+	Hypothesis string
+	Probes     []Probe
+}
+
+// FieldError describes a problem at a specific place in a spec file.
+type FieldError struct {
+	File    string
+	Line    int
+	Column  int
+	Path    string
+	Message string
+}
+
+func (e FieldError) Error() string {
+	pos := fmt.Sprintf("%s:%d", e.File, e.Line)
+	if e.Column > 0 {
+		pos += fmt.Sprintf(":%d", e.Column)
+	}
+	if e.Path == "" {
+		return pos + ": " + e.Message
+	}
+	return pos + ": " + e.Path + ": " + e.Message
+}
+
+// ValidationError is returned when one or more spec documents are invalid. It
+// lists every problem found rather than only the first.
+type ValidationError struct {
+	Errors []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	lines := make([]string, len(e.Errors))
+	for i, fe := range e.Errors {
+		lines[i] = fe.Error()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Details returns the individual errors, for structured error output.
+func (e *ValidationError) Details() interface{} {
+	return e.Errors
+}
+
+// LookupFunc resolves an environment variable, like os.LookupEnv.
+type LookupFunc func(name string) (string, bool)
+
+// Load reads and validates spec files, interpolating variables from the
+// environment. Problems in every file are reported together in a
+// *ValidationError.
+func Load(paths ...string) ([]Document, error) {
+	var docs []Document
+	verr := &ValidationError{}
+
+	for _, path := range paths {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to read spec file: %v", err)
+		}
+
+		parsed, err := Parse(path, data, os.LookupEnv)
+		if ve, ok := err.(*ValidationError); ok {
+			verr.Errors = append(verr.Errors, ve.Errors...)
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		docs = append(docs, parsed...)
+	}
+
+	if len(verr.Errors) > 0 {
+		return nil, verr
+	}
+	return docs, nil
+}
+
+// Parse validates the spec documents in data, which is named name in errors.
+// A nil lookup leaves every variable undefined.
+func Parse(name string, data []byte, lookup LookupFunc) ([]Document, error) {
+	if lookup == nil {
+		lookup = func(string) (string, bool) { return "", false }
+	}
+
+	var docs []Document
+	v := &validator{file: name}
+
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	for {
+		var root yaml.Node
+		err := dec.Decode(&root)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			v.errors = append(v.errors, syntaxError(name, err))
+			break
+		}
+		if len(root.Content) == 0 {
+			continue
+		}
+		n := root.Content[0]
+
+		before := len(v.errors)
+		v.interpolate(n, lookup)
+		v.validate(n, documentSchema, "")
+		kind := mappingValue(n, "kind")
+		body := mappingValue(n, "spec")
+		if kind != nil && body != nil && specSchemas[kind.Value] != nil {
+			v.validate(body, specSchemas[kind.Value], "spec")
+		}
+		if len(v.errors) > before {
+			continue
+		}
+
+		doc, err := decodeDocument(n)
+		if err != nil {
+			v.errorf(n, "", "%v", err)
+			continue
+		}
+		doc.Source = fmt.Sprintf("%s:%d", name, n.Line)
+		docs = append(docs, *doc)
+	}
+
+	if len(v.errors) > 0 {
+		return nil, &ValidationError{Errors: v.errors}
+	}
+	return docs, nil
+}
+
+var yamlLineRE = regexp.MustCompile(`^yaml: line (\d+): (.*)$`)
+
+func syntaxError(name string, err error) FieldError {
+	if m := yamlLineRE.FindStringSubmatch(err.Error()); m != nil {
+		line, _ := strconv.Atoi(m[1])
+		return FieldError{File: name, Line: line, Message: m[2]}
+	}
+	return FieldError{File: name, Message: strings.TrimPrefix(err.Error(), "yaml: ")}
+}
+
+var variableRE = regexp.MustCompile(`\$\$|\$\{([A-Za-z_][A-Za-z0-9_]*)(:-([^}]*))?\}`)
+
+// interpolate expands variables in every scalar value under n.
+func (v *validator) interpolate(n *yaml.Node, lookup LookupFunc) {
+	switch n.Kind {
+	case yaml.MappingNode:
+		for i := 1; i < len(n.Content); i += 2 {
+			v.interpolate(n.Content[i], lookup)
+		}
+	case yaml.SequenceNode:
+		for _, item := range n.Content {
+			v.interpolate(item, lookup)
+		}
+	case yaml.ScalarNode:
+		if !strings.Contains(n.Value, "$") {
+			return
+		}
+		expanded := variableRE.ReplaceAllStringFunc(n.Value, func(ref string) string {
+			if ref == "$$" {
+				return "$"
+			}
+			m := variableRE.FindStringSubmatch(ref)
+			if value, ok := lookup(m[1]); ok {
+				return value
+			}
+			if m[2] != "" {
+				return m[3]
+			}
+			v.errorf(n, "", "undefined variable %s", m[1])
+			return ""
+		})
+		if expanded == n.Value {
+			return
+		}
+		n.Value = expanded
+		// let plain scalars resolve to numbers or booleans after expansion
+		if n.Style == 0 {
+			n.Tag = ""
+		}
+	}
+}
+
+type document struct {
+	APIVersion string `yaml:"apiVersion"`
+	Kind       string `yaml:"kind"`
+	Metadata   struct {
+		Name   string            `yaml:"name"`
+		Labels map[string]string `yaml:"labels"`
+	} `yaml:"metadata"`
+	Spec yaml.Node `yaml:"spec"`
+}
+
+type attackBody struct {
+	Command struct {
+		Type string   `yaml:"type"`
+		Args []string `yaml:"args"`
+	} `yaml:"command"`
+	Selector string            `yaml:"selector"`
+	Target   *gremlin.Target   `yaml:"target"`
+	Labels   map[string]string `yaml:"labels"`
+}
+
+func (b attackBody) attackCommand() (gremlin.AttackCommand, error) {
+	cmd := gremlin.Command{Type: b.Command.Type, Args: b.Command.Args}
+
+	var ac gremlin.AttackCommand
+	switch {
+	case b.Selector != "":
+		sel, err := gremlin.ParseSelector(b.Selector)
+		if err != nil {
+			return ac, err
+		}
+		ac = sel.AttackCommand(cmd)
+	case b.Target != nil:
+		ac = gremlin.AttackCommand{Command: cmd, Target: *b.Target}
+	default:
+		// like the UI, default to a single random host
+		ac = gremlin.AttackCommand{Command: cmd, Target: gremlin.Target{Type: gremlin.TargetRandom}}
+	}
+
+	for k, v := range b.Labels {
+		if ac.Labels == nil {
+			ac.Labels = make(map[string]string)
+		}
+		ac.Labels[k] = v
+	}
+	return ac, nil
+}
+
+func decodeDocument(n *yaml.Node) (*Document, error) {
+	var raw document
+	if err := n.Decode(&raw); err != nil {
+		return nil, err
+	}
+
+	doc := &Document{
+		APIVersion: raw.APIVersion,
+		Kind:       raw.Kind,
+		Name:       raw.Metadata.Name,
+		Labels:     raw.Metadata.Labels,
+	}
+
+	switch raw.Kind {
+	case KindAttack:
+		var body struct {
+			attackBody     `yaml:",inline"`
+			experimentBody `yaml:",inline"`
+		}
+		if err := raw.Spec.Decode(&body); err != nil {
+			return nil, err
+		}
+		ac, err := body.attackCommand()
+		if err != nil {
+			return nil, err
+		}
+		doc.Attack = &ac
+		if err := body.experimentBody.apply(doc); err != nil {
+			return nil, err
+		}
+
+	case KindTemplate:
+		var body struct {
+			attackBody  `yaml:",inline"`
+			Description string `yaml:"description"`
+		}
+		if err := raw.Spec.Decode(&body); err != nil {
+			return nil, err
+		}
+		ac, err := body.attackCommand()
+		if err != nil {
+			return nil, err
+		}
+		doc.Template = &gremlin.Template{
+			Name:        doc.Name,
+			Description: body.Description,
+			Command:     ac.Command,
+			Target:      ac.Target,
+			Labels:      ac.Labels,
+			Annotations: doc.Labels,
+		}
+
+	case KindSchedule:
+		var body struct {
+			attackBody `yaml:",inline"`
+			Trigger    struct {
+				Days     []string `yaml:"days"`
+				Start    string   `yaml:"start"`
+				End      string   `yaml:"end"`
+				TimeZone string   `yaml:"timezone"`
+				MaxRuns  int      `yaml:"maxRuns"`
+			} `yaml:"trigger"`
+		}
+		if err := raw.Spec.Decode(&body); err != nil {
+			return nil, err
+		}
+		ac, err := body.attackCommand()
+		if err != nil {
+			return nil, err
+		}
+		doc.Schedule = &gremlin.Schedule{
+			Name:        doc.Name,
+			Command:     ac.Command,
+			Target:      ac.Target,
+			Labels:      ac.Labels,
+			Trigger:     gremlin.ScheduleTrigger(body.Trigger),
+			Annotations: doc.Labels,
+		}
+
+	case KindScenario:
+		var body struct {
+			experimentBody `yaml:",inline"`
+			Description    string `yaml:"description"`
+			Steps          []struct {
+				attackBody `yaml:",inline"`
+				Delay      gremlin.Duration `yaml:"delay"`
+			} `yaml:"steps"`
+		}
+		if err := raw.Spec.Decode(&body); err != nil {
+			return nil, err
+		}
+		scenario := &gremlin.Scenario{Name: doc.Name, Description: body.Description}
+		for _, s := range body.Steps {
+			ac, err := s.attackCommand()
+			if err != nil {
+				return nil, err
+			}
+			scenario.Steps = append(scenario.Steps, gremlin.ScenarioStep{
+				Command: ac.Command,
+				Target:  ac.Target,
+				Labels:  ac.Labels,
+				Delay:   int(time.Duration(s.Delay) / time.Second),
+			})
+		}
+		doc.Scenario = scenario
+		if err := body.experimentBody.apply(doc); err != nil {
+			return nil, err
+		}
+	}
+
+	return doc, nil
+}
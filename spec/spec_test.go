@@ -0,0 +1,264 @@
+package spec
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	gremlin "github.com/sonnysideup/go-gremlin"
+)
+
+func lookupMap(vars map[string]string) LookupFunc {
+	return func(name string) (string, bool) {
+		v, ok := vars[name]
+		return v, ok
+	}
+}
+
+func TestLoadAllKinds(t *testing.T) {
+	os.Unsetenv("LENGTH")
+
+	docs, err := Load("testdata/experiments.yaml")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got, want := len(docs), 4; got != want {
+		t.Fatalf("Expected %d documents, but got %d", want, got)
+	}
+
+	attack := docs[0]
+	if got, want := attack.Source, "testdata/experiments.yaml:1"; got != want {
+		t.Errorf("Expected source %q, but got %q", want, got)
+	}
+	if got, want := attack.Labels["team"], "payments"; got != want {
+		t.Errorf("Expected metadata label %q, but got %q", want, got)
+	}
+	if got, want := attack.Attack.Command.Args, []string{"-c", "1", "--length", "60"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected args %v, but got %v", want, got)
+	}
+	if got, want := attack.Attack.Target, (gremlin.Target{Type: gremlin.TargetRandom, Tags: map[string]string{"service": "checkout"}, Percent: 10}); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected target %+v, but got %+v", want, got)
+	}
+
+	template := docs[1].Template
+	if got, want := template.Target.Exact, []string{"cache-1", "cache-2"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected exact hosts %v, but got %v", want, got)
+	}
+	if got, want := template.Name, "cache-latency"; got != want {
+		t.Errorf("Expected template name %q, but got %q", want, got)
+	}
+
+	trigger := docs[2].Schedule.Trigger
+	if got, want := trigger, (gremlin.ScheduleTrigger{Days: []string{"M", "T", "W", "Th", "F"}, Start: "10:00", End: "16:00", TimeZone: "Europe/London", MaxRuns: 2}); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected trigger %+v, but got %+v", want, got)
+	}
+
+	steps := docs[3].Scenario.Steps
+	if got, want := len(steps), 2; got != want {
+		t.Fatalf("Expected %d steps, but got %d", want, got)
+	}
+	if got, want := steps[1].Delay, 300; got != want {
+		t.Errorf("Expected delay of %d seconds, but got %d", want, got)
+	}
+}
+
+func TestParseInterpolation(t *testing.T) {
+	data := `
+apiVersion: gremlin/v1
+kind: Attack
+metadata:
+  name: ${NAME}-cpu
+spec:
+  command:
+    type: ${TYPE:-cpu}
+    args: ["--cost", "$$5"]
+  target:
+    type: Random
+    count: ${COUNT}
+`
+	docs, err := Parse("attack.yaml", []byte(data), lookupMap(map[string]string{"NAME": "web", "COUNT": "3"}))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	ac := docs[0].Attack
+	if got, want := docs[0].Name, "web-cpu"; got != want {
+		t.Errorf("Expected name %q, but got %q", want, got)
+	}
+	if got, want := ac.Command.Type, "cpu"; got != want {
+		t.Errorf("Expected default type %q, but got %q", want, got)
+	}
+	if got, want := ac.Command.Args[1], "$5"; got != want {
+		t.Errorf("Expected escaped dollar %q, but got %q", want, got)
+	}
+	if got, want := ac.Target.Count, 3; got != want {
+		t.Errorf("Expected interpolated count %d, but got %d", want, got)
+	}
+}
+
+func TestParseValidationErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want []string
+	}{
+		{
+			name: "document",
+			data: "apiVersion: gremlin/v2\nkind: Attack\nspec: {}\nextra: true\n",
+			want: []string{
+				`x.yaml:1:13: apiVersion: unknown apiVersion "gremlin/v2"`,
+				"x.yaml:4:1: extra: unknown field",
+				"x.yaml:1:1: metadata: required field is missing",
+				"x.yaml:3:7: spec.command: required field is missing",
+			},
+		},
+		{
+			name: "attack body",
+			data: `apiVersion: gremlin/v1
+kind: Attack
+metadata: {name: a}
+spec:
+  command: {type: cpus, args: -c}
+  selector: random(
+  target: {type: Exact, count: lots}
+  labels: [a]
+`,
+			want: []string{
+				`x.yaml:5:19: spec.command.type: unknown attack type "cpus"`,
+				"x.yaml:5:31: spec.command.args: expected a list of strings",
+				"x.yaml:6:13: spec.selector: Invalid selector at column 8",
+				"x.yaml:7:32: spec.target.count: expected an integer",
+				"x.yaml:8:11: spec.labels: expected a map of strings",
+				"x.yaml:5:3: spec: selector and target are mutually exclusive",
+			},
+		},
+		{
+			name: "schedule and scenario",
+			data: `apiVersion: gremlin/v1
+kind: Schedule
+metadata: {name: s}
+spec:
+  command: {type: cpu}
+  trigger: {days: [M], start: "25:00"}
+---
+apiVersion: gremlin/v1
+kind: Scenario
+metadata: {name: s}
+spec:
+  steps:
+    - command: {type: cpu}
+      delay: soon
+`,
+			want: []string{
+				`x.yaml:6:31: spec.trigger.start: invalid time of day "25:00"`,
+				"x.yaml:6:12: spec.trigger.end: required field is missing",
+				"x.yaml:14:14: spec.steps[0].delay: expected a duration",
+			},
+		},
+		{
+			name: "probes",
+			data: `apiVersion: gremlin/v1
+kind: Attack
+metadata: {name: a}
+spec:
+  command: {type: cpu}
+  probes:
+    - tcp: {address: "db:5432"}
+      http: {url: "http://shop"}
+    - prometheus: {url: "http://prom", query: up, condition: "about 1"}
+    - http: {body: "("}
+`,
+			want: []string{
+				"x.yaml:7:7: spec.probes[0]: expected exactly one of: http, tcp, dns, exec, prometheus",
+				`x.yaml:9:62: spec.probes[1].prometheus.condition: Invalid condition "about 1"`,
+				"x.yaml:10:20: spec.probes[2].http.body: error parsing regexp",
+				"x.yaml:10:13: spec.probes[2].http.url: required field is missing",
+			},
+		},
+		{
+			name: "undefined variable",
+			data: "apiVersion: gremlin/v1\nkind: Attack\nmetadata: {name: \"${WHO}\"}\nspec: {command: {type: cpu}}\n",
+			want: []string{"x.yaml:3:18: undefined variable WHO"},
+		},
+		{
+			name: "syntax",
+			data: "apiVersion: gremlin/v1\nkind: Attack\nmetadata:\n\tname: a\n",
+			want: []string{"x.yaml:4: found character that cannot start any token"},
+		},
+	}
+
+	for _, tt := range tests {
+		_, err := Parse("x.yaml", []byte(tt.data), nil)
+		verr, ok := err.(*ValidationError)
+		if !ok {
+			t.Errorf("%s: expected a *ValidationError, but got %v", tt.name, err)
+			continue
+		}
+		if got, want := len(verr.Errors), len(tt.want); got != want {
+			t.Errorf("%s: expected %d errors, but got %d:\n%v", tt.name, want, got, verr)
+			continue
+		}
+		for i, fe := range verr.Errors {
+			if !strings.HasPrefix(fe.Error(), tt.want[i]) {
+				t.Errorf("%s: expected error %d to start with %q, but got %q", tt.name, i, tt.want[i], fe.Error())
+			}
+		}
+	}
+}
+
+func TestApply(t *testing.T) {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	mux.HandleFunc("/users/auth", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"org_name":"Test Org","header":"Bearer fake-token"}]`)
+	})
+
+	var created []string
+	mux.HandleFunc("/attacks/new", func(w http.ResponseWriter, r *http.Request) {
+		created = append(created, "attack")
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprint(w, "123e4567-e89b-12d3-a456-426655440000")
+	})
+	mux.HandleFunc("/templates", func(w http.ResponseWriter, r *http.Request) {
+		var tmpl gremlin.Template
+		json.NewDecoder(r.Body).Decode(&tmpl)
+		created = append(created, "template "+tmpl.Name)
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprint(w, `{"guid":"t-1"}`)
+	})
+	mux.HandleFunc("/schedules/attacks", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	})
+
+	client := gremlin.NewClient("Test Org", "user@domain.com", "secret", gremlin.WithURL(server.URL))
+	if _, err := client.Authenticate(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	docs, err := Load("testdata/experiments.yaml")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	results, err := Apply(context.Background(), client, docs)
+	if err == nil || !strings.Contains(err.Error(), `Schedule "weekday-blackhole" (testdata/experiments.yaml:26)`) {
+		t.Errorf("Expected the schedule to fail, but got %v", err)
+	}
+	if got, want := created, []string{"attack", "template cache-latency"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v to be created, but got %v", want, got)
+	}
+	if got, want := len(results), 2; got != want {
+		t.Fatalf("Expected %d results, but got %d", want, got)
+	}
+	if got, want := results[1].GUID, "t-1"; got != want {
+		t.Errorf("Expected template guid %q, but got %q", want, got)
+	}
+}
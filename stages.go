@@ -0,0 +1,50 @@
+package gremlin
+
+// AttackStage is the lifecycle stage of an attack or of one of its executions.
+type AttackStage string
+
+// Stages reported by the Gremlin API
+const (
+	StagePending              AttackStage = "Pending"
+	StageDistributed          AttackStage = "Distributed"
+	StageInitializing         AttackStage = "Initializing"
+	StageRunning              AttackStage = "Running"
+	StageRollbackStarted      AttackStage = "RollbackStarted"
+	StageHaltDistributed      AttackStage = "HaltDistributed"
+	StageSuccessful           AttackStage = "Successful"
+	StageHalted               AttackStage = "Halted"
+	StageUserHalted           AttackStage = "UserHalted"
+	StageFailed               AttackStage = "Failed"
+	StageInitializationFailed AttackStage = "InitializationFailed"
+	StageTargetNotFound       AttackStage = "TargetNotFound"
+	StageClientAborted        AttackStage = "ClientAborted"
+	StageLostCommunication    AttackStage = "LostCommunication"
+	StageTeardownFailed       AttackStage = "TeardownFailed"
+	StageInvalidArgs          AttackStage = "InvalidArgs"
+)
+
+// IsTerminal reports whether the stage is final, i.e. the attack will make no
+// further progress.
+func (s AttackStage) IsTerminal() bool {
+	switch s {
+	case StageSuccessful, StageHalted, StageUserHalted:
+		return true
+	}
+	return s.IsFailure()
+}
+
+// IsFailure reports whether the stage is a terminal stage in which the attack
+// did not complete or was not halted cleanly.
+func (s AttackStage) IsFailure() bool {
+	switch s {
+	case StageFailed, StageInitializationFailed, StageTargetNotFound, StageClientAborted,
+		StageLostCommunication, StageTeardownFailed, StageInvalidArgs:
+		return true
+	}
+	return false
+}
+
+// IsHalted reports whether the attack was stopped before its length expired.
+func (s AttackStage) IsHalted() bool {
+	return s == StageHalted || s == StageUserHalted
+}
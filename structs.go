@@ -1,10 +1,14 @@
 package gremlin
 
-import "time"
+import (
+	"time"
 
-// accessToken represents the object returned by the Gremlin API when making an
+	uuid "github.com/satori/go.uuid"
+)
+
+// AccessToken represents the object returned by the Gremlin API when making an
 // authentication request.
-type accessToken struct {
+type AccessToken struct {
 	ID               string    `json:"identifier"`
 	Header           string    `json:"header"`
 	OrganizationID   string    `json:"org_id"`
@@ -15,10 +19,16 @@ type accessToken struct {
 	ExpiresAt        time.Time `json:"expires_at"`
 }
 
+// AttackTypes lists every value Gremlin accepts for Command.Type
+var AttackTypes = []string{
+	"blackhole", "cpu", "io", "latency", "memory", "packet_loss",
+	"shutdown", "dns", "time_travel", "disk", "process_killer",
+}
+
 // Attack command details
 type Command struct {
 	// Type should be one of the following: blackhole, cpu, io, latency, memory,
-	// package_loss, shutdown, dns, time_travel, disk, process_killer
+	// packet_loss, shutdown, dns, time_travel, disk, process_killer
 	Type string `json:"type"`
 
 	// Args supplied to the command should be identical to those passed to the CLI
@@ -26,6 +36,12 @@ type Command struct {
 	Args []string `json:"args,omitempty"`
 }
 
+// Supported values for Target.Type
+const (
+	TargetExact  = "Exact"
+	TargetRandom = "Random"
+)
+
 // Attack target details
 type Target struct {
 	// Type should either be "Random" or "Exact"
@@ -36,6 +52,15 @@ type Target struct {
 
 	// Tags restrict an attack only to hosts with the corresponding kv tags.
 	Tags map[string]string `json:"tags,omitempty"`
+
+	// Percent of matching hosts to select when Type is "Random"
+	Percent int `json:"percent,omitempty"`
+
+	// Count of matching hosts to select when Type is "Random"
+	Count int `json:"count,omitempty"`
+
+	// Exclude lists hosts that must never be selected by a "Random" target
+	Exclude []string `json:"exclude,omitempty"`
 }
 
 // Encapsulates the details required to launch an attack
@@ -46,3 +71,123 @@ type AttackCommand struct {
 	// Labels are used to target Docker containers running on target hosts
 	Labels map[string]string `json:"labels,omitempty"`
 }
+
+// Supported values for Host.State
+const (
+	HostActive = "ACTIVE"
+	HostIdle   = "IDLE"
+)
+
+// Host is a machine running the Gremlin daemon. The Gremlin API refers to
+// these as "clients".
+type Host struct {
+	Identifier string            `json:"identifier"`
+	State      string            `json:"state"`
+	Tags       map[string]string `json:"tags,omitempty"`
+	Version    string            `json:"version,omitempty"`
+	LastActive time.Time         `json:"last_active,omitempty"`
+}
+
+// Container is a Docker container reported by a Host.
+type Container struct {
+	Identifier string            `json:"identifier"`
+	Name       string            `json:"name"`
+	HostID     string            `json:"client_id"`
+	Labels     map[string]string `json:"labels,omitempty"`
+}
+
+// Attack is the state of a launched attack as reported by the Gremlin API.
+type Attack struct {
+	Guid           uuid.UUID   `json:"guid"`
+	Stage          AttackStage `json:"stage"`
+	StageLifecycle string      `json:"stage_lifecycle,omitempty"`
+	Command        Command     `json:"command"`
+	Target         Target      `json:"target"`
+	CreateUser     string      `json:"create_user,omitempty"`
+	CreatedAt      time.Time   `json:"created_at"`
+	UpdatedAt      time.Time   `json:"updated_at"`
+	StartTime      time.Time   `json:"start_time,omitempty"`
+	EndTime        time.Time   `json:"end_time,omitempty"`
+}
+
+// Execution is the progress of an attack on a single host (or container).
+type Execution struct {
+	Guid        string      `json:"guid"`
+	AttackID    uuid.UUID   `json:"task_id"`
+	HostID      string      `json:"client_id"`
+	ContainerID string      `json:"container_id,omitempty"`
+	Stage       AttackStage `json:"stage"`
+	Error       string      `json:"error,omitempty"`
+	Output      string      `json:"output,omitempty"`
+	CreatedAt   time.Time   `json:"created_at"`
+	StartTime   time.Time   `json:"start_time,omitempty"`
+	EndTime     time.Time   `json:"end_time,omitempty"`
+}
+
+// Template is a saved attack definition that can be launched repeatedly.
+type Template struct {
+	Guid        string            `json:"guid,omitempty"`
+	Name        string            `json:"name"`
+	Description string            `json:"description,omitempty"`
+	Command     Command           `json:"command"`
+	Target      Target            `json:"target"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	CreatedAt   time.Time         `json:"created_at,omitempty"`
+
+	// Annotations are free-form key/values stored with the template, such as
+	// the tool that manages it. Gremlin does not interpret them.
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// Schedule launches an attack automatically within a recurring time window.
+type Schedule struct {
+	Guid    string            `json:"guid,omitempty"`
+	Name    string            `json:"name"`
+	Command Command           `json:"command"`
+	Target  Target            `json:"target"`
+	Labels  map[string]string `json:"labels,omitempty"`
+	Trigger ScheduleTrigger   `json:"trigger"`
+
+	// Annotations are free-form key/values stored with the schedule, such as
+	// the tool that manages it. Gremlin does not interpret them.
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// ScheduleTrigger is the window in which Gremlin picks random times to run a
+// scheduled attack.
+type ScheduleTrigger struct {
+	// Days are the weekdays on which the attack may run, e.g. "M", "T", "W"
+	Days []string `json:"activeDays"`
+
+	// Start and End are "15:04" wall-clock times in TimeZone
+	Start    string `json:"start"`
+	End      string `json:"end"`
+	TimeZone string `json:"timeZone,omitempty"`
+
+	// MaxRuns is the most times the attack runs per day
+	MaxRuns int `json:"maxRuns,omitempty"`
+}
+
+// Scenario is an ordered series of attacks run one after another.
+type Scenario struct {
+	Guid        string         `json:"guid,omitempty"`
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	Steps       []ScenarioStep `json:"steps"`
+}
+
+// ScenarioStep is one attack of a Scenario, started Delay seconds after the
+// previous step finishes.
+type ScenarioStep struct {
+	Command Command           `json:"command"`
+	Target  Target            `json:"target"`
+	Labels  map[string]string `json:"labels,omitempty"`
+	Delay   int               `json:"delay,omitempty"`
+}
+
+// ScenarioRun identifies a single run of a Scenario.
+type ScenarioRun struct {
+	Guid       string      `json:"guid"`
+	ScenarioID string      `json:"scenario_id"`
+	Stage      AttackStage `json:"stage,omitempty"`
+}
@@ -0,0 +1,39 @@
+package gremlin
+
+import (
+	"context"
+	"net/http"
+)
+
+// ListTemplates returns the attack templates saved in the organization.
+func (c *Client) ListTemplates(ctx context.Context) ([]Template, error) {
+	var templates []Template
+	if err := c.getJSON(ctx, "templates", &templates); err != nil {
+		return nil, err
+	}
+	return templates, nil
+}
+
+// CreateTemplate saves a new attack template and returns it as stored by
+// Gremlin, including its GUID.
+func (c *Client) CreateTemplate(ctx context.Context, t Template) (*Template, error) {
+	created := &Template{}
+	if err := c.sendJSON(ctx, "POST", "templates", t, http.StatusCreated, created); err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+// DeleteTemplate removes a saved attack template.
+func (c *Client) DeleteTemplate(ctx context.Context, guid string) error {
+	return c.deleteResource(ctx, "templates/"+guid)
+}
+
+// UpdateTemplate replaces the saved template with the same GUID.
+func (c *Client) UpdateTemplate(ctx context.Context, t Template) (*Template, error) {
+	updated := &Template{}
+	if err := c.sendJSON(ctx, "PUT", "templates/"+t.Guid, t, http.StatusOK, updated); err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
@@ -0,0 +1,60 @@
+package gremlin
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestListTemplatesSuccess(t *testing.T) {
+	mux, client, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/templates", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		testHeader(t, r, "Authorization", "Bearer fake-token")
+
+		fmt.Fprint(w, `[{"guid":"t-1","name":"cpu spike","command":{"type":"cpu","args":["-c","1"]},"target":{"type":"Random"}}]`)
+	})
+
+	templates, err := client.ListTemplates(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if got, want := len(templates), 1; got != want {
+		t.Fatalf("Expected %d template, but got %d", want, got)
+	}
+	if got, want := templates[0].Command.Type, "cpu"; got != want {
+		t.Errorf("Expected command type %q, but got %q", want, got)
+	}
+}
+
+func TestCreateAndDeleteTemplate(t *testing.T) {
+	mux, client, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/templates", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+		testHeader(t, r, "Content-Type", "application/json")
+
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprint(w, `{"guid":"t-1","name":"cpu spike"}`)
+	})
+	mux.HandleFunc("/templates/t-1", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "DELETE")
+	})
+
+	created, err := client.CreateTemplate(context.Background(), Template{Name: "cpu spike", Command: Command{Type: "cpu"}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got, want := created.Guid, "t-1"; got != want {
+		t.Errorf("Expected guid %q, but got %q", want, got)
+	}
+
+	if err := client.DeleteTemplate(context.Background(), created.Guid); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
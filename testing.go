@@ -14,11 +14,11 @@ type accessTokenBuilder builder.Builder
 func (b accessTokenBuilder) OrganizationName(orgName string) accessTokenBuilder {
 	return builder.Set(b, "OrganizationName", orgName).(accessTokenBuilder)
 }
-func (b accessTokenBuilder) Build() accessToken {
-	return builder.GetStruct(b).(accessToken)
+func (b accessTokenBuilder) Build() AccessToken {
+	return builder.GetStruct(b).(AccessToken)
 }
 func buildDefaultAccessToken() accessTokenBuilder {
-	b := builder.Register(accessTokenBuilder{}, accessToken{}).(accessTokenBuilder)
+	b := builder.Register(accessTokenBuilder{}, AccessToken{}).(accessTokenBuilder)
 	b = builder.Set(b, "ID", "fake-id").(accessTokenBuilder)
 	b = builder.Set(b, "Header", "fake-header").(accessTokenBuilder)
 	b = builder.Set(b, "OrganizationID", "fake-org-id").(accessTokenBuilder)
@@ -31,7 +31,7 @@ func buildDefaultAccessToken() accessTokenBuilder {
 }
 
 // Mock configuration for auth
-func mockSucessAuth(url string, tokenArr []accessToken) {
+func mockSucessAuth(url string, tokenArr []AccessToken) {
 	httpmock.Activate()
 
 	authResponse, _ := json.Marshal(tokenArr)
@@ -0,0 +1,113 @@
+package gremlin
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+// WaitOptions controls how WaitForAttack polls. Zero values use the defaults.
+type WaitOptions struct {
+	// Interval is the delay before the first re-poll (default 2s).
+	Interval time.Duration
+
+	// MaxInterval caps the delay between polls (default 30s).
+	MaxInterval time.Duration
+
+	// Multiplier grows the delay after every poll (default 1.5).
+	Multiplier float64
+}
+
+func (o WaitOptions) withDefaults() WaitOptions {
+	if o.Interval <= 0 {
+		o.Interval = 2 * time.Second
+	}
+	if o.MaxInterval <= 0 {
+		o.MaxInterval = 30 * time.Second
+	}
+	if o.MaxInterval < o.Interval {
+		o.MaxInterval = o.Interval
+	}
+	if o.Multiplier < 1 {
+		o.Multiplier = 1.5
+	}
+	return o
+}
+
+// AttackFailedError is returned by WaitForAttack when an attack ends in a
+// failure stage. Failures holds the executions that did not succeed.
+type AttackFailedError struct {
+	Attack   *Attack
+	Failures []Execution
+}
+
+func (e *AttackFailedError) Error() string {
+	var hosts []string
+	for _, ex := range e.Failures {
+		host := ex.HostID
+		if ex.ContainerID != "" {
+			host += "/" + ex.ContainerID
+		}
+		msg := fmt.Sprintf("%s: %s", host, ex.Stage)
+		if ex.Error != "" {
+			msg += " (" + ex.Error + ")"
+		}
+		hosts = append(hosts, msg)
+	}
+
+	if len(hosts) == 0 {
+		return fmt.Sprintf("Attack %s ended in stage %s", e.Attack.Guid, e.Attack.Stage)
+	}
+	return fmt.Sprintf("Attack %s ended in stage %s: %s", e.Attack.Guid, e.Attack.Stage, strings.Join(hosts, "; "))
+}
+
+// WaitForAttack polls an attack with exponential backoff until it reaches a
+// terminal stage, then returns it along with its per-host executions. If the
+// attack ends in a failure stage the error is an *AttackFailedError; the
+// attack and executions are returned as well.
+func (c *Client) WaitForAttack(ctx context.Context, guid uuid.UUID, opts WaitOptions) (*Attack, []Execution, error) {
+	opts = opts.withDefaults()
+	delay := opts.Interval
+
+	for {
+		attack, err := c.GetAttack(ctx, guid)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if attack.Stage.IsTerminal() {
+			executions, err := c.ListExecutions(ctx, guid)
+			if err != nil {
+				return attack, nil, err
+			}
+
+			if attack.Stage.IsFailure() {
+				failed := &AttackFailedError{Attack: attack}
+				for _, ex := range executions {
+					if ex.Stage != StageSuccessful && !ex.Stage.IsHalted() {
+						failed.Failures = append(failed.Failures, ex)
+					}
+				}
+				return attack, executions, failed
+			}
+
+			return attack, executions, nil
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return attack, nil, ctx.Err()
+		case <-timer.C:
+		}
+
+		delay = time.Duration(float64(delay) * opts.Multiplier)
+		if delay > opts.MaxInterval {
+			delay = opts.MaxInterval
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package gremlin
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+var fastWait = WaitOptions{Interval: time.Millisecond, MaxInterval: 5 * time.Millisecond}
+
+func TestAttackStageIsTerminal(t *testing.T) {
+	tests := []struct {
+		stage    AttackStage
+		terminal bool
+		failure  bool
+	}{
+		{StagePending, false, false},
+		{StageRunning, false, false},
+		{StageHaltDistributed, false, false},
+		{StageSuccessful, true, false},
+		{StageUserHalted, true, false},
+		{StageFailed, true, true},
+		{StageLostCommunication, true, true},
+	}
+
+	for _, tt := range tests {
+		if got := tt.stage.IsTerminal(); got != tt.terminal {
+			t.Errorf("%s.IsTerminal() = %v, want %v", tt.stage, got, tt.terminal)
+		}
+		if got := tt.stage.IsFailure(); got != tt.failure {
+			t.Errorf("%s.IsFailure() = %v, want %v", tt.stage, got, tt.failure)
+		}
+	}
+}
+
+func TestWaitForAttackSuccess(t *testing.T) {
+	mux, client, teardown := setup()
+	defer teardown()
+
+	guid := uuid.Must(uuid.FromString("123e4567-e89b-12d3-a456-426655440000"))
+	stages := []AttackStage{StagePending, StageRunning, StageSuccessful}
+	polls := 0
+
+	mux.HandleFunc("/attacks/"+guid.String(), func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprintf(w, `{"guid":%q,"stage":%q}`, guid, stages[polls])
+		polls++
+	})
+	mux.HandleFunc("/executions", func(w http.ResponseWriter, r *http.Request) {
+		if got, want := r.URL.Query().Get("taskId"), guid.String(); got != want {
+			t.Errorf("Expected taskId %q, but got %q", want, got)
+		}
+		fmt.Fprint(w, `[{"client_id":"web-1","stage":"Successful"}]`)
+	})
+
+	attack, executions, err := client.WaitForAttack(context.Background(), guid, fastWait)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if got, want := attack.Stage, StageSuccessful; got != want {
+		t.Errorf("Expected final stage %s, but got %s", want, got)
+	}
+	if got, want := polls, 3; got != want {
+		t.Errorf("Expected %d polls, but got %d", want, got)
+	}
+	if got, want := len(executions), 1; got != want {
+		t.Errorf("Expected %d execution, but got %d", want, got)
+	}
+}
+
+func TestWaitForAttackFailure(t *testing.T) {
+	mux, client, teardown := setup()
+	defer teardown()
+
+	guid := uuid.Must(uuid.FromString("123e4567-e89b-12d3-a456-426655440000"))
+
+	mux.HandleFunc("/attacks/"+guid.String(), func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"guid":%q,"stage":"Failed"}`, guid)
+	})
+	mux.HandleFunc("/executions", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[
+			{"client_id":"web-1","stage":"Successful"},
+			{"client_id":"web-2","stage":"LostCommunication","error":"no heartbeat"}
+		]`)
+	})
+
+	_, executions, err := client.WaitForAttack(context.Background(), guid, fastWait)
+
+	ferr, ok := err.(*AttackFailedError)
+	if !ok {
+		t.Fatalf("Expected *AttackFailedError, but got %v", err)
+	}
+	if got, want := len(executions), 2; got != want {
+		t.Errorf("Expected %d executions, but got %d", want, got)
+	}
+	if got, want := len(ferr.Failures), 1; got != want {
+		t.Fatalf("Expected %d failure, but got %d", want, got)
+	}
+	if got, want := ferr.Error(), "web-2: LostCommunication (no heartbeat)"; !strings.Contains(got, want) {
+		t.Errorf("Expected error to match %q, but got %q", want, got)
+	}
+}
+
+func TestWaitForAttackWithCancelledContext(t *testing.T) {
+	mux, client, teardown := setup()
+	defer teardown()
+
+	guid := uuid.Must(uuid.FromString("123e4567-e89b-12d3-a456-426655440000"))
+
+	mux.HandleFunc("/attacks/"+guid.String(), func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"guid":%q,"stage":"Running"}`, guid)
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	attack, _, err := client.WaitForAttack(ctx, guid, fastWait)
+
+	if err == nil || !strings.Contains(err.Error(), "deadline exceeded") {
+		t.Errorf("Expected deadline exceeded, but got %v", err)
+	}
+	if err == context.DeadlineExceeded && attack.Stage != StageRunning {
+		t.Errorf("Expected last seen attack to be returned, but got %+v", attack)
+	}
+}
@@ -0,0 +1,295 @@
+package gremlin
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+// EventType identifies what happened in an Event.
+type EventType string
+
+// Events emitted by a Watcher
+const (
+	EventAttackCreated     EventType = "created"
+	EventStageChanged      EventType = "stage_changed"
+	EventExecutionStarted  EventType = "execution_started"
+	EventExecutionFinished EventType = "execution_finished"
+	EventAttackHalted      EventType = "halted"
+)
+
+// Event is a change observed by a Watcher.
+type Event struct {
+	Type EventType
+	Time time.Time
+
+	// Attack is the state of the attack when the event was observed.
+	Attack Attack
+
+	// PreviousStage is set for EventStageChanged when the earlier stage was seen.
+	PreviousStage AttackStage
+
+	// Execution is set for the execution events.
+	Execution *Execution
+}
+
+// WatcherOptions configures a Watcher. Zero values use the defaults.
+type WatcherOptions struct {
+	// Interval between polls (default 5s).
+	Interval time.Duration
+
+	// Since is a checkpoint: only events that happened strictly after it are
+	// emitted. Pass the Checkpoint of a previous Watcher to resume without
+	// duplicates, or time.Now() to only see new activity.
+	Since time.Time
+
+	// Executions enables the per-host execution events, which costs one
+	// extra request per attack that is active or has just finished.
+	Executions bool
+
+	// OnError is called with errors from individual polls. Polling continues
+	// after an error.
+	OnError func(error)
+}
+
+// Watcher turns the active and completed attack endpoints into a stream of
+// de-duplicated events.
+type Watcher struct {
+	client *Client
+	opts   WatcherOptions
+	events chan Event
+
+	mu         sync.Mutex
+	checkpoint time.Time
+
+	stages     map[uuid.UUID]AttackStage
+	executions map[uuid.UUID]map[string]executionState
+	settled    map[uuid.UUID]time.Time // when each settled attack last changed
+	delivered  map[uuid.UUID]map[eventKey]bool
+
+	// forgotten is the latest change of a settled attack that was pruned;
+	// a finished attack no newer than it is not reported again
+	forgotten time.Time
+}
+
+type executionState struct {
+	started, finished bool
+}
+
+// eventKey identifies an event of an attack for de-duplication: the type
+// and, for stage and execution events, the stage or host it is about.
+type eventKey struct {
+	typ    EventType
+	detail string
+}
+
+func keyOf(e Event) eventKey {
+	k := eventKey{typ: e.Type}
+	switch {
+	case e.Execution != nil:
+		k.detail = e.Execution.HostID + "/" + e.Execution.ContainerID
+	case e.Type == EventStageChanged:
+		k.detail = string(e.Attack.Stage)
+	}
+	return k
+}
+
+// NewWatcher creates a Watcher. Nothing is polled until Run is called.
+func (c *Client) NewWatcher(opts WatcherOptions) *Watcher {
+	if opts.Interval <= 0 {
+		opts.Interval = 5 * time.Second
+	}
+
+	return &Watcher{
+		client:     c,
+		opts:       opts,
+		events:     make(chan Event, 64),
+		checkpoint: opts.Since,
+		stages:     make(map[uuid.UUID]AttackStage),
+		executions: make(map[uuid.UUID]map[string]executionState),
+		settled:    make(map[uuid.UUID]time.Time),
+		delivered:  make(map[uuid.UUID]map[eventKey]bool),
+	}
+}
+
+// Events returns the channel events are delivered on. It is closed when Run
+// returns.
+func (w *Watcher) Events() <-chan Event {
+	return w.events
+}
+
+// Checkpoint returns the time of the latest event delivered so far.
+func (w *Watcher) Checkpoint() time.Time {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.checkpoint
+}
+
+// Run polls until the context is cancelled and returns the context error.
+func (w *Watcher) Run(ctx context.Context) error {
+	defer close(w.events)
+
+	ticker := time.NewTicker(w.opts.Interval)
+	defer ticker.Stop()
+
+	for {
+		events, err := w.poll(ctx)
+		if err != nil && ctx.Err() == nil && w.opts.OnError != nil {
+			w.opts.OnError(err)
+		}
+
+		for _, e := range events {
+			select {
+			case w.events <- e:
+				w.mu.Lock()
+				if e.Time.After(w.checkpoint) {
+					w.checkpoint = e.Time
+				}
+				w.mu.Unlock()
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// poll fetches the current attacks and diffs them against what was seen
+// before, returning new events in time order.
+func (w *Watcher) poll(ctx context.Context) ([]Event, error) {
+	active, err := w.client.ListActiveAttacks(ctx)
+	if err != nil {
+		return nil, err
+	}
+	completed, err := w.client.ListCompletedAttacks(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	w.prune(active, completed)
+
+	// events of one attack may be reported after newer events of another,
+	// so they are only filtered by Since and by what was delivered
+	var events []Event
+	emit := func(e Event) {
+		if !w.opts.Since.IsZero() && !e.Time.After(w.opts.Since) {
+			return
+		}
+		delivered := w.delivered[e.Attack.Guid]
+		if delivered == nil {
+			delivered = make(map[eventKey]bool)
+			w.delivered[e.Attack.Guid] = delivered
+		}
+		if k := keyOf(e); !delivered[k] {
+			delivered[k] = true
+			events = append(events, e)
+		}
+	}
+
+	for _, attack := range append(active, completed...) {
+		if _, ok := w.settled[attack.Guid]; ok {
+			continue
+		}
+
+		prev, seen := w.stages[attack.Guid]
+		if !seen && attack.Stage.IsTerminal() && !attack.UpdatedAt.After(w.forgotten) {
+			// listed again after it was pruned
+			w.settled[attack.Guid] = attack.UpdatedAt
+			continue
+		}
+		if !seen {
+			emit(Event{Type: EventAttackCreated, Time: attack.CreatedAt, Attack: attack})
+		}
+		if attack.Stage != prev && (seen || attack.Stage != StagePending) {
+			emit(Event{Type: EventStageChanged, Time: attack.UpdatedAt, Attack: attack, PreviousStage: prev})
+			if attack.Stage.IsHalted() {
+				emit(Event{Type: EventAttackHalted, Time: attack.UpdatedAt, Attack: attack})
+			}
+		}
+		w.stages[attack.Guid] = attack.Stage
+
+		finished := true
+		if w.opts.Executions {
+			executions, err := w.client.ListExecutions(ctx, attack.Guid)
+			if err != nil {
+				return sortEvents(events), err
+			}
+			for i := range executions {
+				for _, e := range w.executionEvents(attack, &executions[i]) {
+					emit(e)
+				}
+				finished = finished && executions[i].Stage.IsTerminal()
+			}
+		}
+
+		// a terminal attack needs no further polling once every one of its
+		// executions has finished too
+		if attack.Stage.IsTerminal() && finished {
+			w.settled[attack.Guid] = attack.UpdatedAt
+		}
+	}
+
+	return sortEvents(events), nil
+}
+
+// prune forgets settled attacks that are no longer listed, so that a
+// long-running watcher does not grow without bound.
+func (w *Watcher) prune(active, completed []Attack) {
+	listed := make(map[uuid.UUID]bool)
+	for _, a := range append(active, completed...) {
+		listed[a.Guid] = true
+	}
+
+	for guid, updated := range w.settled {
+		if listed[guid] {
+			continue
+		}
+		if updated.After(w.forgotten) {
+			w.forgotten = updated
+		}
+		delete(w.settled, guid)
+		delete(w.stages, guid)
+		delete(w.executions, guid)
+		delete(w.delivered, guid)
+	}
+}
+
+func (w *Watcher) executionEvents(attack Attack, ex *Execution) []Event {
+	if w.executions[attack.Guid] == nil {
+		w.executions[attack.Guid] = make(map[string]executionState)
+	}
+	key := ex.HostID + "/" + ex.ContainerID
+	st := w.executions[attack.Guid][key]
+
+	var events []Event
+	if !st.started && !ex.StartTime.IsZero() {
+		st.started = true
+		events = append(events, Event{Type: EventExecutionStarted, Time: ex.StartTime, Attack: attack, Execution: ex})
+	}
+	if !st.finished && ex.Stage.IsTerminal() {
+		st.finished = true
+		end := ex.EndTime
+		if end.IsZero() {
+			end = attack.UpdatedAt
+		}
+		events = append(events, Event{Type: EventExecutionFinished, Time: end, Attack: attack, Execution: ex})
+	}
+
+	w.executions[attack.Guid][key] = st
+	return events
+}
+
+func sortEvents(events []Event) []Event {
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Time.Before(events[j].Time)
+	})
+	return events
+}
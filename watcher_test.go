@@ -0,0 +1,230 @@
+package gremlin
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// watchScript serves a fixed sequence of poll results from the active,
+// completed and executions endpoints, repeating the last one forever.
+type watchScript struct {
+	mu    sync.Mutex
+	polls int
+	steps []struct{ active, completed, executions string }
+}
+
+func (s *watchScript) register(mux *http.ServeMux) {
+	step := func() int {
+		if s.polls >= len(s.steps) {
+			return len(s.steps) - 1
+		}
+		return s.polls
+	}
+
+	mux.HandleFunc("/attacks/active", func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		fmt.Fprint(w, s.steps[step()].active)
+	})
+	mux.HandleFunc("/attacks/completed", func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		fmt.Fprint(w, s.steps[step()].completed)
+	})
+	mux.HandleFunc("/executions", func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		fmt.Fprint(w, s.steps[step()].executions)
+		s.polls++
+	})
+}
+
+func newWatchScript() *watchScript {
+	attack := func(stage string, updated int) string {
+		return fmt.Sprintf(`[{"guid":"123e4567-e89b-12d3-a456-426655440000","stage":%q,`+
+			`"created_at":"2018-03-07T10:00:00Z","updated_at":"2018-03-07T10:00:%02dZ"}]`, stage, updated)
+	}
+
+	s := &watchScript{}
+	s.steps = append(s.steps,
+		struct{ active, completed, executions string }{attack("Pending", 0), "[]", "[]"},
+		struct{ active, completed, executions string }{attack("Running", 5), "[]",
+			`[{"client_id":"web-1","stage":"Running","start_time":"2018-03-07T10:00:04Z"}]`},
+		struct{ active, completed, executions string }{"[]", attack("UserHalted", 9),
+			`[{"client_id":"web-1","stage":"UserHalted","start_time":"2018-03-07T10:00:04Z","end_time":"2018-03-07T10:00:08Z"}]`},
+	)
+	return s
+}
+
+func collectEvents(t *testing.T, w *Watcher, n int) []EventType {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	done := make(chan struct{})
+	go func() {
+		w.Run(ctx)
+		close(done)
+	}()
+
+	var got []EventType
+	for e := range w.Events() {
+		got = append(got, e.Type)
+		if len(got) == n {
+			cancel()
+		}
+	}
+	<-done
+	return got
+}
+
+func TestWatcherEmitsDeduplicatedEvents(t *testing.T) {
+	mux, client, teardown := setup()
+	defer teardown()
+	newWatchScript().register(mux)
+
+	w := client.NewWatcher(WatcherOptions{Interval: time.Millisecond, Executions: true})
+	got := collectEvents(t, w, 6)
+
+	want := []EventType{
+		EventAttackCreated,
+		EventExecutionStarted,
+		EventStageChanged,
+		EventExecutionFinished,
+		EventStageChanged,
+		EventAttackHalted,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected events %v, but got %v", want, got)
+	}
+
+	if got, want := w.Checkpoint(), time.Date(2018, 3, 7, 10, 0, 9, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("Expected checkpoint %s, but got %s", want, got)
+	}
+}
+
+func TestWatcherResumesFromCheckpoint(t *testing.T) {
+	mux, client, teardown := setup()
+	defer teardown()
+	newWatchScript().register(mux)
+
+	since := time.Date(2018, 3, 7, 10, 0, 5, 0, time.UTC)
+	w := client.NewWatcher(WatcherOptions{Interval: time.Millisecond, Executions: true, Since: since})
+	got := collectEvents(t, w, 3)
+
+	want := []EventType{EventExecutionFinished, EventStageChanged, EventAttackHalted}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected events %v, but got %v", want, got)
+	}
+}
+
+func TestWatcherWaitsForExecutionsToFinish(t *testing.T) {
+	mux, client, teardown := setup()
+	defer teardown()
+
+	attack := `[{"guid":"123e4567-e89b-12d3-a456-426655440000","stage":"UserHalted",` +
+		`"created_at":"2018-03-07T10:00:00Z","updated_at":"2018-03-07T10:00:05Z"}]`
+	s := &watchScript{}
+	s.steps = append(s.steps,
+		// the attack is halted while web-1 is still stopping
+		struct{ active, completed, executions string }{"[]", attack,
+			`[{"client_id":"web-1","stage":"Running","start_time":"2018-03-07T10:00:01Z"}]`},
+		struct{ active, completed, executions string }{"[]", attack,
+			`[{"client_id":"web-1","stage":"UserHalted","start_time":"2018-03-07T10:00:01Z","end_time":"2018-03-07T10:00:07Z"}]`},
+	)
+	s.register(mux)
+
+	w := client.NewWatcher(WatcherOptions{Interval: time.Millisecond, Executions: true})
+	got := collectEvents(t, w, 5)
+
+	want := []EventType{
+		EventAttackCreated,
+		EventExecutionStarted,
+		EventStageChanged,
+		EventAttackHalted,
+		EventExecutionFinished,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected events %v, but got %v", want, got)
+	}
+}
+
+func TestWatcherForgetsSettledAttacks(t *testing.T) {
+	mux, client, teardown := setup()
+	defer teardown()
+
+	s := newWatchScript()
+	s.steps = append(s.steps, struct{ active, completed, executions string }{"[]", "[]", "[]"})
+	s.register(mux)
+
+	w := client.NewWatcher(WatcherOptions{Executions: true})
+	for i := 0; i < len(s.steps)-1; i++ {
+		if _, err := w.poll(context.Background()); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	if len(w.settled) != 1 {
+		t.Fatalf("Expected the halted attack to be settled, but got %v", w.settled)
+	}
+
+	// the attack drops off the completed list
+	if _, err := w.poll(context.Background()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(w.settled) != 0 || len(w.stages) != 0 || len(w.executions) != 0 || len(w.delivered) != 0 {
+		t.Errorf("Expected the finished attack to be forgotten, but got %d settled, %d stages, %d executions, %d delivered",
+			len(w.settled), len(w.stages), len(w.executions), len(w.delivered))
+	}
+}
+
+func TestWatcherKeepsLateEvents(t *testing.T) {
+	mux, client, teardown := setup()
+	defer teardown()
+
+	const (
+		guidA = "123e4567-e89b-12d3-a456-426655440000"
+		guidB = "123e4567-e89b-12d3-a456-426655440001"
+	)
+	attacks := fmt.Sprintf(`[{"guid":%q,"stage":"Running","created_at":"2018-03-07T10:00:00Z","updated_at":"2018-03-07T10:00:05Z"},`+
+		`{"guid":%q,"stage":"Running","created_at":"2018-03-07T10:00:20Z","updated_at":"2018-03-07T10:00:25Z"}]`, guidA, guidB)
+
+	var mu sync.Mutex
+	polls := 0
+	mux.HandleFunc("/attacks/active", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		polls++
+		fmt.Fprint(w, attacks)
+	})
+	mux.HandleFunc("/attacks/completed", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "[]")
+	})
+	mux.HandleFunc("/executions", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		// attack A's host reports in only after B's events were delivered
+		if r.URL.Query().Get("taskId") == guidA && polls > 1 {
+			fmt.Fprint(w, `[{"client_id":"web-1","stage":"Running","start_time":"2018-03-07T10:00:03Z"}]`)
+			return
+		}
+		fmt.Fprint(w, "[]")
+	})
+
+	w := client.NewWatcher(WatcherOptions{Interval: time.Millisecond, Executions: true})
+	got := collectEvents(t, w, 5)
+
+	want := []EventType{
+		EventAttackCreated,
+		EventStageChanged,
+		EventAttackCreated,
+		EventStageChanged,
+		EventExecutionStarted,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected events %v, but got %v", want, got)
+	}
+}